@@ -0,0 +1,50 @@
+package breaker
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+)
+
+//ReportBatch reports outcomes a caller accumulated locally since its last flush, e.g. a proxy flushing every few
+//milliseconds, with one update of the window each instead of one per request. requests are the requests passed
+//to backend, errors and successes their outcomes known so far and maxLatency the highest latency among them, 0 if
+//not measured. counts are as stale as the flush interval: the caller admits requests by Status meanwhile and
+//circuit breaker trips on the flush reaching the thresholds, and a half-open one closes on successes reaching
+//its close config. like ReportError, requests and errors of an open circuit breaker are not counted. it returns
+//ErrTooManyErrors if circuit breaker rejects requests after the batch
+func (c *CircuitBreaker) ReportBatch(requests, errors, successes uint32, maxLatency time.Duration) error {
+	if c.exited() {
+		return ErrCircuitBreakerClosed
+	}
+
+	mode := Mode(atomic.LoadInt32(&c.mode))
+	if requests > 0 && (mode == ModeForceClosed || mode != ModeForceOpen && c.Status() != CircuitBreakerStatusOpen) {
+		c.passRequests(requests)
+	}
+	if err := c.addErrorRequest(context.Background(), errors); err != nil {
+		return err
+	}
+	if successes > 0 {
+		c.metrics.IncrCounter(MetricSuccesses, successes)
+		//errors of the batch in half-open opened it again already
+		if status, gen := c.loadState(); status == CircuitBreakerStatusHalfOpen && mode != ModeForceOpen && mode != ModeForceClosed {
+			c.recover(gen, successes)
+		}
+	}
+	if maxLatency > 0 {
+		c.ReportLatency(maxLatency)
+	}
+
+	switch mode {
+	case ModeForceOpen:
+		return ErrTooManyErrors
+	case ModeForceClosed, ModeShadow:
+		return nil
+	}
+	if c.Status() == CircuitBreakerStatusOpen {
+		return ErrTooManyErrors
+	}
+
+	return nil
+}
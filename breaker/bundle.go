@@ -0,0 +1,117 @@
+package breaker
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+const bundleVersion = 1
+
+var errBundleVersion = errors.New("unsupported support bundle version")
+
+//Bundle everything known about circuit breakers of a registry at a point in time, for support tickets
+type Bundle struct {
+	Version  int             `json:"version"`
+	Created  time.Time       `json:"created"`
+	Host     string          `json:"host,omitempty"`
+	Breakers []BundleBreaker `json:"breakers"`
+}
+
+//BundleBreaker config, state and event history of a circuit breaker in a bundle
+type BundleBreaker struct {
+	Snapshot Snapshot `json:"snapshot"` //configs and counts
+	State    State    `json:"state"`
+	Decision Decision `json:"decision"`
+	Events   []Event  `json:"events,omitempty"` //oldest first, only if event history is kept
+}
+
+//Bundle returns a bundle of all circuit breakers in registry, sorted by name
+func (r *Registry) Bundle() *Bundle {
+	host, _ := os.Hostname()
+	b := &Bundle{Version: bundleVersion, Created: time.Now(), Host: host}
+
+	for _, name := range r.Names() {
+		cb, ok := r.Get(name)
+		if !ok {
+			continue
+		}
+
+		b.Breakers = append(b.Breakers, BundleBreaker{
+			Snapshot: cb.Snapshot(),
+			State:    cb.state(),
+			Decision: cb.Decision(),
+			Events:   cb.Events(),
+		})
+	}
+
+	return b
+}
+
+//WriteBundle writes a gzip compressed JSON bundle of all circuit breakers in registry to w, see ReadBundle
+func (r *Registry) WriteBundle(w io.Writer) error {
+	zw := gzip.NewWriter(w)
+	if err := json.NewEncoder(zw).Encode(r.Bundle()); err != nil {
+		return err
+	}
+
+	return zw.Close()
+}
+
+//ReadBundle reads a bundle written by WriteBundle
+func ReadBundle(rd io.Reader) (*Bundle, error) {
+	zr, err := gzip.NewReader(rd)
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+
+	var b Bundle
+	if err := json.NewDecoder(zr).Decode(&b); err != nil {
+		return nil, err
+	}
+	if b.Version != bundleVersion {
+		return nil, errBundleVersion
+	}
+
+	return &b, nil
+}
+
+//Load creates circuit breakers of bundle in r with the configs, state and event history they had,
+//to inspect them locally. loaded circuit breakers are not connected to anything, close them when done
+func (b *Bundle) Load(r *Registry) error {
+	var errs []error
+	for _, bb := range b.Breakers {
+		s := bb.Snapshot
+		size := len(bb.Events)
+		if size == 0 {
+			size = defaultEventHistorySize
+		}
+
+		cb := New(
+			WithName(s.Name),
+			WithOpenConfig(s.OpenConfig),
+			WithCloseConfig(s.CloseConfig),
+			WithSleepWindow(s.SleepWindow),
+			WithDecision(bb.Decision),
+			WithEventHistory(size),
+		)
+		for _, e := range bb.Events {
+			cb.history.add(e)
+		}
+
+		if err := cb.restore(bb.State); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", s.Name, err))
+		}
+		if err := r.Register(cb); err != nil {
+			cb.Close()
+			errs = append(errs, fmt.Errorf("%s: %w", s.Name, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
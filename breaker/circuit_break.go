@@ -1,19 +1,22 @@
 package breaker
 
 import (
+	"context"
 	"errors"
-	"fmt"
+	"log"
 	"sync/atomic"
 	"time"
 )
 
+const defaultSleepWindow = time.Minute * 3
+
 var (
 	defaultOpenConfig = CircuitBreakerOpenConfig{
 		RefreshInterval:        3 * time.Minute,
 		ErrorThresholdPercent:  20,
 		RequestVolumeThreshold: 1000,
 
-		errorVolumeThreshold: uint32(float32(1000) * (float32(20) / float32(100))),
+		errorVolumeThreshold: 1000 * 20 / 100,
 	}
 
 	defaultCloseConfig = CircuitBreakerCloseConfig{
@@ -23,8 +26,9 @@ var (
 )
 
 var (
-	errTooManyErrors        = errors.New("too many errors")
-	errCircuitBreakerClosed = errors.New("circult breaker is closed")
+	//ErrTooManyErrors is returned when circuit breaker is open
+	ErrTooManyErrors        = errors.New("too many errors")
+	ErrCircuitBreakerClosed = errors.New("circult breaker is closed")
 )
 
 var (
@@ -37,6 +41,20 @@ const (
 	CircuitBreakerStatusHalfOpen
 )
 
+//StatusText returns a text for the circuit breaker status
+func StatusText(status int32) string {
+	switch status {
+	case CircuitBreakerStatusClosed:
+		return "closed"
+	case CircuitBreakerStatusOpen:
+		return "open"
+	case CircuitBreakerStatusHalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
 const (
 	maxErrorThresholdPercent uint8 = 100
 	minErrorThresholdPercent uint8 = 5
@@ -51,33 +69,54 @@ type CircuitBreakerOpenConfig struct {
 	errorVolumeThreshold uint32 //RequestVolumeThreshold * (ErrorThresholdPercent / 100), use to accelerate compare when status is closed
 }
 
-//CircuitBreakerCloseConfig case in which circuit breaker turns to closed.
+//CircuitBreakerCloseConfig case in which circuit breaker turns to closed from half-open, see recover
 type CircuitBreakerCloseConfig struct {
-	RecoveryInterval       time.Duration //circuitBreaker turns to closed when time is end and all of them are success.
-	SuccessVolumeThreshold uint32        //circuitBreaker turns to closed when volume comes to it and all of them are success.
+	RecoveryInterval       time.Duration //circuitBreaker turns to closed when it passed since half-open and all requests since are success. take effect with SuccessVolumeThreshold
+	SuccessVolumeThreshold uint32        //circuitBreaker turns to closed when successes in half-open come to it and none failed. take effect with RecoveryInterval
+}
+
+//errorVolumeThreshold returns RequestVolumeThreshold * (ErrorThresholdPercent / 100) of oc, rounded down
+func errorVolumeThreshold(oc CircuitBreakerOpenConfig) uint32 {
+	return uint32(uint64(oc.RequestVolumeThreshold) * uint64(oc.ErrorThresholdPercent) / 100)
 }
 
 type CircuitBreakerOption func(c *CircuitBreaker)
 
 func WithOpenConfig(oc CircuitBreakerOpenConfig) CircuitBreakerOption {
 	return func(c *CircuitBreaker) {
-		oc.errorVolumeThreshold = uint32(float32(oc.RequestVolumeThreshold) * (float32(oc.ErrorThresholdPercent) / float32(100)))
-		c.openConfig = oc
+		oc.errorVolumeThreshold = errorVolumeThreshold(oc)
+		c.openConfig.Store(&oc)
 	}
 }
 
 func WithCloseConfig(cc CircuitBreakerCloseConfig) CircuitBreakerOption {
 	return func(c *CircuitBreaker) {
-		c.closeConfig = cc
+		c.closeConfig.Store(&cc)
 	}
 }
 
 func WithSleepWindow(t time.Duration) CircuitBreakerOption {
 	return func(c *CircuitBreaker) {
-		c.sleepWindow = t
+		atomic.StoreInt64(&c.sleepWindow, int64(t))
+	}
+}
+
+//WithName names circuit breaker, used in logs
+func WithName(name string) CircuitBreakerOption {
+	return func(c *CircuitBreaker) {
+		c.name = name
+	}
+}
+
+//WithLabels attaches labels to circuit breaker, e.g. team or tier, see Labels
+func WithLabels(labels map[string]string) CircuitBreakerOption {
+	return func(c *CircuitBreaker) {
+		c.setLabels(labels)
 	}
 }
 
+//WithCallback calls f when circuit breaker turns to open from closed or to closed from half-open. f is called
+//synchronously and must not block
 func WithCallback(f func()) CircuitBreakerOption {
 	return func(c *CircuitBreaker) {
 		if f != nil {
@@ -88,183 +127,469 @@ func WithCallback(f func()) CircuitBreakerOption {
 
 //CircuitBreaker
 type CircuitBreaker struct {
-	status        int32
-	requestVolume uint32 //total num of request
+	*shared
 
-	openConfig  CircuitBreakerOpenConfig
-	errorVolume uint32
+	name      string
+	stateWord atomic.Uint64 //status and generation, see transition
+	window    windowCounts  //requests and errors of the last refresh interval, a ring or a compactWindow
 
-	sleepWindow time.Duration //after SleepWindow, circuitBreaker turns to half-open when circuitBreaker is open
+	lastTrip  atomic.Pointer[tripInfo]   //latest trip, see tripOf
+	probe     atomic.Pointer[probe]      //successes of the latest half-open generation, see recover
+	suggested atomic.Pointer[suggestion] //overrides sleepWindow of the next trip, see SuggestSleepWindow
 
-	closeConfig CircuitBreakerCloseConfig
-	//successVolume uint32
+	mode   int32                      //see SetMode
+	revert atomic.Pointer[modeRevert] //reverts mode, see SetModeFor
+}
+
+//shared holds settings and wiring of circuit breakers, each circuit breaker has one of its own but compact
+//ones of a group share one per pattern, see WithCompactBreakers
+type shared struct {
+	labels atomic.Pointer[map[string]string] //replaced as a whole, see WithLabels
+
+	applied atomic.Pointer[Settings] //settings last applied by config, see UpdateConfig
+
+	openConfig atomic.Pointer[CircuitBreakerOpenConfig] //replaced as a whole by SetOpenConfig
+
+	sleepWindow int64 //after SleepWindow, circuitBreaker turns to half-open when circuitBreaker is open
+	lazy        bool  //sleep windows end on calls, see WithLazyExpiry
+	compact     bool  //see WithCompactBreakers
+	startMode   int32 //mode compact circuit breakers start in, see WithMode
+
+	closeConfig atomic.Pointer[CircuitBreakerCloseConfig]
 
 	callback func() //callback when circuitBreak turns to open from closed or to closed from half-open
 
+	metrics   MetricsSink
+	metricsOf func(name string) MetricsSink //see WithMetricsSinkOf
+
+	history          *eventHistory
+	rejectSampleRate float64 //fraction of rejected requests recorded into history
+	sampleN          uint32  //1 in sampleN reports is counted above sampleQPS, see WithReportSampling
+	sampleQPS        uint32
+
+	summaryInterval time.Duration
+	summaryLogger   *log.Logger
+
+	pprofLabels bool
+
+	statusFallback StatusFallback //see WithStatusFallback
+
+	decision  int32 //see SetDecision
+	following int32 //1 if counts don't trip circuit breaker, see SetFollowing
+
+	listeners      []func(Transition)
+	eventListeners []func(Event)
+
+	windowCounter      WindowCounter
+	windowSyncInterval time.Duration
+
+	//counters written by every report sit on a cache line of their own, away from the read-mostly settings
+	_               cacheLinePad
+	rejectedVolume  uint32 //num of rejected request since last summary
+	pendingRequests uint32 //requests not yet added to window counter
+	pendingErrors   uint32 //errors not yet added to window counter
+	_               cacheLinePad
+
 	closeChan chan struct{}
 }
 
+//suggestion is a sleep window suggested by SuggestSleepWindow
+type suggestion struct {
+	sleepWindow time.Duration
+	at          time.Time
+}
+
 //New return a new citcuit breaker
 func New(opts ...CircuitBreakerOption) *CircuitBreaker {
 	c := &CircuitBreaker{
-		status:        CircuitBreakerStatusClosed,
-		requestVolume: 0,
+		shared: &shared{
+			callback: nil,
 
-		openConfig:  defaultOpenConfig,
-		errorVolume: 0,
+			metrics: noopMetricsSink{},
+
+			closeChan: make(chan struct{}),
+		},
+	}
+	c.stateWord.Store(packState(CircuitBreakerStatusClosed, 0))
+	Defaults().apply(c)
 
-		sleepWindow: time.Minute * 3,
+	for _, opt := range opts {
+		opt(c)
+	}
 
-		closeConfig: defaultCloseConfig,
+	if c.compact {
+		//a goroutine per key is what compact circuit breakers are to avoid
+		c.lazy = true
+		c.startMode = c.mode
+		c.metricsOf = nil
+		c.summaryInterval = 0
+		c.windowCounter = nil
+		c.window = new(compactWindow)
+	} else {
+		c.window = new(ring)
+	}
 
-		callback: nil,
+	if c.metricsOf != nil {
+		WithMetricsSink(c.metricsOf(c.name))(c)
+	}
 
-		closeChan: make(chan struct{}),
+	if c.rejectSampleRate > 0 && c.history == nil {
+		c.history = newEventHistory(defaultEventHistorySize)
 	}
 
-	for _, opt := range opts {
-		opt(c)
+	c.metrics.SetGauge(MetricStatus, float64(CircuitBreakerStatusClosed))
+
+	if c.summaryInterval > 0 {
+		go c.logSummary()
 	}
 
-	go c.resetRefreshInterval()
+	if c.windowCounter != nil {
+		go c.syncWindow()
+	}
 
 	return c
 }
 
 //Close closes circuit breaker
 func (c *CircuitBreaker) Close() {
-	close(c.closeChan)
+	c.exit()
+	if !c.compact {
+		close(c.closeChan)
+	}
 }
 
-//ReportRequest is a short hand of ReportRequestN, call when receive a request
-func (c *CircuitBreaker) ReportRequest() error {
-	select {
-	case <-c.closeChan:
-		return errCircuitBreakerClosed
-	default:
+//Name returns name of circuit breaker
+func (c *CircuitBreaker) Name() string {
+	return c.name
+}
+
+//Labels returns labels of circuit breaker, see WithLabels. don't modify it
+func (c *CircuitBreaker) Labels() map[string]string {
+	if labels := c.labels.Load(); labels != nil {
+		return *labels
+	}
+
+	return nil
+}
+
+func (c *CircuitBreaker) setLabels(labels map[string]string) {
+	copied := make(map[string]string, len(labels))
+	for k, v := range labels {
+		copied[k] = v
+	}
+	c.labels.Store(&copied)
+}
+
+//Status returns current status of circuit breaker as requests see it, open in ModeForceOpen and closed in
+//ModeForceClosed whatever errors did
+func (c *CircuitBreaker) Status() int32 {
+	switch Mode(atomic.LoadInt32(&c.mode)) {
+	case ModeForceOpen:
+		return CircuitBreakerStatusOpen
+	case ModeForceClosed:
+		return CircuitBreakerStatusClosed
 	}
 
-	return c.ReportRequestN(1)
+	return c.status()
+}
+
+//status returns status of circuit breaker following errors, regardless of mode
+func (c *CircuitBreaker) status() int32 {
+	c.expireSleepWindow()
+	status, _ := c.loadState()
+	return status
+}
+
+//SleepWindow returns configured sleep window
+func (c *CircuitBreaker) SleepWindow() time.Duration {
+	return time.Duration(atomic.LoadInt64(&c.sleepWindow))
+}
+
+//RemainingSleepWindow returns how long circuit breaker stays open before half-open, 0 if it is not open. in
+//ModeForceOpen it's how long until the mode reverts, the sleep window if it doesn't revert by itself
+func (c *CircuitBreaker) RemainingSleepWindow() time.Duration {
+	switch Mode(atomic.LoadInt32(&c.mode)) {
+	case ModeForceOpen:
+		until := c.ModeUntil()
+		if until.IsZero() {
+			return c.SleepWindow()
+		}
+		if d := time.Until(until); d > 0 {
+			return d
+		}
+		return 0
+	case ModeForceClosed:
+		return 0
+	}
+
+	status, gen := c.loadState()
+	if status != CircuitBreakerStatusOpen {
+		return 0
+	}
+	t, ok := c.tripOf(gen)
+	if !ok {
+		return 0
+	}
+
+	d := time.Until(t.until)
+	if d < 0 {
+		return 0
+	}
+
+	return d
+}
+
+//SuggestSleepWindow makes the next trip within a refresh interval sleep d instead of the configured sleep window,
+//e.g. duration of Retry-After from upstream. call it before ReportError of the failed request
+func (c *CircuitBreaker) SuggestSleepWindow(d time.Duration) {
+	if d > 0 {
+		c.suggested.Store(&suggestion{sleepWindow: d, at: time.Now()})
+	}
+}
+
+//counts returns requests and errors of the last refresh interval
+func (c *CircuitBreaker) counts() (uint64, uint64) {
+	return c.window.counts(time.Now(), c.openConfig.Load().RefreshInterval)
+}
+
+//ReportRequest is a short hand of ReportRequestN, call when receive a request. reports of a circuit breaker that
+//is not open and doesn't trip don't allocate, unless metrics sink or event listeners do. whether circuit breaker
+//is open or closed by Close takes one atomic load of its state word
+func (c *CircuitBreaker) ReportRequest() error {
+	return c.addRequest(1, RequestMeta{})
 }
 
 //ReportRequestN calculates reuqests
 func (c *CircuitBreaker) ReportRequestN(n uint32) error {
-	select {
-	case <-c.closeChan:
-		return errCircuitBreakerClosed
-	default:
-	}
+	return c.addRequest(n, RequestMeta{})
+}
 
-	return c.addRequest(n)
+//ReportRequestWithMeta works like ReportRequest, meta is recorded into event history if the request is rejected and sampled
+func (c *CircuitBreaker) ReportRequestWithMeta(meta RequestMeta) error {
+	return c.addRequest(1, meta)
 }
 
 //ReportError is a short hand of ReportErrorN, call when receiving no response from backend or other define error
 func (c *CircuitBreaker) ReportError() error {
-	select {
-	case <-c.closeChan:
-		return errCircuitBreakerClosed
-	default:
-	}
-
-	return c.ReportErrorN(1)
+	return c.addErrorRequest(context.Background(), 1)
 }
 
 //ReportErrorN calculates error reuqests
 func (c *CircuitBreaker) ReportErrorN(n uint32) error {
-	select {
-	case <-c.closeChan:
-		return errCircuitBreakerClosed
-	default:
-	}
+	return c.addErrorRequest(context.Background(), n)
+}
 
-	c.addErrorRequest(n)
-	return nil
+//ReportErrorContext works like ReportError, ctx is passed to a ContextMetricsSink, e.g. to attach trace exemplars
+func (c *CircuitBreaker) ReportErrorContext(ctx context.Context) error {
+	return c.addErrorRequest(ctx, 1)
 }
 
-func (c *CircuitBreaker) addRequest(n uint32) error {
-	status := atomic.LoadInt32(&c.status)
+func (c *CircuitBreaker) addRequest(n uint32, meta RequestMeta) error {
+	c.expireSleepWindow()
+	s := c.stateWord.Load()
+	if s&stateExited != 0 {
+		return ErrCircuitBreakerClosed
+	}
+	status, gen := unpackState(s)
+	switch Mode(atomic.LoadInt32(&c.mode)) {
+	case ModeForceOpen:
+		status = CircuitBreakerStatusOpen
+	case ModeForceClosed:
+		status = CircuitBreakerStatusClosed
+	case ModeShadow:
+		if status == CircuitBreakerStatusOpen {
+			//would reject, pass anyway
+			c.metrics.IncrCounter(MetricShadowRejected, n)
+			return nil
+		}
+	}
+
 	switch status {
 	case CircuitBreakerStatusOpen:
-		return errTooManyErrors
+		c.countRejected(n)
+		c.sampleReject(meta)
+		return ErrTooManyErrors
 	case CircuitBreakerStatusHalfOpen:
 		//pass request to backend
 
-		atomic.StoreUint32(&c.requestVolume, atomic.AddUint32(&c.requestVolume, n))
+		c.passRequests(n)
+		c.recover(gen, n)
 	case CircuitBreakerStatusClosed:
 		//pass all
 
-		atomic.StoreUint32(&c.requestVolume, atomic.AddUint32(&c.requestVolume, n))
+		c.passRequests(n)
 	default:
-		panic(errUnknownStatus)
+		c.recordUnknownStatus(status)
+		if c.statusFallback == FallbackReject {
+			c.countRejected(n)
+			return ErrTooManyErrors
+		}
+		c.metrics.IncrCounter(MetricRequests, n)
 	}
 
 	return nil
 }
 
-func (c *CircuitBreaker) addErrorRequest(n uint32) {
+//countRejected counts n rejected requests. compact circuit breakers log no summary, so they skip its count shared
+//by the whole group
+func (c *CircuitBreaker) countRejected(n uint32) {
+	if !c.compact {
+		atomic.AddUint32(&c.rejectedVolume, n)
+	}
+	c.metrics.IncrCounter(MetricRejected, n)
+}
+
+//passRequests counts n requests passed to backend
+func (c *CircuitBreaker) passRequests(n uint32) {
+	now, interval := time.Now(), c.openConfig.Load().RefreshInterval
+	if counted := c.sample(now, interval, n); counted > 0 {
+		c.window.addRequests(now, interval, counted)
+	}
+	c.metrics.IncrCounter(MetricRequests, n)
+
+	if c.windowCounter != nil && c.shares() {
+		atomic.AddUint32(&c.pendingRequests, n)
+	}
+}
+
+func (c *CircuitBreaker) addErrorRequest(ctx context.Context, n uint32) error {
+	if c.exited() {
+		return ErrCircuitBreakerClosed
+	}
 	if n == 0 {
-		return
+		return nil
+	}
+
+	c.incrCounter(ctx, MetricErrors, n)
+	if c.windowCounter != nil && c.shares() {
+		atomic.AddUint32(&c.pendingErrors, n)
+	}
+
+	switch Mode(atomic.LoadInt32(&c.mode)) {
+	case ModeForceOpen, ModeForceClosed:
+		//status is pinned, errors don't trip
+		return nil
 	}
 
-	status := atomic.LoadInt32(&c.status)
+	c.expireSleepWindow()
+	status, _ := c.loadState()
 	switch status {
 	case CircuitBreakerStatusOpen:
 		//skip
 	case CircuitBreakerStatusHalfOpen:
-		atomic.StoreInt32(&c.status, CircuitBreakerStatusOpen)
-
-		go c.waitForSleepWindow()
+		c.open(ctx, CircuitBreakerStatusHalfOpen, CauseErrors)
 	case CircuitBreakerStatusClosed:
-		v := atomic.AddUint32(&c.errorVolume, n)
+		oc := c.openConfig.Load()
+		now := time.Now()
+		if n = c.sample(now, oc.RefreshInterval, n); n == 0 {
+			return nil
+		}
+		c.window.addErrors(now, oc.RefreshInterval, n)
 
 		//closed => open
-		if v >= c.openConfig.errorVolumeThreshold &&
-			atomic.LoadUint32(&c.openConfig.RequestVolumeThreshold) <= atomic.LoadUint32(&c.requestVolume) &&
-			v >= c.getCurErrorQuorm() {
-			atomic.StoreInt32(&c.status, CircuitBreakerStatusOpen)
-
-			go c.waitForSleepWindow()
-			return
+		if !c.tripsOnOwnCounts() {
+			return nil
+		}
+		if requests, errors := c.window.counts(now, oc.RefreshInterval); oc.shouldOpen(requests, errors) {
+			c.open(ctx, CircuitBreakerStatusClosed, CauseErrors)
 		}
-
-		//stay closed
-		atomic.StoreUint32(&c.errorVolume, v)
 	default:
-		panic(errUnknownStatus)
+		//not counted, nothing to trip from
+		c.recordUnknownStatus(status)
 	}
-}
 
-func (c *CircuitBreaker) resetRefreshInterval() {
-	t := time.NewTicker(c.openConfig.RefreshInterval)
-	for {
-		select {
-		case <-t.C:
-			atomic.StoreUint32(&c.requestVolume, 0)
-			atomic.StoreUint32(&c.errorVolume, 0)
-		case <-c.closeChan:
-			fmt.Println("circuit breaker has already exited")
+	return nil
+}
 
-			t.Stop()
-			return
-		}
+//nextSleepWindow returns sleep window of a trip
+func (c *CircuitBreaker) nextSleepWindow() time.Duration {
+	sleepWindow := c.SleepWindow()
+	if s := c.suggested.Swap(nil); s != nil && time.Since(s.at) < c.openConfig.Load().RefreshInterval {
+		sleepWindow = s.sleepWindow
 	}
-}
 
-func (c *CircuitBreaker) waitForSleepWindow() {
-	timer := time.NewTimer(c.sleepWindow)
+	return sleepWindow
+}
 
+//endSleepWindow turns circuit breaker to half-open once sleepWindow of the trip to generation gen passed, run
+//by sleepWheel. it does nothing if circuit breaker left that generation meanwhile
+func (c *CircuitBreaker) endSleepWindow(gen uint32, sleepWindow time.Duration) {
 	select {
-	case <-timer.C:
-		atomic.StoreInt32(&c.status, CircuitBreakerStatusHalfOpen)
-
-		timer.Stop()
 	case <-c.closeChan:
-		fmt.Println("circuit breaker has already exited")
+		return
+	default:
+	}
 
-		timer.Stop()
+	if !c.transition(CircuitBreakerStatusOpen, gen, CircuitBreakerStatusHalfOpen) {
+		return
 	}
+	c.reportHalfOpen(gen+1, sleepWindow)
+}
+
+//reportHalfOpen reports the end of a sleep window turning circuit breaker half-open in generation gen
+func (c *CircuitBreaker) reportHalfOpen(gen uint32, sleepWindow time.Duration) {
+	c.startProbe(gen)
+	c.metrics.SetGauge(MetricStatus, float64(CircuitBreakerStatusHalfOpen))
+	c.metrics.ObserveDuration(MetricOpenDuration, sleepWindow)
+	c.recordEvent(Event{Type: EventHalfOpen, Status: CircuitBreakerStatusHalfOpen, Cause: CauseSleepWindow})
+	c.notifyTransition(CircuitBreakerStatusOpen, CircuitBreakerStatusHalfOpen, CauseSleepWindow)
+}
+
+func (c *CircuitBreaker) reportOpen(ctx context.Context, cause TransitionCause) {
+	c.incrCounter(ctx, MetricTrips, 1)
+	c.metrics.SetGauge(MetricStatus, float64(CircuitBreakerStatusOpen))
+	c.recordEvent(Event{
+		Type:   EventTrip,
+		Status: CircuitBreakerStatusOpen,
+		Cause:  cause,
+		Until:  c.openUntil(),
+	})
+}
+
+//shouldOpen reports whether errors of requests in a refresh interval reach thresholds of oc. the error rate is
+//compared in integers, errors*100 against requests*percent, exactly like breakersync does. counts of a window fit
+//in 44 bits, so neither product overflows
+func (oc *CircuitBreakerOpenConfig) shouldOpen(requests, errors uint64) bool {
+	return errors >= uint64(oc.errorVolumeThreshold) &&
+		uint64(oc.RequestVolumeThreshold) <= requests &&
+		errors*100 >= requests*uint64(oc.ErrorThresholdPercent)
 }
 
-func (c *CircuitBreaker) getCurErrorQuorm() uint32 {
-	return uint32(float32(atomic.LoadUint32(&c.requestVolume)) * (float32(c.openConfig.ErrorThresholdPercent) / float32(100)))
+//open turns circuit breaker to open if it is still in status from
+func (c *CircuitBreaker) open(ctx context.Context, from int32, cause TransitionCause) bool {
+	return c.openFor(ctx, from, cause, c.nextSleepWindow())
+}
+
+//openFor works like open with the given sleep window. the trip is stored for the next generation before the
+//transition, so it is visible no later than the open status. of callers racing from the same generation only
+//the one storing its trip goes on to the transition, and drops the trip if the transition loses
+func (c *CircuitBreaker) openFor(ctx context.Context, from int32, cause TransitionCause, sleepWindow time.Duration) bool {
+	status, gen := c.loadState()
+	if status != from {
+		return false
+	}
+	prev := c.lastTrip.Load()
+	if prev != nil && prev.gen == gen+1 {
+		//another trip of this generation is under way
+		return false
+	}
+	t := &tripInfo{gen: gen + 1, until: time.Now().Add(sleepWindow), sleepWindow: sleepWindow}
+	if !c.lastTrip.CompareAndSwap(prev, t) {
+		return false
+	}
+	if !c.transition(from, gen, CircuitBreakerStatusOpen) {
+		c.lastTrip.CompareAndSwap(t, prev)
+		return false
+	}
+	gen++
+	c.reportOpen(ctx, cause)
+	c.notifyTransition(from, CircuitBreakerStatusOpen, cause)
+	if from == CircuitBreakerStatusClosed && c.callback != nil {
+		c.callback()
+	}
+
+	if !c.lazy {
+		sleepWheel.after(sleepWindow, func() { c.endSleepWindow(gen, sleepWindow) })
+	}
+	return true
 }
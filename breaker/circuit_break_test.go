@@ -0,0 +1,46 @@
+package breaker
+
+import (
+	"testing"
+)
+
+func TestReportAllocs(t *testing.T) {
+	for name, report := range map[string]func(c *CircuitBreaker){
+		"ReportRequest": func(c *CircuitBreaker) { c.ReportRequest() },
+		"ReportError":   func(c *CircuitBreaker) { c.ReportError() },
+	} {
+		//requests alone or errors alone never trip it, so it stays closed
+		c := New()
+		if n := testing.AllocsPerRun(1000, func() { report(c) }); n != 0 {
+			t.Errorf("%s on a closed circuit breaker: %v allocs, want 0", name, n)
+		}
+		if s := c.Status(); s != CircuitBreakerStatusClosed {
+			t.Errorf("%s: status %s, want closed", name, StatusText(s))
+		}
+		c.Close()
+	}
+}
+
+func BenchmarkReportRequest(b *testing.B) {
+	c := New()
+	defer c.Close()
+
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			c.ReportRequest()
+		}
+	})
+}
+
+func BenchmarkReportError(b *testing.B) {
+	c := New()
+	defer c.Close()
+
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			c.ReportError()
+		}
+	})
+}
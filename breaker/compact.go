@@ -0,0 +1,126 @@
+package breaker
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+//WithCompactBreakers makes circuit breakers of a Group compact, for keys by the million, e.g. one per user or
+//device. compact circuit breakers matching the same pattern share settings, metrics sink, listeners and event
+//history, so tuning one tunes them all, and none runs a goroutine: sleep windows end like WithLazyExpiry, and
+//WithSummaryLog, WithWindowCounter and WithMetricsSinkOf are ignored, WithStore only publishes trips. they count
+//a fixed refresh interval instead of a rolling one, up to 16M requests, which takes a circuit breaker under 100
+//bytes besides its key and map entry. rollouts need settings of their own per key and don't start in a group of
+//them. status, modes and trips stay per key
+func WithCompactBreakers() CircuitBreakerOption {
+	return func(c *CircuitBreaker) {
+		c.compact = true
+	}
+}
+
+//sibling returns a new compact circuit breaker named name sharing settings of compact circuit breaker c
+func (c *CircuitBreaker) sibling(name string) *CircuitBreaker {
+	cb := &CircuitBreaker{
+		shared: c.shared,
+		name:   name,
+		window: new(compactWindow),
+		mode:   c.startMode,
+	}
+	cb.stateWord.Store(packState(CircuitBreakerStatusClosed, 0))
+
+	return cb
+}
+
+//windowCounts counts requests and errors of the last refresh interval, see ring and compactWindow
+type windowCounts interface {
+	addRequests(now time.Time, interval time.Duration, n uint32)
+	addErrors(now time.Time, interval time.Duration, n uint32)
+	counts(now time.Time, interval time.Duration) (uint64, uint64)
+	rate(now time.Time, interval time.Duration) float64
+	restore(now time.Time, interval time.Duration, requests, errors uint64)
+	reset()
+}
+
+const (
+	compactCountBits = 24                             //bits of requests and of errors in the window word
+	compactCountMax  = 1<<compactCountBits - 1        //counts of a window saturate at it
+	compactEpochMask = 1<<(64-2*compactCountBits) - 1 //epochs, refresh intervals since the unix epoch, wrap at it
+)
+
+//compactWindow counts the current refresh interval in one word, epoch<<48 | requests<<24 | errors, the add
+//finding an older epoch starts it over by the same CAS
+type compactWindow struct {
+	word atomic.Uint64
+}
+
+//compactEpoch returns the epoch tag of the refresh interval of now
+func compactEpoch(now time.Time, interval time.Duration) uint64 {
+	if interval <= 0 {
+		interval = 1
+	}
+
+	return uint64(now.UnixNano()/int64(interval)) & compactEpochMask
+}
+
+//packCompact returns the window word of epoch with requests and errors, saturated
+func packCompact(epoch, requests, errors uint64) uint64 {
+	if requests > compactCountMax {
+		requests = compactCountMax
+	}
+	if errors > compactCountMax {
+		errors = compactCountMax
+	}
+
+	return epoch<<(2*compactCountBits) | requests<<compactCountBits | errors
+}
+
+func (w *compactWindow) add(now time.Time, interval time.Duration, requests, errors uint64) {
+	epoch := compactEpoch(now, interval)
+	for {
+		old := w.word.Load()
+		r, e := requests, errors
+		if old>>(2*compactCountBits) == epoch {
+			r += old >> compactCountBits & compactCountMax
+			e += old & compactCountMax
+		}
+		if w.word.CompareAndSwap(old, packCompact(epoch, r, e)) {
+			return
+		}
+	}
+}
+
+func (w *compactWindow) addRequests(now time.Time, interval time.Duration, n uint32) {
+	w.add(now, interval, uint64(n), 0)
+}
+
+func (w *compactWindow) addErrors(now time.Time, interval time.Duration, n uint32) {
+	w.add(now, interval, 0, uint64(n))
+}
+
+func (w *compactWindow) counts(now time.Time, interval time.Duration) (uint64, uint64) {
+	word := w.word.Load()
+	if word>>(2*compactCountBits) != compactEpoch(now, interval) {
+		return 0, 0
+	}
+
+	return word >> compactCountBits & compactCountMax, word & compactCountMax
+}
+
+//rate returns requests a second of the refresh interval so far spread over all of it, low early in an interval
+//so sampling starts late rather than early
+func (w *compactWindow) rate(now time.Time, interval time.Duration) float64 {
+	requests, _ := w.counts(now, interval)
+	if interval <= 0 {
+		return 0
+	}
+
+	return float64(requests) / interval.Seconds()
+}
+
+func (w *compactWindow) restore(now time.Time, interval time.Duration, requests, errors uint64) {
+	w.word.Store(packCompact(compactEpoch(now, interval), requests, errors))
+}
+
+func (w *compactWindow) reset() {
+	w.word.Store(0)
+}
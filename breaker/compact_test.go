@@ -0,0 +1,121 @@
+package breaker
+
+import (
+	"testing"
+	"time"
+	"unsafe"
+)
+
+func TestCompactSize(t *testing.T) {
+	if n := unsafe.Sizeof(CircuitBreaker{}) + unsafe.Sizeof(compactWindow{}); n >= 100 {
+		t.Errorf("compact circuit breaker takes %d bytes, want under 100", n)
+	}
+}
+
+func compactGroup(t *testing.T) *Group {
+	t.Helper()
+
+	g := NewGroup(
+		WithCompactBreakers(),
+		WithOpenConfig(CircuitBreakerOpenConfig{RefreshInterval: time.Minute, ErrorThresholdPercent: 50, RequestVolumeThreshold: 4}),
+		WithCloseConfig(CircuitBreakerCloseConfig{SuccessVolumeThreshold: 2}),
+		WithSleepWindow(time.Millisecond),
+	)
+	t.Cleanup(g.Close)
+
+	return g
+}
+
+func TestCompactGroup(t *testing.T) {
+	g := compactGroup(t)
+	a, b := g.Get("a"), g.Get("b")
+	if a.shared != b.shared {
+		t.Fatal("compact circuit breakers of a group don't share settings")
+	}
+	if a.Name() != "a" || b.Name() != "b" {
+		t.Fatalf("names %q and %q, want a and b", a.Name(), b.Name())
+	}
+
+	a.ReportRequestN(4)
+	a.ReportErrorN(2)
+	if s := a.Status(); s != CircuitBreakerStatusOpen {
+		t.Fatalf("status %s after errors, want open", StatusText(s))
+	}
+	if s := b.Status(); s != CircuitBreakerStatusClosed {
+		t.Fatalf("status %s of another key, want closed", StatusText(s))
+	}
+
+	time.Sleep(2 * time.Millisecond)
+	if s := a.Status(); s != CircuitBreakerStatusHalfOpen {
+		t.Fatalf("status %s after the sleep window, want half-open", StatusText(s))
+	}
+	a.ReportRequestN(2)
+	if s := a.Status(); s != CircuitBreakerStatusClosed {
+		t.Fatalf("status %s after successes in half-open, want closed", StatusText(s))
+	}
+	if requests, errors := a.counts(); requests != 0 || errors != 0 {
+		t.Errorf("counts %d/%d after recovery, want 0/0", requests, errors)
+	}
+}
+
+func TestCompactGroupPatterns(t *testing.T) {
+	g := compactGroup(t)
+	if err := g.Match("api-*", WithSleepWindow(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+
+	api, web := g.Get("api-1"), g.Get("web-1")
+	if api.shared != g.Get("api-2").shared || api.shared == web.shared {
+		t.Fatal("compact circuit breakers don't share settings by pattern")
+	}
+	if d := api.SleepWindow(); d != time.Hour {
+		t.Errorf("sleep window %s of a pattern, want 1h", d)
+	}
+
+	if err := web.SetSleepWindow(time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if d := g.Get("web-2").SleepWindow(); d != time.Minute {
+		t.Errorf("sleep window %s of a sibling, want 1m", d)
+	}
+}
+
+func TestCompactGroupRemove(t *testing.T) {
+	g := compactGroup(t)
+	a := g.Get("a")
+	g.Remove("a")
+	if err := a.ReportRequest(); err != ErrCircuitBreakerClosed {
+		t.Fatalf("report of a removed circuit breaker: %v, want ErrCircuitBreakerClosed", err)
+	}
+	if err := g.Get("b").ReportRequest(); err != nil {
+		t.Fatalf("report of a sibling: %v", err)
+	}
+	if _, err := g.StartRollout(Settings{SleepWindow: time.Minute}); err != errRolloutCompact {
+		t.Fatalf("rollout in a compact group: %v, want errRolloutCompact", err)
+	}
+}
+
+func TestCompactWindow(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	for _, tc := range []struct {
+		name         string
+		adds         [][2]uint64
+		at           time.Time
+		wantRequests uint64
+		wantErrors   uint64
+	}{
+		{"adds up", [][2]uint64{{3, 1}, {2, 1}}, now, 5, 2},
+		{"saturates", [][2]uint64{{compactCountMax, 0}, {10, compactCountMax + 1}}, now, compactCountMax, compactCountMax},
+		{"next interval", [][2]uint64{{3, 1}}, now.Add(time.Minute), 0, 0},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var w compactWindow
+			for _, a := range tc.adds {
+				w.add(now, time.Minute, a[0], a[1])
+			}
+			if requests, errors := w.counts(tc.at, time.Minute); requests != tc.wantRequests || errors != tc.wantErrors {
+				t.Errorf("counts %d/%d, want %d/%d", requests, errors, tc.wantRequests, tc.wantErrors)
+			}
+		})
+	}
+}
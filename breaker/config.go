@@ -0,0 +1,216 @@
+package breaker
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+var errConfigFormat = errors.New("unknown circuit breaker config format, want .json, .yaml or .yml")
+
+//Settings of a named circuit breaker in a config file. zero fields take defaults of New
+type Settings struct {
+	Name   string            `json:"name" yaml:"name" mapstructure:"name"`
+	Labels map[string]string `json:"labels,omitempty" yaml:"labels,omitempty" mapstructure:"labels"`
+
+	RefreshInterval        time.Duration `json:"refresh_interval,omitempty" yaml:"refresh_interval,omitempty" mapstructure:"refresh_interval"`
+	ErrorThresholdPercent  uint8         `json:"error_threshold_percent,omitempty" yaml:"error_threshold_percent,omitempty" mapstructure:"error_threshold_percent"`
+	RequestVolumeThreshold uint32        `json:"request_volume_threshold,omitempty" yaml:"request_volume_threshold,omitempty" mapstructure:"request_volume_threshold"`
+
+	RecoveryInterval       time.Duration `json:"recovery_interval,omitempty" yaml:"recovery_interval,omitempty" mapstructure:"recovery_interval"`
+	SuccessVolumeThreshold uint32        `json:"success_volume_threshold,omitempty" yaml:"success_volume_threshold,omitempty" mapstructure:"success_volume_threshold"`
+
+	SleepWindow time.Duration `json:"sleep_window,omitempty" yaml:"sleep_window,omitempty" mapstructure:"sleep_window"`
+
+	Mode     string `json:"mode,omitempty" yaml:"mode,omitempty" mapstructure:"mode"`             //see ParseMode
+	Decision string `json:"decision,omitempty" yaml:"decision,omitempty" mapstructure:"decision"` //see ParseDecision
+
+	Extends string `json:"extends,omitempty" yaml:"extends,omitempty" mapstructure:"extends"` //name of a template of the config
+
+	errorThresholdText string //ErrorThresholdPercent as written when it saturated, reported by Validate
+}
+
+//Config lists circuit breakers, e.g.
+//
+//	breakers:
+//	  - name: maps
+//	    extends: base-external-api
+//	    sleep_window: 1m
+//	  - name: payments
+//	    labels: {team: checkout}
+//	    refresh_interval: 1m
+//	    error_threshold_percent: 30%
+//	    request_volume_threshold: 200
+//	    sleep_window: 30s
+//	    mode: shadow
+//	defaults:
+//	  sleep_window: 1m
+//	profiles:
+//	  staging:
+//	    defaults: {mode: shadow}
+//	    breakers:
+//	      - name: payments
+//	        error_threshold_percent: 50%
+//	integrations:
+//	  - http: api.stripe.com
+//	    breaker: payments
+//	templates:
+//	  base-external-api:
+//	    error_threshold_percent: 25%
+//	    sleep_window: 10s
+//
+//defaults apply to every circuit breaker, profiles to one environment, templates to the circuit breakers extending
+//them, see Resolve. integrations route calls of adapters to circuit breakers, see Integration. durations are
+//strings like "250ms" or "3m", or nanoseconds, percentages strings like "20%" or numbers, in JSON and YAML alike.
+//mapstructure tags let configuration libraries decode it too, see breakerviper and breakerkoanf
+type Config struct {
+	Defaults  Settings            `json:"defaults,omitempty" yaml:"defaults,omitempty" mapstructure:"defaults"`
+	Breakers  []Settings          `json:"breakers" yaml:"breakers" mapstructure:"breakers"`
+	Profiles  map[string]Profile  `json:"profiles,omitempty" yaml:"profiles,omitempty" mapstructure:"profiles"`
+	Templates map[string]Settings `json:"templates,omitempty" yaml:"templates,omitempty" mapstructure:"templates"`
+
+	Integrations []Integration `json:"integrations,omitempty" yaml:"integrations,omitempty" mapstructure:"integrations"`
+}
+
+//ReadConfig reads config file of path, its format is chosen by extension
+func ReadConfig(path string) (*Config, error) {
+	return ConfigDefault.ReadConfig(path)
+}
+
+//ParseConfig parses config of b in the format of file extension ext, .json, .yaml or .yml
+func ParseConfig(b []byte, ext string) (*Config, error) {
+	var cfg Config
+	var err error
+	switch ext {
+	case ".json":
+		err = json.Unmarshal(b, &cfg)
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(b, &cfg)
+	default:
+		return nil, errConfigFormat
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &cfg, nil
+}
+
+//LoadConfig reads config file of path and returns a registry of its circuit breakers, opts apply to all of them
+//before their settings, e.g. WithMetricsSink. the profile named by ProfileEnv is used, and environment variables
+//of EnvPrefix override the file, see EnvName
+func LoadConfig(path string, opts ...CircuitBreakerOption) (*Registry, error) {
+	return ConfigDefault.LoadConfig(path, opts...)
+}
+
+//Apply creates circuit breakers of config and registers them into r. nothing is registered if one of them fails.
+//defaults are folded in, profiles are ignored, see Resolve
+func (cfg *Config) Apply(r *Registry, opts ...CircuitBreakerOption) error {
+	cfg, err := cfg.Resolve("")
+	if err != nil {
+		return err
+	}
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+
+	for _, s := range cfg.Breakers {
+		if _, ok := r.Get(s.Name); ok {
+			return fmt.Errorf("%s: %w", s.Name, errDuplicateName)
+		}
+	}
+
+	cbs := make([]*CircuitBreaker, 0, len(cfg.Breakers))
+	for _, s := range cfg.Breakers {
+		so, _ := s.Options()
+		cbs = append(cbs, New(append(append([]CircuitBreakerOption{}, opts...), so...)...))
+	}
+	if err := r.registerAll(cbs, cfg.Breakers); err != nil {
+		for _, cb := range cbs {
+			cb.Close()
+		}
+		return err
+	}
+	r.setIntegrations(cfg.Integrations)
+	r.recordVersion(cfg)
+
+	return nil
+}
+
+//register adds cb created from settings s, it's removed by UpdateConfig of a config without it
+func (r *Registry) register(cb *CircuitBreaker, s Settings) error {
+	if err := r.Register(cb); err != nil {
+		return err
+	}
+
+	s = s.withDefaults()
+	cb.applied.Store(&s)
+
+	r.mu.Lock()
+	r.configured[cb.name] = true
+	r.mu.Unlock()
+
+	return nil
+}
+
+//registerAll adds cbs created from settings ss like register, all of them under one lock or none if a name is
+//registered meanwhile
+func (r *Registry) registerAll(cbs []*CircuitBreaker, ss []Settings) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, cb := range cbs {
+		if _, ok := r.breakers[cb.name]; ok {
+			return fmt.Errorf("%s: %w", cb.name, errDuplicateName)
+		}
+	}
+	for i, cb := range cbs {
+		s := ss[i].withDefaults()
+		cb.applied.Store(&s)
+		r.breakers[cb.name] = cb
+		r.configured[cb.name] = true
+	}
+
+	return nil
+}
+
+//Validate returns all problems of config at once: circuit breakers without a name or of a name used before,
+//settings out of allowed values, see Settings.Validate, and integrations of unknown circuit breakers
+func (cfg *Config) Validate() error {
+	var errs []error
+	names := make(map[string]bool, len(cfg.Breakers))
+	for i, s := range cfg.Breakers {
+		at := s.Name
+		if at == "" {
+			at = fmt.Sprintf("breakers[%d]", i)
+			errs = append(errs, fmt.Errorf("%s: %w", at, errEmptyName))
+		} else if names[s.Name] {
+			errs = append(errs, fmt.Errorf("%s: %w", at, errDuplicateName))
+		}
+		names[s.Name] = true
+
+		if err := s.Validate(); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", at, err))
+		}
+	}
+	for i, in := range cfg.Integrations {
+		if err := in.validate(names); err != nil {
+			errs = append(errs, fmt.Errorf("integrations[%d]: %w", i, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+//Options returns options of circuit breaker of settings, it fails if settings are not valid, see Validate.
+//zero fields keep defaults of New, see SetDefaults, or values of options before
+func (s Settings) Options() ([]CircuitBreakerOption, error) {
+	if err := s.Validate(); err != nil {
+		return nil, err
+	}
+
+	return []CircuitBreakerOption{s.apply}, nil
+}
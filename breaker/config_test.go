@@ -0,0 +1,27 @@
+package breaker
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestApplyRegistersAllOrNone(t *testing.T) {
+	r := NewRegistry()
+	if err := r.Register(New(WithName("b"))); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := &Config{Breakers: []Settings{{Name: "a"}, {Name: "b"}, {Name: "c"}}}
+	if err := cfg.Apply(r); !errors.Is(err, errDuplicateName) {
+		t.Fatalf("Apply with b registered: %v, want errDuplicateName", err)
+	}
+
+	//b registered between the check of Apply and its registration
+	cbs := []*CircuitBreaker{New(WithName("a")), New(WithName("b")), New(WithName("c"))}
+	if err := r.registerAll(cbs, cfg.Breakers); !errors.Is(err, errDuplicateName) {
+		t.Fatalf("registerAll with b registered: %v, want errDuplicateName", err)
+	}
+	if names := r.Names(); len(names) != 1 || names[0] != "b" {
+		t.Errorf("registered %v, want only b", names)
+	}
+}
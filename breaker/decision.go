@@ -0,0 +1,72 @@
+package breaker
+
+import (
+	"fmt"
+	"sync/atomic"
+)
+
+//Decision chooses which counts trip circuit breaker when distributed state is set up, e.g. WithWindowCounter.
+//not every dependency is worth the coordination, and some only fail fleet-wide
+type Decision int32
+
+const (
+	DecisionHybrid Decision = iota //own counts trip fast, shared counts and peers trip the rest
+	DecisionLocal                  //only own counts trip. counts aren't shared, trips aren't published and peers are ignored
+	DecisionGlobal                 //only shared counts and peers trip, own counts are shared but don't trip alone
+)
+
+func (d Decision) String() string {
+	switch d {
+	case DecisionHybrid:
+		return "hybrid"
+	case DecisionLocal:
+		return "local"
+	case DecisionGlobal:
+		return "global"
+	default:
+		return "unknown"
+	}
+}
+
+//ParseDecision parses text returned by Decision.String
+func ParseDecision(s string) (Decision, error) {
+	for _, d := range []Decision{DecisionHybrid, DecisionLocal, DecisionGlobal} {
+		if d.String() == s {
+			return d, nil
+		}
+	}
+
+	return DecisionHybrid, fmt.Errorf("unknown circuit breaker decision %q", s)
+}
+
+//WithDecision sets decision of circuit breaker, default DecisionHybrid
+func WithDecision(d Decision) CircuitBreakerOption {
+	return func(c *CircuitBreaker) {
+		c.decision = int32(d)
+	}
+}
+
+//SetDecision changes decision of circuit breaker, it takes effect from the next request
+func (c *CircuitBreaker) SetDecision(d Decision) {
+	atomic.StoreInt32(&c.decision, int32(d))
+}
+
+//Decision returns current decision of circuit breaker
+func (c *CircuitBreaker) Decision() Decision {
+	return Decision(atomic.LoadInt32(&c.decision))
+}
+
+//tripsOnOwnCounts reports whether counts of this instance alone may trip circuit breaker
+func (c *CircuitBreaker) tripsOnOwnCounts() bool {
+	return c.Decision() != DecisionGlobal && !c.Following()
+}
+
+//tripsOnSharedCounts reports whether counts shared by a WindowCounter may trip circuit breaker
+func (c *CircuitBreaker) tripsOnSharedCounts() bool {
+	return c.Decision() != DecisionLocal && !c.Following()
+}
+
+//shares reports whether counts and trips are shared with and taken from other instances
+func (c *CircuitBreaker) shares() bool {
+	return c.Decision() != DecisionLocal
+}
@@ -0,0 +1,94 @@
+package breaker
+
+import (
+	"sync/atomic"
+)
+
+var defaults atomic.Pointer[Settings] //see SetDefaults, nil for builtin defaults
+
+//SetDefaults changes defaults of circuit breakers created from now on, by New, config or Settings.Options.
+//fields of s that are not zero replace the builtin defaults of 3m refresh interval, 20% errors, 1000 requests,
+//1m recovery interval, 100 successes, 3m sleep window, normal mode and hybrid decision. name is ignored.
+//call it once at startup, existing circuit breakers keep their settings
+func SetDefaults(s Settings) error {
+	if err := s.Validate(); err != nil {
+		return err
+	}
+
+	d := builtinDefaults().Override(s)
+	d.Name = ""
+	defaults.Store(&d)
+
+	return nil
+}
+
+//Defaults returns current defaults of circuit breakers, see SetDefaults
+func Defaults() Settings {
+	if d := defaults.Load(); d != nil {
+		return *d
+	}
+
+	return builtinDefaults()
+}
+
+func builtinDefaults() Settings {
+	return Settings{
+		RefreshInterval:        defaultOpenConfig.RefreshInterval,
+		ErrorThresholdPercent:  defaultOpenConfig.ErrorThresholdPercent,
+		RequestVolumeThreshold: defaultOpenConfig.RequestVolumeThreshold,
+		RecoveryInterval:       defaultCloseConfig.RecoveryInterval,
+		SuccessVolumeThreshold: defaultCloseConfig.SuccessVolumeThreshold,
+		SleepWindow:            defaultSleepWindow,
+		Mode:                   ModeNormal.String(),
+		Decision:               DecisionHybrid.String(),
+	}
+}
+
+//apply sets settings of s that are not zero, which are valid, to c being created
+func (s Settings) apply(c *CircuitBreaker) {
+	if s.Name != "" {
+		c.name = s.Name
+	}
+	if s.Labels != nil {
+		c.setLabels(s.Labels)
+	}
+
+	var oc CircuitBreakerOpenConfig
+	if cur := c.openConfig.Load(); cur != nil {
+		oc = *cur
+	}
+	if s.RefreshInterval > 0 {
+		oc.RefreshInterval = s.RefreshInterval
+	}
+	if s.ErrorThresholdPercent > 0 {
+		oc.ErrorThresholdPercent = s.ErrorThresholdPercent
+	}
+	if s.RequestVolumeThreshold > 0 {
+		oc.RequestVolumeThreshold = s.RequestVolumeThreshold
+	}
+	WithOpenConfig(oc)(c)
+
+	var cc CircuitBreakerCloseConfig
+	if cur := c.closeConfig.Load(); cur != nil {
+		cc = *cur
+	}
+	if s.RecoveryInterval > 0 {
+		cc.RecoveryInterval = s.RecoveryInterval
+	}
+	if s.SuccessVolumeThreshold > 0 {
+		cc.SuccessVolumeThreshold = s.SuccessVolumeThreshold
+	}
+	WithCloseConfig(cc)(c)
+
+	if s.SleepWindow > 0 {
+		WithSleepWindow(s.SleepWindow)(c)
+	}
+	if s.Mode != "" {
+		m, _ := ParseMode(s.Mode)
+		c.mode = int32(m)
+	}
+	if s.Decision != "" {
+		d, _ := ParseDecision(s.Decision)
+		c.decision = int32(d)
+	}
+}
@@ -0,0 +1,153 @@
+package breaker
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//EnvPrefix is the default prefix of environment variables read by LoadConfig
+const EnvPrefix = "CB"
+
+//EnvName returns the environment variable of field of circuit breaker name, prefix_NAME_FIELD with name
+//upper cased and every character other than letters and digits replaced by _, e.g. CB_PAYMENTS_API_SLEEP_WINDOW
+//for prefix CB, name payments-api and field SLEEP_WINDOW. fields are
+//
+//	REFRESH_INTERVAL   duration, e.g. 1m
+//	ERROR_THRESHOLD    error threshold percent, e.g. 30 or 30%
+//	REQUEST_VOLUME     request volume threshold
+//	RECOVERY_INTERVAL  duration
+//	SUCCESS_VOLUME     success volume threshold
+//	SLEEP_WINDOW       duration
+//	MODE               see ParseMode
+//	DECISION           see ParseDecision
+//	LABELS             comma separated key=value pairs, replacing labels of config
+func EnvName(prefix, name, field string) string {
+	var b strings.Builder
+	b.WriteString(prefix)
+	b.WriteByte('_')
+	for _, r := range strings.ToUpper(name) {
+		if (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+		} else {
+			b.WriteByte('_')
+		}
+	}
+	b.WriteByte('_')
+	b.WriteString(field)
+
+	return b.String()
+}
+
+//EnvSettings returns settings of circuit breaker name from environment variables of prefix only, see EnvName
+func EnvSettings(prefix, name string) (Settings, error) {
+	s := Settings{Name: name}
+	err := s.ApplyEnv(prefix)
+
+	return s, err
+}
+
+//ApplyEnv overrides settings by environment variables of prefix set for its name, see EnvName
+func (s *Settings) ApplyEnv(prefix string) error {
+	env := func(field string) (string, string, bool) {
+		key := EnvName(prefix, s.Name, field)
+		v, ok := os.LookupEnv(key)
+		return key, v, ok
+	}
+	interval := func(field string, d *time.Duration) error {
+		key, v, ok := env(field)
+		if !ok {
+			return nil
+		}
+		parsed, err := time.ParseDuration(v)
+		if err != nil {
+			return fmt.Errorf("%s: %w", key, err)
+		}
+		*d = parsed
+		return nil
+	}
+	number := func(field string, n *uint32) error {
+		key, v, ok := env(field)
+		if !ok {
+			return nil
+		}
+		parsed, err := strconv.ParseUint(v, 10, 32)
+		if err != nil {
+			return fmt.Errorf("%s: %w", key, err)
+		}
+		*n = uint32(parsed)
+		return nil
+	}
+
+	if err := interval("REFRESH_INTERVAL", &s.RefreshInterval); err != nil {
+		return err
+	}
+	if key, v, ok := env("ERROR_THRESHOLD"); ok {
+		p, err := ParsePercent(v)
+		if err != nil {
+			return fmt.Errorf("%s: %w", key, err)
+		}
+		s.ErrorThresholdPercent, s.errorThresholdText = p, ""
+		if p == math.MaxUint8 {
+			s.errorThresholdText = v
+		}
+	}
+	if err := number("REQUEST_VOLUME", &s.RequestVolumeThreshold); err != nil {
+		return err
+	}
+	if err := interval("RECOVERY_INTERVAL", &s.RecoveryInterval); err != nil {
+		return err
+	}
+	if err := number("SUCCESS_VOLUME", &s.SuccessVolumeThreshold); err != nil {
+		return err
+	}
+	if err := interval("SLEEP_WINDOW", &s.SleepWindow); err != nil {
+		return err
+	}
+	if _, v, ok := env("MODE"); ok {
+		s.Mode = v
+	}
+	if _, v, ok := env("DECISION"); ok {
+		s.Decision = v
+	}
+	if key, v, ok := env("LABELS"); ok {
+		labels, err := parseLabels(v)
+		if err != nil {
+			return fmt.Errorf("%s: %w", key, err)
+		}
+		s.Labels = labels
+	}
+
+	return nil
+}
+
+//ApplyEnv overrides settings of all circuit breakers of config by environment variables of prefix
+func (cfg *Config) ApplyEnv(prefix string) error {
+	for i := range cfg.Breakers {
+		if err := cfg.Breakers[i].ApplyEnv(prefix); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func parseLabels(v string) (map[string]string, error) {
+	labels := make(map[string]string)
+	for _, kv := range strings.Split(v, ",") {
+		kv = strings.TrimSpace(kv)
+		if kv == "" {
+			continue
+		}
+		k, v, ok := strings.Cut(kv, "=")
+		if !ok {
+			return nil, fmt.Errorf("label %q is not key=value", kv)
+		}
+		labels[strings.TrimSpace(k)] = strings.TrimSpace(v)
+	}
+
+	return labels, nil
+}
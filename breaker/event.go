@@ -0,0 +1,155 @@
+package breaker
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+)
+
+const defaultEventHistorySize = 128
+
+type EventType uint8
+
+const (
+	EventTrip          EventType = iota + 1 //circuit breaker turns to open
+	EventHalfOpen                           //circuit breaker turns to half-open after sleep window
+	EventReject                             //a sampled request rejected while open
+	EventSkip                               //a scheduled run skipped while open, see RunIfClosed
+	EventUnknownStatus                      //a report found a status that doesn't exist, see WithStatusFallback
+	EventClose                              //circuit breaker turns to closed after successes in half-open
+)
+
+func (t EventType) String() string {
+	switch t {
+	case EventTrip:
+		return "trip"
+	case EventHalfOpen:
+		return "half-open"
+	case EventReject:
+		return "reject"
+	case EventSkip:
+		return "skip"
+	case EventUnknownStatus:
+		return "unknown_status"
+	case EventClose:
+		return "close"
+	default:
+		return "unknown"
+	}
+}
+
+//RequestMeta caller supplied metadata of a request, recorded when the request is sampled
+type RequestMeta struct {
+	Key    string
+	Method string
+}
+
+//Event something happened to circuit breaker. events are values, listeners get copies and history stores them
+//in place, so recording one doesn't allocate and there is nothing to release. keep that when adding fields, no
+//pointers, maps or slices filled per event
+type Event struct {
+	Name   string //name of circuit breaker
+	Type   EventType
+	Time   time.Time
+	Status int32 //status of circuit breaker after the event
+
+	Cause TransitionCause //only for EventTrip, EventHalfOpen and EventClose
+	Until time.Time       //end of sleep window, only for EventTrip
+	Meta  RequestMeta     //only for EventReject and EventSkip
+}
+
+//WithEventHistory keeps the latest size events in memory, see CircuitBreaker.Events
+func WithEventHistory(size int) CircuitBreakerOption {
+	return func(c *CircuitBreaker) {
+		if size > 0 {
+			c.history = newEventHistory(size)
+		}
+	}
+}
+
+//WithRejectSampling records rate (0, 1] of rejected requests into event history
+func WithRejectSampling(rate float64) CircuitBreakerOption {
+	return func(c *CircuitBreaker) {
+		if rate > 0 && rate <= 1 {
+			c.rejectSampleRate = rate
+		}
+	}
+}
+
+//WithEventListener calls f for every event, whether event history is kept or not. f is called synchronously
+//and must not block, it can be given more than once. e is a copy, f may keep it
+func WithEventListener(f func(Event)) CircuitBreakerOption {
+	return func(c *CircuitBreaker) {
+		if f != nil {
+			c.eventListeners = append(c.eventListeners, f)
+		}
+	}
+}
+
+//Events returns events in history from oldest to newest
+func (c *CircuitBreaker) Events() []Event {
+	if c.history == nil {
+		return nil
+	}
+
+	return c.history.list()
+}
+
+//recordEvent adds e to history and passes it to listeners, name and time are filled in
+func (c *CircuitBreaker) recordEvent(e Event) {
+	if c.history == nil && len(c.eventListeners) == 0 {
+		return
+	}
+
+	e.Name = c.name
+	e.Time = time.Now()
+	if c.history != nil {
+		c.history.add(e)
+	}
+	for _, f := range c.eventListeners {
+		f(e)
+	}
+}
+
+func (c *CircuitBreaker) sampleReject(meta RequestMeta) {
+	if c.rejectSampleRate == 0 || rand.Float64() >= c.rejectSampleRate {
+		return
+	}
+
+	c.recordEvent(Event{Type: EventReject, Status: CircuitBreakerStatusOpen, Meta: meta})
+}
+
+type eventHistory struct {
+	mu     sync.Mutex
+	events []Event
+	next   int
+	full   bool
+}
+
+func newEventHistory(size int) *eventHistory {
+	return &eventHistory{events: make([]Event, size)}
+}
+
+func (h *eventHistory) add(e Event) {
+	h.mu.Lock()
+	h.events[h.next] = e
+	h.next++
+	if h.next == len(h.events) {
+		h.next = 0
+		h.full = true
+	}
+	h.mu.Unlock()
+}
+
+func (h *eventHistory) list() []Event {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if !h.full {
+		return append([]Event(nil), h.events[:h.next]...)
+	}
+
+	out := make([]Event, 0, len(h.events))
+	out = append(out, h.events[h.next:]...)
+	return append(out, h.events[:h.next]...)
+}
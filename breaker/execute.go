@@ -0,0 +1,38 @@
+package breaker
+
+import (
+	"context"
+	"runtime/pprof"
+)
+
+//WithPprofLabels makes Execute run fn with pprof labels breaker=<name> and state=<status>,
+//so profiles show how much work runs under each circuit breaker and in which state
+func WithPprofLabels() CircuitBreakerOption {
+	return func(c *CircuitBreaker) {
+		c.pprofLabels = true
+	}
+}
+
+//Execute reports a request, runs fn if circuit breaker allows it and reports an error if fn fails.
+//it returns the error of ReportRequest when rejected, otherwise the error of fn
+func (c *CircuitBreaker) Execute(ctx context.Context, fn func(ctx context.Context) error) error {
+	status := c.Status()
+	if err := c.ReportRequest(); err != nil {
+		return err
+	}
+
+	var err error
+	if c.pprofLabels {
+		pprof.Do(ctx, pprof.Labels("breaker", c.name, "state", StatusText(status)), func(ctx context.Context) {
+			err = fn(ctx)
+		})
+	} else {
+		err = fn(ctx)
+	}
+
+	if err != nil {
+		c.ReportErrorContext(ctx)
+	}
+
+	return err
+}
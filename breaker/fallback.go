@@ -0,0 +1,34 @@
+package breaker
+
+//StatusFallback how circuit breaker treats requests when it finds a status that doesn't exist, which only a bug
+//can store. reports never panic on it
+type StatusFallback int32
+
+const (
+	FallbackPass   StatusFallback = iota //requests pass uncounted, as if closed
+	FallbackReject                       //requests are rejected, as if open
+)
+
+func (f StatusFallback) String() string {
+	switch f {
+	case FallbackPass:
+		return "pass"
+	case FallbackReject:
+		return "reject"
+	default:
+		return "unknown"
+	}
+}
+
+//WithStatusFallback chooses how requests are treated in an unknown status, FallbackPass by default. every report
+//finding one records EventUnknownStatus with the status found and errors of it are dropped
+func WithStatusFallback(f StatusFallback) CircuitBreakerOption {
+	return func(c *CircuitBreaker) {
+		c.statusFallback = f
+	}
+}
+
+//recordUnknownStatus records that a report found unknown status
+func (c *CircuitBreaker) recordUnknownStatus(status int32) {
+	c.recordEvent(Event{Type: EventUnknownStatus, Status: status})
+}
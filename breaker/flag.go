@@ -0,0 +1,105 @@
+package breaker
+
+import (
+	"flag"
+	"fmt"
+	"strconv"
+)
+
+//RegisterFlags defines flags of settings of a circuit breaker in fs, named prefix-field like the fields of
+//EnvName in lower case, e.g. -payments-cb-error-threshold=25 for prefix payments-cb. the returned settings are
+//filled in when fs is parsed, flags not given stay zero and take defaults. name is not a flag, set it before use
+func RegisterFlags(fs *flag.FlagSet, prefix string) *Settings {
+	s := &Settings{}
+	d := Defaults()
+	name := func(field string) string {
+		if prefix == "" {
+			return field
+		}
+		return prefix + "-" + field
+	}
+
+	fs.DurationVar(&s.RefreshInterval, name("refresh-interval"), 0,
+		fmt.Sprintf("circuit breaker statistical period (default %s)", d.RefreshInterval))
+	fs.Var(percentFlag{p: &s.ErrorThresholdPercent}, name("error-threshold"),
+		fmt.Sprintf("circuit breaker error threshold percent (default %d)", d.ErrorThresholdPercent))
+	fs.Var(uint32Flag{n: &s.RequestVolumeThreshold}, name("request-volume"),
+		fmt.Sprintf("circuit breaker request volume threshold (default %d)", d.RequestVolumeThreshold))
+	fs.DurationVar(&s.RecoveryInterval, name("recovery-interval"), 0,
+		fmt.Sprintf("circuit breaker recovery interval (default %s)", d.RecoveryInterval))
+	fs.Var(uint32Flag{n: &s.SuccessVolumeThreshold}, name("success-volume"),
+		fmt.Sprintf("circuit breaker success volume threshold (default %d)", d.SuccessVolumeThreshold))
+	fs.DurationVar(&s.SleepWindow, name("sleep-window"), 0,
+		fmt.Sprintf("circuit breaker sleep window (default %s)", d.SleepWindow))
+	fs.StringVar(&s.Mode, name("mode"), "", "circuit breaker mode: normal, force-open, force-closed or shadow")
+	fs.StringVar(&s.Decision, name("decision"), "", "circuit breaker decision: hybrid, local or global")
+	fs.Var(labelsFlag{s: s}, name("labels"), "circuit breaker labels as comma separated key=value pairs")
+
+	return s
+}
+
+type uint32Flag struct {
+	n *uint32
+}
+
+func (f uint32Flag) String() string {
+	if f.n == nil || *f.n == 0 {
+		return ""
+	}
+
+	return strconv.FormatUint(uint64(*f.n), 10)
+}
+
+func (f uint32Flag) Set(v string) error {
+	parsed, err := strconv.ParseUint(v, 10, 32)
+	if err != nil {
+		return err
+	}
+	*f.n = uint32(parsed)
+
+	return nil
+}
+
+type percentFlag struct {
+	p *uint8
+}
+
+func (f percentFlag) String() string {
+	if f.p == nil || *f.p == 0 {
+		return ""
+	}
+
+	return strconv.Itoa(int(*f.p)) + "%"
+}
+
+func (f percentFlag) Set(v string) error {
+	p, err := ParsePercent(v)
+	if err != nil {
+		return err
+	}
+	*f.p = p
+
+	return nil
+}
+
+type labelsFlag struct {
+	s *Settings
+}
+
+func (f labelsFlag) String() string {
+	if f.s == nil {
+		return ""
+	}
+
+	return formatLabels(f.s.Labels)
+}
+
+func (f labelsFlag) Set(v string) error {
+	labels, err := parseLabels(v)
+	if err != nil {
+		return err
+	}
+	f.s.Labels = labels
+
+	return nil
+}
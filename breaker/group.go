@@ -0,0 +1,150 @@
+package breaker
+
+import (
+	"path"
+	"sort"
+	"sync"
+)
+
+//Group creates and holds circuit breakers by key on demand, e.g. one per upstream host. see WithCompactBreakers
+//for millions of keys
+type Group struct {
+	mu       sync.RWMutex
+	breakers map[string]*CircuitBreaker
+	opts     []CircuitBreakerOption
+	patterns []groupPattern
+	rollout  *Rollout                //see StartRollout
+	rolled   []CircuitBreakerOption  //settings of complete rollouts, applied after patterns
+	compact  map[int]*CircuitBreaker //first compact circuit breaker by index of the pattern it matched, -1 for none
+}
+
+type groupPattern struct {
+	pattern string
+	opts    []CircuitBreakerOption
+}
+
+//NewGroup return a group, every circuit breaker in it is created with opts and named by its key
+func NewGroup(opts ...CircuitBreakerOption) *Group {
+	return &Group{
+		breakers: make(map[string]*CircuitBreaker),
+		opts:     opts,
+	}
+}
+
+//Match sets extra options for circuit breakers whose key matches pattern in path.Match syntax,
+//e.g. "/pkg.Export/Batch*". patterns are tried in order and only the first matched is applied.
+//it only affects circuit breakers created afterwards
+func (g *Group) Match(pattern string, opts ...CircuitBreakerOption) error {
+	if _, err := path.Match(pattern, ""); err != nil {
+		return err
+	}
+
+	g.mu.Lock()
+	g.patterns = append(g.patterns, groupPattern{pattern: pattern, opts: opts})
+	g.mu.Unlock()
+
+	return nil
+}
+
+//Get returns circuit breaker of key, creates it if not exists
+func (g *Group) Get(key string) *CircuitBreaker {
+	g.mu.RLock()
+	cb, ok := g.breakers[key]
+	g.mu.RUnlock()
+	if ok {
+		return cb
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if cb, ok = g.breakers[key]; ok {
+		return cb
+	}
+
+	matched := -1
+	for i, p := range g.patterns {
+		if ok, _ := path.Match(p.pattern, key); ok {
+			matched = i
+			break
+		}
+	}
+
+	//compact circuit breakers of a pattern share the settings of the first
+	if first, ok := g.compact[matched]; ok {
+		cb = first.sibling(key)
+		g.breakers[key] = cb
+		return cb
+	}
+
+	opts := make([]CircuitBreakerOption, 0, len(g.opts)+len(g.rolled)+1)
+	opts = append(opts, g.opts...)
+	if matched >= 0 {
+		opts = append(opts, g.patterns[matched].opts...)
+	}
+	opts = append(opts, g.rolled...)
+	opts = append(opts, WithName(key))
+
+	cb = New(opts...)
+	g.breakers[key] = cb
+
+	if cb.compact {
+		if g.compact == nil {
+			g.compact = make(map[int]*CircuitBreaker)
+		}
+		g.compact[matched] = cb
+		return cb
+	}
+
+	if g.rollout != nil && g.rollout.Includes(key) {
+		g.rollout.apply(key, cb)
+	}
+
+	return cb
+}
+
+//lookup returns circuit breaker of key if it's created
+func (g *Group) lookup(key string) (*CircuitBreaker, bool) {
+	g.mu.RLock()
+	cb, ok := g.breakers[key]
+	g.mu.RUnlock()
+
+	return cb, ok
+}
+
+//Keys returns sorted keys of created circuit breakers
+func (g *Group) Keys() []string {
+	g.mu.RLock()
+	keys := make([]string, 0, len(g.breakers))
+	for key := range g.breakers {
+		keys = append(keys, key)
+	}
+	g.mu.RUnlock()
+
+	sort.Strings(keys)
+	return keys
+}
+
+//Remove closes and removes circuit breaker of key
+func (g *Group) Remove(key string) {
+	g.mu.Lock()
+	cb, ok := g.breakers[key]
+	delete(g.breakers, key)
+	g.mu.Unlock()
+
+	if ok {
+		cb.Close()
+	}
+}
+
+//Close closes all circuit breakers in group
+func (g *Group) Close() {
+	g.mu.Lock()
+	breakers := g.breakers
+	g.breakers = make(map[string]*CircuitBreaker)
+	g.mu.Unlock()
+
+	for _, cb := range breakers {
+		cb.Close()
+	}
+}
@@ -0,0 +1,98 @@
+package breaker
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"reflect"
+)
+
+//kinds of integrations, see Registry.Route
+const (
+	IntegrationHTTP = "http" //keys are hosts of requests, see breakerhttp.NewRegistryTransport
+	IntegrationGRPC = "grpc" //keys are full method names, see breakergrpc.RegistryUnaryClientInterceptor
+)
+
+//Integration routes calls of an adapter to a circuit breaker of the config, e.g.
+//
+//	integrations:
+//	  - http: api.stripe.com
+//	    breaker: payments
+//	  - grpc: /pkg.Svc/*
+//	    breaker: pkg
+//
+//exactly one of http and grpc is set, in path.Match syntax. integrations are tried in order and only the first
+//matched is used, calls routed to none are not guarded
+type Integration struct {
+	HTTP    string `json:"http,omitempty" yaml:"http,omitempty" mapstructure:"http"`
+	GRPC    string `json:"grpc,omitempty" yaml:"grpc,omitempty" mapstructure:"grpc"`
+	Breaker string `json:"breaker" yaml:"breaker" mapstructure:"breaker"` //name of a circuit breaker of the config
+}
+
+//Kind returns kind of the integration and its pattern, "" if none or both are set
+func (in Integration) Kind() (string, string) {
+	switch {
+	case in.HTTP != "" && in.GRPC == "":
+		return IntegrationHTTP, in.HTTP
+	case in.GRPC != "" && in.HTTP == "":
+		return IntegrationGRPC, in.GRPC
+	default:
+		return "", ""
+	}
+}
+
+//validate checks integration against names of circuit breakers of its config
+func (in Integration) validate(names map[string]bool) error {
+	kind, pattern := in.Kind()
+	if kind == "" {
+		return errors.New("want exactly one of http and grpc")
+	}
+	if _, err := path.Match(pattern, ""); err != nil {
+		return fmt.Errorf("%s %q: %w", kind, pattern, err)
+	}
+	if !names[in.Breaker] {
+		return fmt.Errorf("unknown circuit breaker %q", in.Breaker)
+	}
+
+	return nil
+}
+
+//Route returns circuit breaker of the first integration of kind matching key, see Config.Integrations
+func (r *Registry) Route(kind, key string) (*CircuitBreaker, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, in := range r.integrations {
+		k, pattern := in.Kind()
+		if k != kind {
+			continue
+		}
+		if ok, _ := path.Match(pattern, key); ok {
+			cb, ok := r.breakers[in.Breaker]
+			return cb, ok
+		}
+	}
+
+	return nil, false
+}
+
+//Integrations returns integrations of the config applied last
+func (r *Registry) Integrations() []Integration {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return append([]Integration(nil), r.integrations...)
+}
+
+//setIntegrations replaces integrations of registry, it reports whether they changed
+func (r *Registry) setIntegrations(integrations []Integration) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if len(integrations) == 0 && len(r.integrations) == 0 || reflect.DeepEqual(integrations, r.integrations) {
+		return false
+	}
+	r.integrations = append([]Integration(nil), integrations...)
+
+	return true
+}
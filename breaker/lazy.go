@@ -0,0 +1,32 @@
+package breaker
+
+import (
+	"time"
+)
+
+//WithLazyExpiry ends sleep windows on the first call after them instead of on a timer, so circuit breaker runs
+//no goroutine at all, e.g. in short-lived programs and tests. windows roll lazily anyway. Status and every
+//Report call check the sleep window, it stays open until one of them. options syncing with other instances,
+//like WithWindowCounter, WithStore and WithSummaryLog, still run goroutines of their own
+func WithLazyExpiry() CircuitBreakerOption {
+	return func(c *CircuitBreaker) {
+		c.lazy = true
+	}
+}
+
+//expireSleepWindow turns a lazy circuit breaker to half-open if the sleep window of its trip passed
+func (c *CircuitBreaker) expireSleepWindow() {
+	if !c.lazy {
+		return
+	}
+	status, gen := c.loadState()
+	if status != CircuitBreakerStatusOpen {
+		return
+	}
+	t, ok := c.tripOf(gen)
+	if !ok || time.Now().Before(t.until) || !c.transition(CircuitBreakerStatusOpen, gen, CircuitBreakerStatusHalfOpen) {
+		return
+	}
+
+	c.reportHalfOpen(gen+1, t.sleepWindow)
+}
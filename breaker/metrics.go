@@ -0,0 +1,73 @@
+package breaker
+
+import (
+	"context"
+	"time"
+)
+
+//names of metrics reported to MetricsSink
+const (
+	MetricRequests     = "requests"      //counter, requests passed to backend
+	MetricRejected     = "rejected"      //counter, requests rejected because circuit breaker is open
+	MetricErrors       = "errors"        //counter, error requests reported
+	MetricTrips        = "trips"         //counter, times circuit breaker turns to open
+	MetricStatus       = "status"        //gauge, current status of circuit breaker
+	MetricOpenDuration = "open_duration" //duration, time circuit breaker stays open before half-open
+	MetricLatency      = "latency"       //duration, latency of requests reported by ReportLatency
+	MetricSuccesses    = "successes"     //counter, successful requests reported by ReportBatch
+	MetricSkipped      = "skipped"       //counter, scheduled runs skipped by RunIfClosed
+
+	MetricShadowRejected = "shadow_rejected" //counter, requests passed in ModeShadow which would have been rejected
+	MetricSyncErrors     = "sync_errors"     //counter, failed syncs with shared state, e.g. WindowCounter
+)
+
+//MetricsSink receives metrics of a circuit breaker, so any monitoring system can be wired in.
+//it is called on the hot path and must not block.
+type MetricsSink interface {
+	IncrCounter(name string, delta uint32)
+	SetGauge(name string, value float64)
+	ObserveDuration(name string, d time.Duration)
+}
+
+//ContextMetricsSink is an optional extension of MetricsSink. counters of errors and trips are reported with
+//the context passed to ReportErrorContext, so the sink can link them to the active trace
+type ContextMetricsSink interface {
+	MetricsSink
+	IncrCounterContext(ctx context.Context, name string, delta uint32)
+}
+
+type noopMetricsSink struct{}
+
+func (noopMetricsSink) IncrCounter(string, uint32)            {}
+func (noopMetricsSink) SetGauge(string, float64)              {}
+func (noopMetricsSink) ObserveDuration(string, time.Duration) {}
+
+func WithMetricsSink(s MetricsSink) CircuitBreakerOption {
+	return func(c *CircuitBreaker) {
+		if s != nil {
+			c.metrics = s
+		}
+	}
+}
+
+//WithMetricsSinkOf sets metrics sink f returns for the name of circuit breaker, once all options are applied.
+//it names sinks of circuit breakers created by config, whose names come after the options shared by all of them
+func WithMetricsSinkOf(f func(name string) MetricsSink) CircuitBreakerOption {
+	return func(c *CircuitBreaker) {
+		c.metricsOf = f
+	}
+}
+
+//ReportLatency reports latency of a request to metrics sink
+func (c *CircuitBreaker) ReportLatency(d time.Duration) {
+	c.metrics.ObserveDuration(MetricLatency, d)
+}
+
+func (c *CircuitBreaker) incrCounter(ctx context.Context, name string, delta uint32) {
+	if s, ok := c.metrics.(ContextMetricsSink); ok {
+		s.IncrCounterContext(ctx, name, delta)
+		return
+	}
+
+	c.metrics.IncrCounter(name, delta)
+}
@@ -0,0 +1,138 @@
+package breaker
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+//Mode overrides how circuit breaker treats requests regardless of errors, e.g. driven by a feature flag
+type Mode int32
+
+const (
+	ModeNormal      Mode = iota //status follows errors
+	ModeForceOpen               //reject all requests, errors don't change status
+	ModeForceClosed             //pass all requests, errors don't change status
+	ModeShadow                  //status follows errors but no request is rejected, see MetricShadowRejected
+)
+
+func (m Mode) String() string {
+	switch m {
+	case ModeNormal:
+		return "normal"
+	case ModeForceOpen:
+		return "force-open"
+	case ModeForceClosed:
+		return "force-closed"
+	case ModeShadow:
+		return "shadow"
+	default:
+		return "unknown"
+	}
+}
+
+//ParseMode parses text returned by Mode.String
+func ParseMode(s string) (Mode, error) {
+	for _, m := range []Mode{ModeNormal, ModeForceOpen, ModeForceClosed, ModeShadow} {
+		if m.String() == s {
+			return m, nil
+		}
+	}
+
+	return ModeNormal, fmt.Errorf("unknown circuit breaker mode %q", s)
+}
+
+//WithMode sets initial mode of circuit breaker, default ModeNormal
+func WithMode(m Mode) CircuitBreakerOption {
+	return func(c *CircuitBreaker) {
+		c.mode = int32(m)
+	}
+}
+
+//SetMode changes mode of circuit breaker, it takes effect from the next request
+func (c *CircuitBreaker) SetMode(m Mode) {
+	c.SetModeFor(m, 0)
+}
+
+//SetModeFor works like SetMode, the mode reverts to ModeNormal after ttl unless it is changed again before,
+//so an emergency override can't be forgotten. ttl 0 keeps it until changed
+func (c *CircuitBreaker) SetModeFor(m Mode, ttl time.Duration) {
+	r := c.reverter()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.timer != nil {
+		r.timer.Stop()
+		r.timer = nil
+	}
+	atomic.StoreInt32(&c.mode, int32(m))
+	atomic.StoreInt64(&r.until, 0)
+
+	if ttl <= 0 || m == ModeNormal {
+		return
+	}
+
+	atomic.StoreInt64(&r.until, time.Now().Add(ttl).UnixNano())
+	var t *time.Timer
+	t = time.AfterFunc(ttl, func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+
+		//changed in the meantime
+		if r.timer != t {
+			return
+		}
+		r.timer = nil
+		atomic.StoreInt32(&c.mode, int32(ModeNormal))
+		atomic.StoreInt64(&r.until, 0)
+	})
+	r.timer = t
+}
+
+//modeRevert reverts the mode of a circuit breaker set by SetModeFor, allocated by the first SetMode
+type modeRevert struct {
+	mu    sync.Mutex  //guards timer
+	timer *time.Timer //reverts mode
+	until int64       //unix nano when mode reverts to normal, 0 if it doesn't
+}
+
+//reverter returns the modeRevert of circuit breaker, allocating it once
+func (c *CircuitBreaker) reverter() *modeRevert {
+	if r := c.revert.Load(); r != nil {
+		return r
+	}
+	c.revert.CompareAndSwap(nil, &modeRevert{})
+
+	return c.revert.Load()
+}
+
+//ForceOpen rejects all requests for ttl, ttl 0 until mode is changed. it's SetModeFor with ModeForceOpen
+func (c *CircuitBreaker) ForceOpen(ttl time.Duration) {
+	c.SetModeFor(ModeForceOpen, ttl)
+}
+
+//ModeUntil returns when current mode reverts to ModeNormal, zero if it doesn't
+func (c *CircuitBreaker) ModeUntil() time.Time {
+	r := c.revert.Load()
+	if r == nil {
+		return time.Time{}
+	}
+	until := atomic.LoadInt64(&r.until)
+	if until == 0 {
+		return time.Time{}
+	}
+
+	return time.Unix(0, until)
+}
+
+//Mode returns current mode of circuit breaker
+func (c *CircuitBreaker) Mode() Mode {
+	return Mode(atomic.LoadInt32(&c.mode))
+}
+
+//SetOpenConfig replaces open config of circuit breaker. thresholds take effect from the next error,
+//a new refresh interval starts counting anew
+func (c *CircuitBreaker) SetOpenConfig(oc CircuitBreakerOpenConfig) {
+	WithOpenConfig(oc)(c)
+}
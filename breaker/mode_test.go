@@ -0,0 +1,38 @@
+package breaker
+
+import (
+	"testing"
+	"time"
+)
+
+func TestModeStatus(t *testing.T) {
+	for _, tc := range []struct {
+		mode      Mode
+		ttl       time.Duration
+		tripped   bool
+		status    int32
+		remaining func(d time.Duration) bool
+	}{
+		{ModeNormal, 0, false, CircuitBreakerStatusClosed, func(d time.Duration) bool { return d == 0 }},
+		{ModeNormal, 0, true, CircuitBreakerStatusOpen, func(d time.Duration) bool { return d > 50*time.Minute && d <= time.Hour }},
+		{ModeForceOpen, 0, false, CircuitBreakerStatusOpen, func(d time.Duration) bool { return d == 7*time.Second }},
+		{ModeForceOpen, time.Minute, false, CircuitBreakerStatusOpen, func(d time.Duration) bool { return d > 50*time.Second && d <= time.Minute }},
+		{ModeForceClosed, 0, true, CircuitBreakerStatusClosed, func(d time.Duration) bool { return d == 0 }},
+		{ModeShadow, 0, true, CircuitBreakerStatusOpen, func(d time.Duration) bool { return d > 50*time.Minute && d <= time.Hour }},
+	} {
+		c := New(WithLazyExpiry(), WithSleepWindow(7*time.Second))
+		if tc.tripped {
+			c.TripFor(time.Hour)
+		}
+		c.SetModeFor(tc.mode, tc.ttl)
+
+		if s := c.Status(); s != tc.status {
+			t.Errorf("%s for %s, tripped %t: status %s, want %s", tc.mode, tc.ttl, tc.tripped, StatusText(s), StatusText(tc.status))
+		}
+		if d := c.RemainingSleepWindow(); !tc.remaining(d) {
+			t.Errorf("%s for %s, tripped %t: remaining sleep window %s", tc.mode, tc.ttl, tc.tripped, d)
+		}
+		c.SetMode(ModeNormal)
+		c.Close()
+	}
+}
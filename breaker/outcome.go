@@ -0,0 +1,17 @@
+package breaker
+
+//Outcome classifies a finished request
+type Outcome uint8
+
+const (
+	OutcomeSuccess Outcome = iota
+	OutcomeFailure
+)
+
+func (o Outcome) String() string {
+	if o == OutcomeFailure {
+		return "failure"
+	}
+
+	return "success"
+}
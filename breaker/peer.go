@@ -0,0 +1,70 @@
+package breaker
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+)
+
+const defaultPublishTimeout = 5 * time.Second
+
+//TransitionPublisher publishes transitions to peers, e.g. through etcd or pub/sub
+type TransitionPublisher interface {
+	PublishTransition(ctx context.Context, t Transition) error
+}
+
+//TransitionWatcher calls fn for transitions published by peers until ctx is done or watching fails.
+//transitions published by the same instance are not passed to fn
+type TransitionWatcher interface {
+	WatchTransitions(ctx context.Context, fn func(Transition)) error
+}
+
+//WithTransitionPublisher publishes trips to p in background, except trips caused by peers and trips of DecisionLocal.
+//errors are counted as MetricSyncErrors
+func WithTransitionPublisher(p TransitionPublisher) CircuitBreakerOption {
+	return func(c *CircuitBreaker) {
+		WithTransitionListener(func(t Transition) {
+			if t.To != CircuitBreakerStatusOpen || t.Cause == CausePeer || !c.shares() {
+				return
+			}
+
+			go func() {
+				ctx, cancel := context.WithTimeout(context.Background(), defaultPublishTimeout)
+				defer cancel()
+
+				if err := p.PublishTransition(ctx, t); err != nil {
+					c.metrics.IncrCounter(MetricSyncErrors, 1)
+				}
+			}()
+		})(c)
+	}
+}
+
+//FollowPeers opens local circuit breakers for the remaining sleep window of peers when they trip,
+//until ctx is done or w fails. lookup returns the local circuit breaker of a name, e.g. Registry.Get
+func FollowPeers(ctx context.Context, w TransitionWatcher, lookup func(name string) (*CircuitBreaker, bool)) error {
+	return w.WatchTransitions(ctx, func(t Transition) {
+		if t.To != CircuitBreakerStatusOpen {
+			return
+		}
+
+		if cb, ok := lookup(t.Name); ok {
+			cb.TripFromPeer(time.Until(t.Until))
+		}
+	})
+}
+
+//SetFollowing makes circuit breaker follow decisions of peers or a coordinator. while following, counts don't trip it,
+//neither its own nor shared ones, it opens by Trip or TripFromPeer. a failed request in half-open still reopens it
+func (c *CircuitBreaker) SetFollowing(following bool) {
+	var v int32
+	if following {
+		v = 1
+	}
+	atomic.StoreInt32(&c.following, v)
+}
+
+//Following reports whether circuit breaker follows decisions of others, see SetFollowing
+func (c *CircuitBreaker) Following() bool {
+	return atomic.LoadInt32(&c.following) == 1
+}
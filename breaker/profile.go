@@ -0,0 +1,82 @@
+package breaker
+
+import (
+	"fmt"
+	"os"
+)
+
+//ProfileEnv is the environment variable choosing the profile of config files read by LoadConfig, e.g.
+//CB_PROFILE=production. no profile is used if it's empty
+const ProfileEnv = EnvPrefix + "_PROFILE"
+
+//Profile overrides a config for an environment, see Config.Resolve
+type Profile struct {
+	Defaults Settings   `json:"defaults,omitempty" yaml:"defaults,omitempty" mapstructure:"defaults"`
+	Breakers []Settings `json:"breakers,omitempty" yaml:"breakers,omitempty" mapstructure:"breakers"`
+}
+
+//Resolve returns config of profile, "" for none, with defaults folded into every circuit breaker. fields that
+//are not zero override, from lowest to highest: defaults, defaults of the profile, settings of the circuit
+//breaker, settings of the same name in the profile. templates a circuit breaker extends come right before its
+//settings. circuit breakers only in the profile are added
+func (cfg *Config) Resolve(profile string) (*Config, error) {
+	defaults := cfg.Defaults
+	var p Profile
+	if profile != "" {
+		var ok bool
+		if p, ok = cfg.Profiles[profile]; !ok {
+			return nil, fmt.Errorf("unknown circuit breaker config profile %q", profile)
+		}
+		defaults = defaults.Override(p.Defaults)
+	}
+
+	overrides := make(map[string]Settings, len(p.Breakers))
+	for _, s := range p.Breakers {
+		overrides[s.Name] = s
+	}
+
+	out := &Config{
+		Breakers:     make([]Settings, 0, len(cfg.Breakers)+len(p.Breakers)),
+		Integrations: cfg.Integrations,
+	}
+	for _, s := range cfg.Breakers {
+		s, err := cfg.extend(s)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", s.Name, err)
+		}
+		s = defaults.Override(s)
+		if o, ok := overrides[s.Name]; ok && s.Name != "" {
+			if o, err = cfg.extend(o); err != nil {
+				return nil, fmt.Errorf("%s: %w", s.Name, err)
+			}
+			s = s.Override(o)
+			delete(overrides, s.Name)
+		}
+		out.Breakers = append(out.Breakers, s)
+	}
+	for _, s := range p.Breakers {
+		if _, ok := overrides[s.Name]; ok {
+			s, err := cfg.extend(s)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", s.Name, err)
+			}
+			out.Breakers = append(out.Breakers, defaults.Override(s))
+			delete(overrides, s.Name)
+		}
+	}
+
+	return out, nil
+}
+
+//resolveEnv resolves cfg for the profile of ProfileEnv and overrides it by environment variables
+func (cfg *Config) resolveEnv() (*Config, error) {
+	cfg, err := cfg.Resolve(os.Getenv(ProfileEnv))
+	if err != nil {
+		return nil, err
+	}
+	if err := cfg.ApplyEnv(EnvPrefix); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
@@ -0,0 +1,45 @@
+package breaker
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+//probe counts successes of requests passed while circuit breaker is half-open in generation gen
+type probe struct {
+	gen       uint32
+	since     time.Time //when circuit breaker turned half-open
+	successes atomic.Uint64
+}
+
+//startProbe starts counting successes of half-open generation gen, called once circuit breaker turned half-open
+func (c *CircuitBreaker) startProbe(gen uint32) {
+	c.probe.Store(&probe{gen: gen, since: time.Now()})
+}
+
+//recover counts n successes in half-open generation gen and turns circuit breaker to closed once they reach
+//SuccessVolumeThreshold and RecoveryInterval passed since it turned half-open. a request passed in half-open
+//counts as a success unless an error is reported, which opens circuit breaker again
+func (c *CircuitBreaker) recover(gen uint32, n uint32) {
+	p := c.probe.Load()
+	if p == nil || p.gen != gen || n == 0 {
+		return
+	}
+
+	cc := c.closeConfig.Load()
+	if p.successes.Add(uint64(n)) < uint64(cc.SuccessVolumeThreshold) || time.Since(p.since) < cc.RecoveryInterval {
+		return
+	}
+	if !c.transition(CircuitBreakerStatusHalfOpen, gen, CircuitBreakerStatusClosed) {
+		return
+	}
+
+	//counts before the trip don't count against the recovered backend
+	c.window.reset()
+	c.metrics.SetGauge(MetricStatus, float64(CircuitBreakerStatusClosed))
+	c.recordEvent(Event{Type: EventClose, Status: CircuitBreakerStatusClosed, Cause: CauseRecovered})
+	c.notifyTransition(CircuitBreakerStatusHalfOpen, CircuitBreakerStatusClosed, CauseRecovered)
+	if c.callback != nil {
+		c.callback()
+	}
+}
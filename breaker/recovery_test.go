@@ -0,0 +1,172 @@
+package breaker
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+//halfOpened returns a lazy circuit breaker of cc tripped once and half-open, and counts of its callback
+func halfOpened(t *testing.T, cc CircuitBreakerCloseConfig, opts ...CircuitBreakerOption) (*CircuitBreaker, *int32) {
+	t.Helper()
+
+	var calls int32
+	opts = append([]CircuitBreakerOption{
+		WithLazyExpiry(),
+		WithCloseConfig(cc),
+		WithCallback(func() { atomic.AddInt32(&calls, 1) }),
+	}, opts...)
+	c := New(opts...)
+	t.Cleanup(c.Close)
+
+	if !c.TripFor(time.Millisecond) {
+		t.Fatal("TripFor of a closed circuit breaker failed")
+	}
+	time.Sleep(2 * time.Millisecond)
+	if s := c.Status(); s != CircuitBreakerStatusHalfOpen {
+		t.Fatalf("status %s after the sleep window, want half-open", StatusText(s))
+	}
+
+	return c, &calls
+}
+
+func TestRecover(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		cc       CircuitBreakerCloseConfig
+		requests uint32
+		wait     time.Duration
+		want     int32
+	}{
+		{"successes reach threshold", CircuitBreakerCloseConfig{SuccessVolumeThreshold: 3}, 3, 0, CircuitBreakerStatusClosed},
+		{"successes below threshold", CircuitBreakerCloseConfig{SuccessVolumeThreshold: 3}, 2, 0, CircuitBreakerStatusHalfOpen},
+		{"recovery interval not passed", CircuitBreakerCloseConfig{RecoveryInterval: time.Hour, SuccessVolumeThreshold: 1}, 5, 0, CircuitBreakerStatusHalfOpen},
+		{"recovery interval passed", CircuitBreakerCloseConfig{RecoveryInterval: 5 * time.Millisecond, SuccessVolumeThreshold: 1}, 1, 10 * time.Millisecond, CircuitBreakerStatusClosed},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			c, calls := halfOpened(t, tc.cc)
+			time.Sleep(tc.wait)
+			for i := uint32(0); i < tc.requests; i++ {
+				if err := c.ReportRequest(); err != nil {
+					t.Fatalf("request %d in half-open: %v", i, err)
+				}
+			}
+
+			if s := c.Status(); s != tc.want {
+				t.Fatalf("status %s, want %s", StatusText(s), StatusText(tc.want))
+			}
+			//once for the trip from closed, once more for the close
+			want := int32(1)
+			if tc.want == CircuitBreakerStatusClosed {
+				want = 2
+			}
+			if n := atomic.LoadInt32(calls); n != want {
+				t.Errorf("callback called %d times, want %d", n, want)
+			}
+		})
+	}
+}
+
+func TestRecoverStartsOverAfterError(t *testing.T) {
+	c, calls := halfOpened(t, CircuitBreakerCloseConfig{SuccessVolumeThreshold: 3})
+	c.ReportRequestN(2)
+	c.ReportError()
+	if s := c.Status(); s != CircuitBreakerStatusOpen {
+		t.Fatalf("status %s after an error in half-open, want open", StatusText(s))
+	}
+
+	//the error reopened it for the configured sleep window, end it early
+	_, gen := c.loadState()
+	if !c.transition(CircuitBreakerStatusOpen, gen, CircuitBreakerStatusHalfOpen) {
+		t.Fatal("transition to half-open failed")
+	}
+	c.reportHalfOpen(gen+1, 0)
+	c.ReportRequestN(2)
+	if s := c.Status(); s != CircuitBreakerStatusHalfOpen {
+		t.Fatalf("status %s, successes before the error counted", StatusText(s))
+	}
+	c.ReportRequest()
+	if s := c.Status(); s != CircuitBreakerStatusClosed {
+		t.Fatalf("status %s, want closed", StatusText(s))
+	}
+	//the reopen from half-open doesn't call it
+	if n := atomic.LoadInt32(calls); n != 2 {
+		t.Errorf("callback called %d times, want 2", n)
+	}
+}
+
+func TestRecoverRace(t *testing.T) {
+	var closes int32
+	c, calls := halfOpened(t, CircuitBreakerCloseConfig{SuccessVolumeThreshold: 100},
+		WithTransitionListener(func(tr Transition) {
+			if tr.From == CircuitBreakerStatusHalfOpen && tr.To == CircuitBreakerStatusClosed {
+				atomic.AddInt32(&closes, 1)
+			}
+		}))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				c.ReportRequest()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if s := c.Status(); s != CircuitBreakerStatusClosed {
+		t.Fatalf("status %s, want closed", StatusText(s))
+	}
+	if n := atomic.LoadInt32(&closes); n != 1 {
+		t.Errorf("%d transitions to closed, want 1", n)
+	}
+	if n := atomic.LoadInt32(calls); n != 2 {
+		t.Errorf("callback called %d times, want 2", n)
+	}
+}
+
+func TestSetCloseConfig(t *testing.T) {
+	c, _ := halfOpened(t, CircuitBreakerCloseConfig{RecoveryInterval: time.Hour, SuccessVolumeThreshold: 100})
+	c.ReportRequestN(2)
+	if s := c.Status(); s != CircuitBreakerStatusHalfOpen {
+		t.Fatalf("status %s, want half-open", StatusText(s))
+	}
+
+	c.SetSuccessVolumeThreshold(3)
+	if err := c.SetRecoveryInterval(time.Nanosecond); err != nil {
+		t.Fatal(err)
+	}
+	c.ReportRequest()
+	if s := c.Status(); s != CircuitBreakerStatusClosed {
+		t.Fatalf("status %s after lowering thresholds, want closed", StatusText(s))
+	}
+}
+
+func TestReportBatchRecovers(t *testing.T) {
+	c, _ := halfOpened(t, CircuitBreakerCloseConfig{SuccessVolumeThreshold: 10})
+	if err := c.ReportBatch(6, 0, 6, 0); err != nil {
+		t.Fatal(err)
+	}
+	if s := c.Status(); s != CircuitBreakerStatusHalfOpen {
+		t.Fatalf("status %s after 6 successes, want half-open", StatusText(s))
+	}
+	if err := c.ReportBatch(4, 0, 4, 0); err != nil {
+		t.Fatal(err)
+	}
+	if s := c.Status(); s != CircuitBreakerStatusClosed {
+		t.Fatalf("status %s after 10 successes, want closed", StatusText(s))
+	}
+}
+
+func TestReportBatchErrorsInHalfOpen(t *testing.T) {
+	c, _ := halfOpened(t, CircuitBreakerCloseConfig{SuccessVolumeThreshold: 10})
+	if err := c.ReportBatch(20, 1, 19, 0); err != ErrTooManyErrors {
+		t.Fatalf("batch with an error in half-open: %v, want ErrTooManyErrors", err)
+	}
+	if s := c.Status(); s != CircuitBreakerStatusOpen {
+		t.Fatalf("status %s, want open", StatusText(s))
+	}
+}
@@ -0,0 +1,139 @@
+package breaker
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"time"
+)
+
+var (
+	errEmptyName     = errors.New("circuit breaker has no name")
+	errDuplicateName = errors.New("circuit breaker name already registered")
+)
+
+//Snapshot point-in-time state of a circuit breaker
+type Snapshot struct {
+	Name          string
+	Status        int32
+	RequestVolume uint64
+	ErrorVolume   uint64
+
+	OpenConfig  CircuitBreakerOpenConfig
+	CloseConfig CircuitBreakerCloseConfig
+	SleepWindow time.Duration
+}
+
+//Snapshot returns current state of circuit breaker
+func (c *CircuitBreaker) Snapshot() Snapshot {
+	requests, errors := c.counts()
+	return Snapshot{
+		Name:          c.name,
+		Status:        c.Status(),
+		RequestVolume: requests,
+		ErrorVolume:   errors,
+
+		OpenConfig:  *c.openConfig.Load(),
+		CloseConfig: *c.closeConfig.Load(),
+		SleepWindow: c.SleepWindow(),
+	}
+}
+
+//Registry holds circuit breakers by name
+type Registry struct {
+	mu         sync.RWMutex
+	breakers   map[string]*CircuitBreaker
+	configured map[string]bool //names of circuit breakers created from config, see Config.Apply
+
+	integrations []Integration //see Route
+
+	versionsMu  sync.Mutex
+	versions    []ConfigVersion //see RollbackConfig
+	lastVersion int
+	historySize int //see SetConfigHistory, 0 for default
+}
+
+//NewRegistry return an empty registry
+func NewRegistry() *Registry {
+	return &Registry{
+		breakers:   make(map[string]*CircuitBreaker),
+		configured: make(map[string]bool),
+	}
+}
+
+//Register adds a named circuit breaker, see WithName
+func (r *Registry) Register(cb *CircuitBreaker) error {
+	if cb.name == "" {
+		return errEmptyName
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.breakers[cb.name]; ok {
+		return errDuplicateName
+	}
+	r.breakers[cb.name] = cb
+
+	return nil
+}
+
+//Get returns circuit breaker of name
+func (r *Registry) Get(name string) (*CircuitBreaker, bool) {
+	r.mu.RLock()
+	cb, ok := r.breakers[name]
+	r.mu.RUnlock()
+
+	return cb, ok
+}
+
+//Remove removes circuit breaker of name from registry, it's not closed
+func (r *Registry) Remove(name string) {
+	r.mu.Lock()
+	delete(r.breakers, name)
+	delete(r.configured, name)
+	r.mu.Unlock()
+}
+
+//Names returns sorted names of all circuit breakers
+func (r *Registry) Names() []string {
+	r.mu.RLock()
+	names := make([]string, 0, len(r.breakers))
+	for name := range r.breakers {
+		names = append(names, name)
+	}
+	r.mu.RUnlock()
+
+	sort.Strings(names)
+	return names
+}
+
+//Snapshot returns snapshots of all circuit breakers sorted by name
+func (r *Registry) Snapshot() []Snapshot {
+	names := r.Names()
+
+	snapshots := make([]Snapshot, 0, len(names))
+	for _, name := range names {
+		if cb, ok := r.Get(name); ok {
+			snapshots = append(snapshots, cb.Snapshot())
+		}
+	}
+
+	return snapshots
+}
+
+//Dump writes snapshot of registry to w, one line per circuit breaker
+func (r *Registry) Dump(w io.Writer) error {
+	for _, s := range r.Snapshot() {
+		_, err := fmt.Fprintf(w, "%s status=%s requests=%d errors=%d refresh_interval=%s error_threshold=%d%% request_volume_threshold=%d sleep_window=%s\n",
+			s.Name, StatusText(s.Status), s.RequestVolume, s.ErrorVolume,
+			s.OpenConfig.RefreshInterval, s.OpenConfig.ErrorThresholdPercent, s.OpenConfig.RequestVolumeThreshold, s.SleepWindow)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
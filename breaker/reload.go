@@ -0,0 +1,203 @@
+package breaker
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+const defaultReloadDelay = 100 * time.Millisecond
+
+type ReloadOption func(w *ConfigWatcher)
+
+//WithReloadListener calls f with every change made by a reload, e.g. to log or export it
+func WithReloadListener(f func(ConfigChange)) ReloadOption {
+	return func(w *ConfigWatcher) {
+		if f != nil {
+			w.listeners = append(w.listeners, f)
+		}
+	}
+}
+
+//WithReloadErrorHandler calls f with errors of reloads in Run, default log.Printf. the registry keeps its
+//previous config on errors
+func WithReloadErrorHandler(f func(error)) ReloadOption {
+	return func(w *ConfigWatcher) {
+		if f != nil {
+			w.onError = f
+		}
+	}
+}
+
+//WithReloadGate calls f with what a reload would change before applying it, an error of f rejects the reload
+//and is handled like other errors of reloads, e.g. to refuse removing circuit breakers
+func WithReloadGate(f func(Diff) error) ReloadOption {
+	return func(w *ConfigWatcher) {
+		w.gate = f
+	}
+}
+
+//WithReloadStrictness sets how reloads treat unknown keys and values out of range, default ConfigDefault
+func WithReloadStrictness(st ConfigStrictness) ReloadOption {
+	return func(w *ConfigWatcher) {
+		w.strictness = st
+	}
+}
+
+//WithReloadOptions sets options of circuit breakers created by reloads, like opts of LoadConfig
+func WithReloadOptions(opts ...CircuitBreakerOption) ReloadOption {
+	return func(w *ConfigWatcher) {
+		w.opts = opts
+	}
+}
+
+//ConfigWatcher reloads a config file into a registry when it changes, see Registry.UpdateConfig.
+//profile and environment variables are used as in LoadConfig
+type ConfigWatcher struct {
+	r    *Registry
+	path string
+	opts []CircuitBreakerOption
+
+	listeners []func(ConfigChange)
+	onError   func(error)
+	gate      func(Diff) error
+
+	strictness ConfigStrictness
+
+	mu   sync.Mutex
+	last []byte //content of the last reload
+}
+
+//WatchConfig return a watcher of config file of path for r, usually r is returned by LoadConfig of the same path.
+//for configs given to ReloadData path only names the config and chooses its format
+func WatchConfig(r *Registry, path string, opts ...ReloadOption) *ConfigWatcher {
+	w := &ConfigWatcher{
+		r:    r,
+		path: path,
+		onError: func(err error) {
+			log.Printf("reload circuit breaker config: %v", err)
+		},
+	}
+
+	for _, opt := range opts {
+		opt(w)
+	}
+
+	return w
+}
+
+//Reload reads config file and applies it to registry if it changed since the last reload
+func (w *ConfigWatcher) Reload() ([]ConfigChange, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	b, err := os.ReadFile(w.path)
+	if err != nil {
+		return nil, err
+	}
+
+	return w.reload(b)
+}
+
+//ReloadData applies config of content b to registry if it changed since the last reload, its format is chosen
+//by extension of path of the watcher. it's for configs not read from files, e.g. pushed by an API
+func (w *ConfigWatcher) ReloadData(b []byte) ([]ConfigChange, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.reload(b)
+}
+
+//Notify is ReloadData with errors handled like those of reloads in Run
+func (w *ConfigWatcher) Notify(b []byte) {
+	if _, err := w.ReloadData(b); err != nil {
+		w.onError(err)
+	}
+}
+
+func (w *ConfigWatcher) reload(b []byte) ([]ConfigChange, error) {
+	if w.last != nil && bytes.Equal(b, w.last) {
+		return nil, nil
+	}
+
+	cfg, err := w.strictness.parseConfigEnv(b, filepath.Ext(w.path))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", w.path, err)
+	}
+
+	if w.gate != nil {
+		d, err := w.r.PlanConfig(cfg)
+		if err != nil {
+			return nil, err
+		}
+		if err := w.gate(d); err != nil {
+			return nil, err
+		}
+	}
+
+	changes, err := w.r.UpdateConfig(cfg, w.opts...)
+	for _, ch := range changes {
+		for _, f := range w.listeners {
+			f(ch)
+		}
+	}
+	if err != nil {
+		return changes, err
+	}
+	w.last = b
+
+	return changes, nil
+}
+
+//Run reloads config file on every change until ctx is done. the directory of the file is watched, so files
+//replaced by rename, as editors and kubernetes do, are followed
+func (w *ConfigWatcher) Run(ctx context.Context) error {
+	fw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer fw.Close()
+
+	if err := fw.Add(filepath.Dir(w.path)); err != nil {
+		return err
+	}
+
+	if _, err := w.Reload(); err != nil {
+		w.onError(err)
+	}
+
+	//a change is often several events, reload once they settle
+	delay := time.NewTimer(defaultReloadDelay)
+	delay.Stop()
+	defer delay.Stop()
+
+	for {
+		select {
+		case ev, ok := <-fw.Events:
+			if !ok {
+				return nil
+			}
+			if ev.Op&(fsnotify.Write|fsnotify.Create|fsnotify.Rename|fsnotify.Remove) != 0 {
+				delay.Reset(defaultReloadDelay)
+			}
+		case err, ok := <-fw.Errors:
+			if !ok {
+				return nil
+			}
+			w.onError(err)
+		case <-delay.C:
+			if _, err := w.Reload(); err != nil && !os.IsNotExist(err) {
+				w.onError(err)
+			}
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package breaker
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+//windowBuckets is the number of buckets a refresh interval is split into
+const windowBuckets = 10
+
+const (
+	epochBits      = 24                    //bits of the epoch tag of a bucket
+	epochMask      = 1<<epochBits - 1      //epochs wrap at it, compared modulo it
+	maxBucketCount = 1<<(64-epochBits) - 1 //counts of a bucket saturate at it
+)
+
+//ring counts requests and errors of the last refresh interval in windowBuckets buckets of a tenth of it each,
+//indexed by time. a bucket packs its epoch, the number of bucket widths since the unix epoch modulo epochMask+1,
+//into the low 24 bits of one word and its count into the high 40, so the add finding a stale bucket resets it by
+//the same CAS and the window rolls without locks or a goroutine. 40 bits hold a trillion requests per bucket, a
+//bucket saturates there instead of wrapping, and counts of the window add up in 64 bits
+type ring struct {
+	interval int64 //refresh interval the buckets are of, a new one clears them
+	_        cacheLinePad
+	requests [windowBuckets]uint64
+	_        cacheLinePad
+	errors   [windowBuckets]uint64
+	_        cacheLinePad
+}
+
+//cacheLineSize is the cache line size of common CPUs, 64 bytes on amd64 and most arm64
+const cacheLineSize = 64
+
+//cacheLinePad keeps fields before and after it on different cache lines, against false sharing
+type cacheLinePad [cacheLineSize]byte
+
+//bucket returns epoch tag and index of the bucket of now
+func (r *ring) bucket(now time.Time, interval time.Duration) (uint64, int) {
+	if cur := atomic.LoadInt64(&r.interval); cur != int64(interval) &&
+		atomic.CompareAndSwapInt64(&r.interval, cur, int64(interval)) {
+		r.reset()
+	}
+
+	width := int64(interval) / windowBuckets
+	if width <= 0 {
+		width = 1
+	}
+	epoch := now.UnixNano() / width
+
+	return uint64(epoch) & epochMask, int(epoch % windowBuckets)
+}
+
+func (r *ring) addRequests(now time.Time, interval time.Duration, n uint32) {
+	epoch, i := r.bucket(now, interval)
+	addBucket(&r.requests[i], epoch, uint64(n))
+}
+
+func (r *ring) addErrors(now time.Time, interval time.Duration, n uint32) {
+	epoch, i := r.bucket(now, interval)
+	addBucket(&r.errors[i], epoch, uint64(n))
+}
+
+//counts returns requests and errors of the refresh interval until now
+func (r *ring) counts(now time.Time, interval time.Duration) (uint64, uint64) {
+	epoch, _ := r.bucket(now, interval)
+
+	return sumBuckets(&r.requests, epoch), sumBuckets(&r.errors, epoch)
+}
+
+//rate returns requests a second of the bucket before the bucket of now, 0 if it is stale
+func (r *ring) rate(now time.Time, interval time.Duration) float64 {
+	epoch, i := r.bucket(now, interval)
+	b := atomic.LoadUint64(&r.requests[(i+windowBuckets-1)%windowBuckets])
+	if b&epochMask != (epoch-1)&epochMask {
+		return 0
+	}
+
+	width := interval / windowBuckets
+	if width <= 0 {
+		width = 1
+	}
+	return float64(b>>epochBits) / width.Seconds()
+}
+
+//restore replaces counts by requests and errors in the bucket of now
+func (r *ring) restore(now time.Time, interval time.Duration, requests, errors uint64) {
+	epoch, i := r.bucket(now, interval)
+	r.reset()
+	atomic.StoreUint64(&r.requests[i], packBucket(epoch, requests))
+	atomic.StoreUint64(&r.errors[i], packBucket(epoch, errors))
+}
+
+func (r *ring) reset() {
+	for i := range r.requests {
+		atomic.StoreUint64(&r.requests[i], 0)
+		atomic.StoreUint64(&r.errors[i], 0)
+	}
+}
+
+//packBucket returns the word of a bucket of epoch tag epoch with count, saturated
+func packBucket(epoch, count uint64) uint64 {
+	if count > maxBucketCount {
+		count = maxBucketCount
+	}
+
+	return count<<epochBits | epoch
+}
+
+//addBucket adds n to bucket b of epoch, a bucket of an older epoch starts over from n
+func addBucket(b *uint64, epoch uint64, n uint64) {
+	for {
+		old := atomic.LoadUint64(b)
+		count := n
+		if old&epochMask == epoch {
+			count += old >> epochBits
+		}
+		if atomic.CompareAndSwapUint64(b, old, packBucket(epoch, count)) {
+			return
+		}
+	}
+}
+
+//sumBuckets returns counts of buckets within windowBuckets epochs until epoch, the difference of tags taken
+//modulo epochMask+1 so the window rolls over the wrap of tags. saturated buckets can't overflow the sum
+func sumBuckets(buckets *[windowBuckets]uint64, epoch uint64) uint64 {
+	var total uint64
+	for i := range buckets {
+		b := atomic.LoadUint64(&buckets[i])
+		if (epoch-b&epochMask)&epochMask < windowBuckets {
+			total += b >> epochBits
+		}
+	}
+
+	return total
+}
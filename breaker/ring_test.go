@@ -0,0 +1,114 @@
+package breaker
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRingRollover(t *testing.T) {
+	const interval = 10 * time.Second
+	start := time.Unix(1700000000, 0)
+	width := interval / windowBuckets
+
+	for _, tc := range []struct {
+		name string
+		adds []time.Duration //offsets from start of one request each
+		at   time.Duration
+		want uint64
+	}{
+		{"same bucket", []time.Duration{0, 0, 0}, 0, 3},
+		{"buckets of an interval", []time.Duration{0, width, 5 * width, 9 * width}, 9 * width, 4},
+		{"oldest bucket rolls out", []time.Duration{0, width}, interval, 1},
+		{"whole interval rolls out", []time.Duration{0, width}, interval + width, 0},
+		{"stale bucket starts over", []time.Duration{0, 0, interval}, interval, 1},
+		{"idle for laps", []time.Duration{0}, 7*interval + width/2, 0},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var r ring
+			for _, d := range tc.adds {
+				r.addRequests(start.Add(d), interval, 1)
+			}
+			if requests, errors := r.counts(start.Add(tc.at), interval); requests != tc.want || errors != 0 {
+				t.Errorf("counts %d/%d, want %d/0", requests, errors, tc.want)
+			}
+		})
+	}
+}
+
+func TestRingIntervalChange(t *testing.T) {
+	var r ring
+	now := time.Now()
+	r.addRequests(now, time.Minute, 5)
+	if requests, _ := r.counts(now, time.Second); requests != 0 {
+		t.Errorf("requests %d after the refresh interval changed, want 0", requests)
+	}
+}
+
+func TestBucketSaturation(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		start uint64
+		add   uint64
+		want  uint64
+	}{
+		{"below", 1, 2, 3},
+		{"reaches max", maxBucketCount - 2, 2, maxBucketCount},
+		{"past max", maxBucketCount - 2, 10, maxBucketCount},
+		{"saturated", maxBucketCount, maxBucketCount, maxBucketCount},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			const epoch = 7
+			b := packBucket(epoch, tc.start)
+			addBucket(&b, epoch, tc.add)
+			if b&epochMask != epoch {
+				t.Fatalf("epoch tag %d, want %d", b&epochMask, epoch)
+			}
+			if n := b >> epochBits; n != tc.want {
+				t.Errorf("count %d, want %d", n, tc.want)
+			}
+		})
+	}
+}
+
+func TestSumBucketsEpochWrap(t *testing.T) {
+	var buckets [windowBuckets]uint64
+	//tags epochMask-1 and epochMask are the two epochs before 0 once tags wrap
+	buckets[0] = packBucket(epochMask-1, 1)
+	buckets[1] = packBucket(epochMask, 10)
+	buckets[2] = packBucket(0, 100)
+	buckets[3] = packBucket(epochMask-windowBuckets, 1000) //a lap before, out of the window
+
+	if n := sumBuckets(&buckets, 0); n != 111 {
+		t.Errorf("sum %d over the wrap of tags, want 111", n)
+	}
+	if n := sumBuckets(&buckets, windowBuckets-2); n != 110 {
+		t.Errorf("sum %d once the oldest tag rolled out, want 110", n)
+	}
+}
+
+func TestRingRace(t *testing.T) {
+	const (
+		goroutines = 8
+		adds       = 1000
+	)
+
+	var r ring
+	now := time.Now()
+	var wg sync.WaitGroup
+	for i := 0; i < goroutines; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < adds; j++ {
+				r.addRequests(now, time.Minute, 1)
+				r.addErrors(now, time.Minute, 1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if requests, errors := r.counts(now, time.Minute); requests != goroutines*adds || errors != goroutines*adds {
+		t.Errorf("counts %d/%d, want %d each", requests, errors, goroutines*adds)
+	}
+}
@@ -0,0 +1,259 @@
+package breaker
+
+import (
+	"context"
+	"errors"
+	"hash/fnv"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const defaultRolloutInterval = 5 * time.Minute
+
+var (
+	defaultRolloutSteps = []int{5, 25, 50, 100}
+
+	errRolloutActive  = errors.New("circuit breaker group has a rollout in progress")
+	errRolloutCompact = errors.New("compact circuit breakers share settings, a rollout can't change them by key")
+)
+
+type RolloutOption func(r *Rollout)
+
+//WithRolloutSteps sets percentages of keys a rollout expands to, in order, default 5, 25, 50 and 100.
+//a last step below 100 is followed by 100
+func WithRolloutSteps(percents ...int) RolloutOption {
+	return func(r *Rollout) {
+		steps := make([]int, 0, len(percents)+1)
+		for _, p := range percents {
+			if p > 0 && p <= 100 && (len(steps) == 0 || p > steps[len(steps)-1]) {
+				steps = append(steps, p)
+			}
+		}
+		if len(steps) == 0 || steps[len(steps)-1] != 100 {
+			steps = append(steps, 100)
+		}
+		r.steps = steps
+	}
+}
+
+//WithRolloutInterval sets how long each step of Run stabilizes before the next one, default 5m
+func WithRolloutInterval(d time.Duration) RolloutOption {
+	return func(r *Rollout) {
+		if d > 0 {
+			r.interval = d
+		}
+	}
+}
+
+//WithRolloutSeed sets seed of the hash choosing keys, default empty. instances with the same seed choose the
+//same keys, another seed chooses another first share of keys
+func WithRolloutSeed(seed string) RolloutOption {
+	return func(r *Rollout) {
+		r.seed = seed
+	}
+}
+
+//Rollout applies settings to a growing share of the keys of a Group, see Group.StartRollout.
+//a key is included when the hash of the seed and the key, mod 100, is below the current percentage,
+//so every instance includes the same keys and a key once included stays so
+type Rollout struct {
+	g        *Group
+	s        Settings
+	seed     string
+	steps    []int
+	interval time.Duration
+
+	step    int32 //index of current step in steps
+	percent int32
+	done    chan struct{}
+
+	mu      sync.Mutex
+	prev    map[string]Settings //settings before the rollout of keys it applied to
+	stopped bool
+}
+
+//StartRollout applies fields of s that are not zero to the keys of the first step of the rollout, existing and
+//created later. call Run, or Advance, to expand it. once it reaches 100% circuit breakers created later by Get
+//take s too. only one rollout may be in progress in a group, none in a group of compact circuit breakers
+func (g *Group) StartRollout(s Settings, opts ...RolloutOption) (*Rollout, error) {
+	s.Name = ""
+	if err := s.Validate(); err != nil {
+		return nil, err
+	}
+
+	r := &Rollout{
+		g:        g,
+		s:        s,
+		steps:    defaultRolloutSteps,
+		interval: defaultRolloutInterval,
+		done:     make(chan struct{}),
+		prev:     make(map[string]Settings),
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	g.mu.Lock()
+	if g.rollout != nil {
+		g.mu.Unlock()
+		return nil, errRolloutActive
+	}
+	if len(g.compact) > 0 {
+		g.mu.Unlock()
+		return nil, errRolloutCompact
+	}
+	g.rollout = r
+	g.mu.Unlock()
+
+	r.expand(0)
+	return r, nil
+}
+
+//Percent returns current percentage of keys the rollout applies to
+func (r *Rollout) Percent() int {
+	return int(atomic.LoadInt32(&r.percent))
+}
+
+//Includes reports whether the rollout applies to key at current percentage
+func (r *Rollout) Includes(key string) bool {
+	return r.bucket(key) < r.Percent()
+}
+
+//Done is closed when the rollout reached 100% or was aborted
+func (r *Rollout) Done() <-chan struct{} {
+	return r.done
+}
+
+//Advance expands the rollout to its next step and returns the new percentage
+func (r *Rollout) Advance() int {
+	next := int(atomic.LoadInt32(&r.step)) + 1
+	if next >= len(r.steps) {
+		return r.Percent()
+	}
+
+	r.expand(next)
+	return r.Percent()
+}
+
+//Run advances the rollout every interval until it reaches 100%, is aborted, or ctx is done
+func (r *Rollout) Run(ctx context.Context) error {
+	t := time.NewTicker(r.interval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-t.C:
+			r.Advance()
+		case <-r.done:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+//Abort stops the rollout and restores settings of the keys it applied to, a complete rollout is kept
+func (r *Rollout) Abort() {
+	select {
+	case <-r.done:
+		return
+	default:
+	}
+
+	r.mu.Lock()
+	if r.stopped {
+		r.mu.Unlock()
+		return
+	}
+	r.stopped = true
+	prev := r.prev
+	r.prev = nil
+	r.mu.Unlock()
+
+	atomic.StoreInt32(&r.percent, 0)
+	r.finish(false)
+
+	for key, s := range prev {
+		if cb, ok := r.g.lookup(key); ok {
+			cb.UpdateConfig(s)
+		}
+	}
+}
+
+func (r *Rollout) expand(step int) {
+	r.mu.Lock()
+	if r.stopped {
+		r.mu.Unlock()
+		return
+	}
+	atomic.StoreInt32(&r.step, int32(step))
+	atomic.StoreInt32(&r.percent, int32(r.steps[step]))
+	r.mu.Unlock()
+
+	for _, key := range r.g.Keys() {
+		if cb, ok := r.g.lookup(key); ok && r.Includes(key) {
+			r.apply(key, cb)
+		}
+	}
+
+	if r.steps[step] == 100 {
+		r.finish(true)
+	}
+}
+
+//apply applies settings of rollout to cb of key if it's not yet
+func (r *Rollout) apply(key string, cb *CircuitBreaker) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.stopped {
+		return
+	}
+	if _, ok := r.prev[key]; ok {
+		return
+	}
+
+	cur := cb.Settings()
+	r.prev[key] = cur
+	cb.UpdateConfig(cur.Override(r.s))
+}
+
+//finish ends the rollout in its group, complete rollouts apply to circuit breakers created later
+func (r *Rollout) finish(complete bool) {
+	r.g.mu.Lock()
+	defer r.g.mu.Unlock()
+
+	if r.g.rollout != r {
+		return
+	}
+	r.g.rollout = nil
+	if complete {
+		r.g.rolled = append(r.g.rolled, r.s.apply)
+	}
+	close(r.done)
+}
+
+//bucket returns the bucket of key in [0, 100)
+func (r *Rollout) bucket(key string) int {
+	h := fnv.New32a()
+	h.Write([]byte(r.seed))
+	h.Write([]byte{0})
+	h.Write([]byte(key))
+
+	return int(h.Sum32() % 100)
+}
+
+//Keys returns sorted keys of circuit breakers the rollout applied to
+func (r *Rollout) Keys() []string {
+	r.mu.Lock()
+	keys := make([]string, 0, len(r.prev))
+	for key := range r.prev {
+		keys = append(keys, key)
+	}
+	r.mu.Unlock()
+
+	sort.Strings(keys)
+	return keys
+}
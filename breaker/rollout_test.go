@@ -0,0 +1,39 @@
+package breaker
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRolloutOverridesPatterns(t *testing.T) {
+	g := NewGroup(WithLazyExpiry(), WithSleepWindow(time.Minute))
+	defer g.Close()
+	if err := g.Match("api-*", WithSleepWindow(2*time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	existing := g.Get("api-old")
+
+	r, err := g.StartRollout(Settings{SleepWindow: 3 * time.Minute}, WithRolloutSteps(100))
+	if err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-r.Done():
+	default:
+		t.Fatal("rollout to 100% is not done")
+	}
+
+	for _, tc := range []struct {
+		key  string
+		cb   *CircuitBreaker
+		want time.Duration
+	}{
+		{"api-old", existing, 3 * time.Minute},
+		{"api-new", g.Get("api-new"), 3 * time.Minute},
+		{"web", g.Get("web"), 3 * time.Minute},
+	} {
+		if d := tc.cb.SleepWindow(); d != tc.want {
+			t.Errorf("%s: sleep window %s, want %s", tc.key, d, tc.want)
+		}
+	}
+}
@@ -0,0 +1,34 @@
+package breaker
+
+import (
+	"math"
+	"math/rand"
+	"time"
+)
+
+//WithReportSampling counts only 1 in n requests and errors, n times each, once circuit breaker passes more than
+//qps requests a second, so ultra-hot paths contend on the window rarely. counts stay accurate on average, the
+//error rate of a refresh interval of tens of thousands of requests hardly moves. only the window of a closed or
+//half-open circuit breaker is sampled, errors in half-open, metrics and WindowCounter see every report
+func WithReportSampling(n, qps uint32) CircuitBreakerOption {
+	return func(c *CircuitBreaker) {
+		if n > 1 && qps > 0 {
+			c.sampleN, c.sampleQPS = n, qps
+		}
+	}
+}
+
+//sample returns how many of n reports to count in the window, 0 if they are skipped
+func (c *CircuitBreaker) sample(now time.Time, interval time.Duration, n uint32) uint32 {
+	if c.sampleN == 0 || c.window.rate(now, interval) <= float64(c.sampleQPS) {
+		return n
+	}
+	if rand.Uint32()%c.sampleN != 0 {
+		return 0
+	}
+
+	if scaled := uint64(n) * uint64(c.sampleN); scaled < math.MaxUint32 {
+		return uint32(scaled)
+	}
+	return math.MaxUint32
+}
@@ -0,0 +1,23 @@
+package breaker
+
+//RunIfClosed returns a func for schedulers such as cron, which runs fn only when none of deps is open.
+//a skipped run is counted as MetricSkipped and recorded as EventSkip with key name on every open breaker,
+//so a nightly job does not hammer a dependency known to be down
+func RunIfClosed(name string, fn func(), deps ...*CircuitBreaker) func() {
+	return func() {
+		var skip bool
+		for _, c := range deps {
+			if c.Status() != CircuitBreakerStatusOpen {
+				continue
+			}
+
+			skip = true
+			c.metrics.IncrCounter(MetricSkipped, 1)
+			c.recordEvent(Event{Type: EventSkip, Status: CircuitBreakerStatusOpen, Meta: RequestMeta{Key: name}})
+		}
+
+		if !skip {
+			fn()
+		}
+	}
+}
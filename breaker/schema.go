@@ -0,0 +1,96 @@
+package breaker
+
+import (
+	"encoding/json"
+	"math"
+)
+
+//ConfigSchemaID is the $id of the schema returned by ConfigSchema
+const ConfigSchemaID = "https://github.com/carl-leopard/circuitbreaker/breaker/config.schema.json"
+
+type schema map[string]interface{}
+
+//ConfigSchema returns a JSON Schema (draft 2020-12) of config files read by ReadConfig, for editors and CI to
+//validate them before deploy. the schema rejects unknown keys, which ReadConfig ignores
+func ConfigSchema() []byte {
+	duration := schema{
+		"description": `duration like "250ms" or "3m", or nanoseconds`,
+		"oneOf": []schema{
+			{"type": "string", "pattern": `^([0-9]+(\.[0-9]+)?(ns|us|µs|ms|s|m|h))+$`},
+			{"type": "integer", "minimum": 0},
+		},
+	}
+	volume := schema{"type": "integer", "minimum": 0, "maximum": uint64(math.MaxUint32)}
+
+	settings := schema{
+		"type":                 "object",
+		"additionalProperties": false,
+		"properties": schema{
+			"name": schema{"type": "string", "minLength": 1},
+			"labels": schema{
+				"type":                 "object",
+				"additionalProperties": schema{"type": "string"},
+				"propertyNames":        schema{"minLength": 1},
+			},
+			"refresh_interval": duration,
+			"error_threshold_percent": schema{
+				"description": `0 for default or 5-100, a number or a string like "20%"`,
+				"oneOf": []schema{
+					{"type": "integer", "anyOf": []schema{{"const": 0}, {"minimum": minErrorThresholdPercent, "maximum": maxErrorThresholdPercent}}},
+					{"type": "string", "pattern": `^(0|[5-9]|[1-9][0-9]|100)%?$`},
+				},
+			},
+			"request_volume_threshold": volume,
+			"recovery_interval":        duration,
+			"success_volume_threshold": volume,
+			"sleep_window":             duration,
+			"mode":                     schema{"enum": []string{"normal", "force-open", "force-closed", "shadow"}},
+			"decision":                 schema{"enum": []string{"hybrid", "local", "global"}},
+			"extends":                  schema{"type": "string", "minLength": 1, "description": "name of a template"},
+		},
+	}
+	named := schema{"$ref": "#/$defs/settings", "required": []string{"name"}}
+
+	doc := schema{
+		"$schema":              "https://json-schema.org/draft/2020-12/schema",
+		"$id":                  ConfigSchemaID,
+		"title":                "circuit breaker config",
+		"type":                 "object",
+		"additionalProperties": false,
+		"properties": schema{
+			"defaults":  schema{"$ref": "#/$defs/settings"},
+			"breakers":  schema{"type": "array", "items": named},
+			"templates": schema{"type": "object", "additionalProperties": schema{"$ref": "#/$defs/settings"}},
+			"integrations": schema{
+				"type": "array",
+				"items": schema{
+					"type":                 "object",
+					"additionalProperties": false,
+					"properties": schema{
+						"http":    schema{"type": "string", "minLength": 1, "description": "host pattern in path.Match syntax"},
+						"grpc":    schema{"type": "string", "minLength": 1, "description": "full method pattern in path.Match syntax"},
+						"breaker": schema{"type": "string", "minLength": 1},
+					},
+					"required": []string{"breaker"},
+					"oneOf":    []schema{{"required": []string{"http"}}, {"required": []string{"grpc"}}},
+				},
+			},
+			"profiles": schema{
+				"type": "object",
+				"additionalProperties": schema{
+					"type":                 "object",
+					"additionalProperties": false,
+					"properties": schema{
+						"defaults": schema{"$ref": "#/$defs/settings"},
+						"breakers": schema{"type": "array", "items": named},
+					},
+				},
+			},
+		},
+		"$defs": schema{"settings": settings},
+	}
+
+	//the schema is made of maps, slices and numbers only, it always encodes
+	b, _ := json.MarshalIndent(doc, "", "  ")
+	return b
+}
@@ -0,0 +1,89 @@
+package breaker
+
+import (
+	"context"
+	"math/rand"
+	"strconv"
+	"sync"
+	"time"
+)
+
+//ShardedCounter spreads window counts of a breaker over shards keys of ws, so a hot breaker doesn't turn one key
+//of the store into a bottleneck. counts are added to a random shard and the totals are summed over all of them,
+//the shards are read concurrently. shard keys are named "<name>#<shard>", e.g. in breakerredis every shard gets
+//a hash tag of its own and so spreads over cluster nodes
+type ShardedCounter struct {
+	ws     WindowStore
+	shards int
+}
+
+var _ WindowStore = (*ShardedCounter)(nil)
+
+//NewShardedCounter return a counter of shards keys per window in ws
+func NewShardedCounter(ws WindowStore, shards int) *ShardedCounter {
+	if shards < 1 {
+		shards = 1
+	}
+
+	return &ShardedCounter{ws: ws, shards: shards}
+}
+
+func shardName(name string, shard int) string {
+	return name + "#" + strconv.Itoa(shard)
+}
+
+//IncrWindow implements WindowCounter. once counts are added, shards failing to read are left out of the totals
+//rather than failing, which would add the counts again
+func (c *ShardedCounter) IncrWindow(ctx context.Context, name string, start time.Time, requests, errors uint32) (Window, error) {
+	shard := rand.Intn(c.shards)
+	w, err := c.ws.IncrWindow(ctx, shardName(name, shard), start, requests, errors)
+	if err != nil || c.shards == 1 {
+		return w, err
+	}
+
+	w, _ = c.sum(ctx, name, start, shard, w)
+	return w, nil
+}
+
+//ReadWindow implements WindowReader
+func (c *ShardedCounter) ReadWindow(ctx context.Context, name string, start time.Time) (Window, error) {
+	return c.sum(ctx, name, start, -1, Window{Start: start})
+}
+
+//sum adds windows of all shards but skip to w
+func (c *ShardedCounter) sum(ctx context.Context, name string, start time.Time, skip int, w Window) (Window, error) {
+	var (
+		mu       sync.Mutex
+		wg       sync.WaitGroup
+		firstErr error
+	)
+	for shard := 0; shard < c.shards; shard++ {
+		if shard == skip {
+			continue
+		}
+
+		wg.Add(1)
+		go func(shard int) {
+			defer wg.Done()
+
+			sw, err := c.ws.ReadWindow(ctx, shardName(name, shard), start)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				if firstErr == nil {
+					firstErr = err
+				}
+				return
+			}
+			w.Requests = SaturatedCount(uint64(w.Requests) + uint64(sw.Requests))
+			w.Errors = SaturatedCount(uint64(w.Errors) + uint64(sw.Errors))
+			if sw.OpenFor > w.OpenFor {
+				w.OpenFor = sw.OpenFor
+			}
+		}(shard)
+	}
+	wg.Wait()
+
+	return w, firstErr
+}
@@ -0,0 +1,29 @@
+package breaker
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+)
+
+//fullWindows is a WindowStore whose every window holds counts near math.MaxUint32
+type fullWindows struct{}
+
+func (fullWindows) IncrWindow(ctx context.Context, name string, start time.Time, requests, errors uint32) (Window, error) {
+	return fullWindows{}.ReadWindow(ctx, name, start)
+}
+
+func (fullWindows) ReadWindow(_ context.Context, _ string, start time.Time) (Window, error) {
+	return Window{Start: start, Requests: math.MaxUint32 - 1, Errors: math.MaxUint32 / 2}, nil
+}
+
+func TestShardedCounterSaturates(t *testing.T) {
+	w, err := NewShardedCounter(fullWindows{}, 4).IncrWindow(context.Background(), "a", time.Now(), 1, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w.Requests != math.MaxUint32 || w.Errors != math.MaxUint32 {
+		t.Errorf("sum of shards %d/%d, want both saturated at %d", w.Requests, w.Errors, uint32(math.MaxUint32))
+	}
+}
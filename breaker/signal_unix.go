@@ -0,0 +1,63 @@
+//go:build unix
+
+package breaker
+
+import (
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+//DumpOnSignal dumps r to w every time the process receives SIGUSR1, until stop is called
+func DumpOnSignal(r *Registry, w io.Writer) (stop func()) {
+	ch := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	signal.Notify(ch, syscall.SIGUSR1)
+
+	go func() {
+		for {
+			select {
+			case <-ch:
+				r.Dump(w)
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		signal.Stop(ch)
+		close(done)
+	}
+}
+
+//ReloadOnSignal runs loaders in order every time the process receives SIGHUP, until stop is called, e.g.
+//Reload of a ConfigWatcher, or a func reading config from elsewhere into Registry.UpdateConfig. errors are
+//logged and don't stop the loaders after, the registry keeps its config
+func ReloadOnSignal(loaders ...func() ([]ConfigChange, error)) (stop func()) {
+	ch := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	signal.Notify(ch, syscall.SIGHUP)
+
+	go func() {
+		for {
+			select {
+			case <-ch:
+				for _, load := range loaders {
+					if _, err := load(); err != nil {
+						log.Printf("reload circuit breaker config on SIGHUP: %v", err)
+					}
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		signal.Stop(ch)
+		close(done)
+	}
+}
@@ -0,0 +1,166 @@
+package breaker
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+const stateVersion = 1
+
+var errStateVersion = errors.New("unsupported circuit breaker state version")
+
+//State of a circuit breaker carried over to another process by ExportState and ImportState.
+//configuration is not part of it, the importing circuit breaker keeps its own
+type State struct {
+	Name          string    `json:"name"`
+	Status        int32     `json:"status"`
+	Mode          Mode      `json:"mode"`
+	ModeUntil     time.Time `json:"mode_until,omitempty"` //when mode reverts to normal, see SetModeFor
+	RequestVolume uint64    `json:"requests"`
+	ErrorVolume   uint64    `json:"errors"`
+	OpenUntil     time.Time `json:"open_until,omitempty"` //end of sleep window, only when Status is open
+}
+
+type stateFile struct {
+	Version  int     `json:"version"`
+	Breakers []State `json:"breakers"`
+}
+
+func (c *CircuitBreaker) state() State {
+	requests, errors := c.counts()
+	s := State{
+		Name:          c.name,
+		Status:        c.status(),
+		Mode:          Mode(atomic.LoadInt32(&c.mode)),
+		ModeUntil:     c.ModeUntil(),
+		RequestVolume: requests,
+		ErrorVolume:   errors,
+	}
+	if _, gen := c.loadState(); s.Status == CircuitBreakerStatusOpen {
+		if t, ok := c.tripOf(gen); ok {
+			s.OpenUntil = t.until
+		}
+	}
+
+	return s
+}
+
+//ExportState returns state of circuit breaker as JSON, e.g. to checkpoint it before a deploy
+func (c *CircuitBreaker) ExportState() ([]byte, error) {
+	return json.Marshal(stateFile{Version: stateVersion, Breakers: []State{c.state()}})
+}
+
+//ImportState restores state exported by ExportState of a circuit breaker of the same name.
+//an open state whose sleep window has not ended trips for the rest of it, otherwise the circuit breaker
+//turns half-open. counts of a closed state are restored into current refresh interval
+func (c *CircuitBreaker) ImportState(b []byte) error {
+	f, err := decodeState(b)
+	if err != nil {
+		return err
+	}
+
+	for _, s := range f.Breakers {
+		if s.Name == c.name {
+			return c.restore(s)
+		}
+	}
+
+	return fmt.Errorf("no state of circuit breaker %q", c.name)
+}
+
+func decodeState(b []byte) (stateFile, error) {
+	var f stateFile
+	if err := json.Unmarshal(b, &f); err != nil {
+		return f, err
+	}
+	if f.Version != stateVersion {
+		return f, errStateVersion
+	}
+
+	return f, nil
+}
+
+func (c *CircuitBreaker) restore(s State) error {
+	if c.exited() {
+		return ErrCircuitBreakerClosed
+	}
+
+	if s.ModeUntil.IsZero() {
+		c.SetMode(s.Mode)
+	} else if d := time.Until(s.ModeUntil); d > 0 {
+		c.SetModeFor(s.Mode, d)
+	} else {
+		c.SetMode(ModeNormal)
+	}
+
+	switch s.Status {
+	case CircuitBreakerStatusClosed:
+		if status, _ := c.loadState(); status == CircuitBreakerStatusClosed {
+			c.window.restore(time.Now(), c.openConfig.Load().RefreshInterval, s.RequestVolume, s.ErrorVolume)
+		}
+	case CircuitBreakerStatusOpen:
+		if d := time.Until(s.OpenUntil); d > 0 {
+			c.trip(CauseRestored, d)
+			return nil
+		}
+		c.halfOpen()
+	case CircuitBreakerStatusHalfOpen:
+		c.halfOpen()
+	default:
+		return errUnknownStatus
+	}
+
+	return nil
+}
+
+//halfOpen turns a closed circuit breaker to half-open, an open one turns half-open at the end of its sleep window
+func (c *CircuitBreaker) halfOpen() {
+	status, gen := c.loadState()
+	if status != CircuitBreakerStatusClosed || !c.transition(CircuitBreakerStatusClosed, gen, CircuitBreakerStatusHalfOpen) {
+		return
+	}
+
+	c.startProbe(gen + 1)
+	c.metrics.SetGauge(MetricStatus, float64(CircuitBreakerStatusHalfOpen))
+	c.recordEvent(Event{Type: EventHalfOpen, Status: CircuitBreakerStatusHalfOpen, Cause: CauseRestored})
+	c.notifyTransition(CircuitBreakerStatusClosed, CircuitBreakerStatusHalfOpen, CauseRestored)
+}
+
+//ExportState returns state of all circuit breakers in registry as JSON
+func (r *Registry) ExportState() ([]byte, error) {
+	names := r.Names()
+
+	f := stateFile{Version: stateVersion, Breakers: make([]State, 0, len(names))}
+	for _, name := range names {
+		if cb, ok := r.Get(name); ok {
+			f.Breakers = append(f.Breakers, cb.state())
+		}
+	}
+
+	return json.Marshal(f)
+}
+
+//ImportState restores state exported by ExportState of a registry or a circuit breaker into circuit breakers
+//of the same names, see CircuitBreaker.ImportState. states of names not registered are ignored
+func (r *Registry) ImportState(b []byte) error {
+	f, err := decodeState(b)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, s := range f.Breakers {
+		cb, ok := r.Get(s.Name)
+		if !ok {
+			continue
+		}
+		if err := cb.restore(s); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", s.Name, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,74 @@
+package breaker
+
+import (
+	"time"
+)
+
+//state word of a circuit breaker packs its generation into the high 32 bits and its status into the low 32.
+//every transition is one CAS of the whole word from a status in a generation to the next generation, so of
+//transitions racing from the same state exactly one happens, and the end of the sleep window of an older trip
+//can't reopen or end a later one
+
+func packState(status int32, gen uint32) uint64 {
+	return uint64(gen)<<32 | uint64(uint32(status))
+}
+
+func unpackState(s uint64) (int32, uint32) {
+	return int32(uint32(s) &^ stateExited), uint32(s >> 32)
+}
+
+//stateExited marks the state word of a circuit breaker closed by Close, which reports check with the same load
+//as status. no transition matches a word with it
+const stateExited = 1 << 31
+
+//exit marks circuit breaker closed by Close
+func (c *CircuitBreaker) exit() {
+	for {
+		s := c.stateWord.Load()
+		if c.stateWord.CompareAndSwap(s, s|stateExited) {
+			return
+		}
+	}
+}
+
+//exited reports whether circuit breaker is closed by Close
+func (c *CircuitBreaker) exited() bool {
+	return c.stateWord.Load()&stateExited != 0
+}
+
+//loadState returns status and generation of circuit breaker
+func (c *CircuitBreaker) loadState() (int32, uint32) {
+	return unpackState(c.stateWord.Load())
+}
+
+//transition turns circuit breaker from status from in generation gen to status to in the next generation, it
+//reports whether it did, false if another transition came first
+func (c *CircuitBreaker) transition(from int32, gen uint32, to int32) bool {
+	return c.stateWord.CompareAndSwap(packState(from, gen), packState(to, gen+1))
+}
+
+//tripInfo is what a trip stores once it won its transition
+type tripInfo struct {
+	gen         uint32 //generation the trip opened
+	until       time.Time
+	sleepWindow time.Duration
+}
+
+//tripOf returns the trip that opened generation gen, false if gen wasn't opened by a trip
+func (c *CircuitBreaker) tripOf(gen uint32) (*tripInfo, bool) {
+	t := c.lastTrip.Load()
+	if t == nil || t.gen != gen {
+		return nil, false
+	}
+
+	return t, true
+}
+
+//openUntil returns end of the sleep window of the latest trip, zero if circuit breaker never tripped
+func (c *CircuitBreaker) openUntil() time.Time {
+	if t := c.lastTrip.Load(); t != nil {
+		return t.until
+	}
+
+	return time.Time{}
+}
@@ -0,0 +1,137 @@
+package breaker
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+//raceTrips runs trip from goroutines at once and returns how many of them reported a trip and how many
+//transitions to open listeners saw
+func raceTrips(t *testing.T, trip func(c *CircuitBreaker) bool, opts ...CircuitBreakerOption) (int32, int32) {
+	t.Helper()
+
+	var opens int32
+	opts = append(opts, WithLazyExpiry(), WithTransitionListener(func(tr Transition) {
+		if tr.To == CircuitBreakerStatusOpen {
+			atomic.AddInt32(&opens, 1)
+		}
+	}))
+	c := New(opts...)
+	defer c.Close()
+
+	var (
+		wg      sync.WaitGroup
+		start   = make(chan struct{})
+		tripped int32
+	)
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			if trip(c) {
+				atomic.AddInt32(&tripped, 1)
+			}
+		}()
+	}
+	close(start)
+	wg.Wait()
+
+	if status, gen := c.loadState(); status != CircuitBreakerStatusOpen || gen != 1 {
+		t.Errorf("status %s in generation %d, want open in 1", StatusText(status), gen)
+	}
+	return tripped, atomic.LoadInt32(&opens)
+}
+
+func TestTripExactlyOnce(t *testing.T) {
+	for _, tc := range []struct {
+		name        string
+		opts        []CircuitBreakerOption
+		trip        func(c *CircuitBreaker) bool
+		wantTripped int32
+	}{
+		{"TripFor", nil, func(c *CircuitBreaker) bool { return c.TripFor(time.Hour) }, 1},
+		{"TripFromPeer", nil, func(c *CircuitBreaker) bool { return c.TripFromPeer(time.Hour) }, 1},
+		{
+			"errors",
+			[]CircuitBreakerOption{WithOpenConfig(CircuitBreakerOpenConfig{RefreshInterval: time.Minute, ErrorThresholdPercent: 50, RequestVolumeThreshold: 1})},
+			func(c *CircuitBreaker) bool {
+				c.ReportRequest()
+				c.ReportError()
+				return false
+			},
+			0,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			tripped, opens := raceTrips(t, tc.trip, tc.opts...)
+			if tripped != tc.wantTripped {
+				t.Errorf("%d callers tripped it, want %d", tripped, tc.wantTripped)
+			}
+			if opens != 1 {
+				t.Errorf("%d transitions to open, want 1", opens)
+			}
+		})
+	}
+}
+
+func TestLazyExpiry(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		ttl  time.Duration
+		wait time.Duration
+		call func(c *CircuitBreaker)
+		want int32
+	}{
+		{"within sleep window", time.Hour, 0, func(c *CircuitBreaker) { c.Status() }, CircuitBreakerStatusOpen},
+		{"Status after it", time.Millisecond, 2 * time.Millisecond, func(c *CircuitBreaker) { c.Status() }, CircuitBreakerStatusHalfOpen},
+		{"ReportRequest after it", time.Millisecond, 2 * time.Millisecond, func(c *CircuitBreaker) { c.ReportRequest() }, CircuitBreakerStatusHalfOpen},
+		{"ReportError after it", time.Millisecond, 2 * time.Millisecond, func(c *CircuitBreaker) { c.ReportError() }, CircuitBreakerStatusOpen},
+		{"no call after it", time.Millisecond, 2 * time.Millisecond, func(c *CircuitBreaker) {}, CircuitBreakerStatusOpen},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			c := New(WithLazyExpiry(), WithCloseConfig(CircuitBreakerCloseConfig{SuccessVolumeThreshold: 100}))
+			defer c.Close()
+
+			c.TripFor(tc.ttl)
+			time.Sleep(tc.wait)
+			tc.call(c)
+			//the raw state word, Status itself would expire it
+			if s, _ := c.loadState(); s != tc.want {
+				t.Errorf("status %s, want %s", StatusText(s), StatusText(tc.want))
+			}
+		})
+	}
+}
+
+func TestLazyExpiryRace(t *testing.T) {
+	var halfOpens int32
+	c := New(WithLazyExpiry(), WithTransitionListener(func(tr Transition) {
+		if tr.To == CircuitBreakerStatusHalfOpen {
+			atomic.AddInt32(&halfOpens, 1)
+		}
+	}))
+	defer c.Close()
+
+	c.TripFor(time.Millisecond)
+	time.Sleep(2 * time.Millisecond)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c.Status()
+		}()
+	}
+	wg.Wait()
+
+	if n := atomic.LoadInt32(&halfOpens); n != 1 {
+		t.Errorf("%d transitions to half-open, want 1", n)
+	}
+	if status, gen := c.loadState(); status != CircuitBreakerStatusHalfOpen || gen != 2 {
+		t.Errorf("status %s in generation %d, want half-open in 2", StatusText(status), gen)
+	}
+}
@@ -0,0 +1,36 @@
+package breaker
+
+import (
+	"context"
+	"time"
+)
+
+//WindowReader reads shared window counts without adding to them, e.g. for dashboards
+type WindowReader interface {
+	//ReadWindow returns the totals of the window of name starting at start, zero if there is none
+	ReadWindow(ctx context.Context, name string, start time.Time) (Window, error)
+}
+
+//WindowStore shares window counts and reads them back
+type WindowStore interface {
+	WindowCounter
+	WindowReader
+}
+
+//Store is what a distributed backend implements to share counts and trips between instances,
+//first party ones are in breakerredis, breakeretcd and breakerconsul. implement it to plug in other storage
+type Store interface {
+	WindowCounter
+	WindowReader
+	TransitionPublisher
+	TransitionWatcher
+}
+
+//WithStore shares counts with s every interval and publishes trips to it, it's WithWindowCounter together with
+//WithTransitionPublisher. follow trips of peers by FollowPeers with s
+func WithStore(s Store, interval time.Duration) CircuitBreakerOption {
+	return func(c *CircuitBreaker) {
+		WithWindowCounter(s, interval)(c)
+		WithTransitionPublisher(s)(c)
+	}
+}
@@ -0,0 +1,286 @@
+package breaker
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+//ConfigStrictness chooses how a loader treats config it doesn't understand, see ConfigStrictness.LoadConfig
+type ConfigStrictness int
+
+const (
+	ConfigDefault ConfigStrictness = iota //unknown keys are ignored, values out of range fail
+	ConfigStrict                          //unknown keys and values out of range fail
+	ConfigLenient                         //unknown keys are ignored, values out of range are clamped and logged
+)
+
+func (st ConfigStrictness) String() string {
+	switch st {
+	case ConfigDefault:
+		return "default"
+	case ConfigStrict:
+		return "strict"
+	case ConfigLenient:
+		return "lenient"
+	default:
+		return "unknown"
+	}
+}
+
+//ParseConfig works like package ParseConfig with unknown keys and values out of range treated as of st
+func (st ConfigStrictness) ParseConfig(b []byte, ext string) (*Config, error) {
+	if st == ConfigStrict {
+		if err := CheckConfigKeys(b, ext); err != nil {
+			return nil, err
+		}
+	}
+
+	cfg, err := ParseConfig(b, ext)
+	if err != nil {
+		return nil, err
+	}
+	if st == ConfigLenient {
+		cfg.clampLogged()
+	}
+
+	return cfg, nil
+}
+
+//ReadConfig works like package ReadConfig with unknown keys and values out of range treated as of st
+func (st ConfigStrictness) ReadConfig(path string) (*Config, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg, err := st.ParseConfig(b, filepath.Ext(path))
+	return cfg, pathError(path, err)
+}
+
+//LoadConfig works like package LoadConfig with unknown keys and values out of range treated as of st, values
+//of environment variables too in lenient mode
+func (st ConfigStrictness) LoadConfig(path string, opts ...CircuitBreakerOption) (*Registry, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := st.parseConfigEnv(b, filepath.Ext(path))
+	if err != nil {
+		return nil, pathError(path, err)
+	}
+
+	r := NewRegistry()
+	if err := cfg.Apply(r, opts...); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+//parseConfigEnv parses config of b for the profile of ProfileEnv, overridden by environment variables
+func (st ConfigStrictness) parseConfigEnv(b []byte, ext string) (*Config, error) {
+	cfg, err := st.ParseConfig(b, ext)
+	if err != nil {
+		return nil, err
+	}
+	if cfg, err = cfg.resolveEnv(); err != nil {
+		return nil, err
+	}
+	if st == ConfigLenient {
+		cfg.clampLogged()
+	}
+
+	return cfg, nil
+}
+
+//pathError names file of path in err, errors of unknown formats are named by extension already
+func pathError(path string, err error) error {
+	if err != nil && err != errConfigFormat {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+
+	return err
+}
+
+//Clamp brings every setting of config out of its allowed values to the nearest allowed one, percentages into
+//5-100, negative durations to 0, unknown modes and decisions and labels of empty keys to none. it returns what it
+//changed like Validate, nil if nothing
+func (cfg *Config) Clamp() error {
+	var errs []error
+	clamp := func(at string, s *Settings) {
+		var err error
+		if *s, err = s.Clamp(); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", at, err))
+		}
+	}
+
+	clamp("defaults", &cfg.Defaults)
+	for i := range cfg.Breakers {
+		at := cfg.Breakers[i].Name
+		if at == "" {
+			at = fmt.Sprintf("breakers[%d]", i)
+		}
+		clamp(at, &cfg.Breakers[i])
+	}
+	names := make([]string, 0, len(cfg.Templates))
+	for name := range cfg.Templates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		s := cfg.Templates[name]
+		clamp("templates."+name, &s)
+		cfg.Templates[name] = s
+	}
+	names = names[:0]
+	for name := range cfg.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		p := cfg.Profiles[name]
+		clamp("profiles."+name+".defaults", &p.Defaults)
+		for i := range p.Breakers {
+			clamp("profiles."+name+"."+p.Breakers[i].Name, &p.Breakers[i])
+		}
+		cfg.Profiles[name] = p
+	}
+
+	return errors.Join(errs...)
+}
+
+//clampLogged clamps config and logs what it changed
+func (cfg *Config) clampLogged() {
+	if err := cfg.Clamp(); err != nil {
+		log.Printf("circuit breaker config out of range, clamped: %v", strings.ReplaceAll(err.Error(), "\n", "; "))
+	}
+}
+
+//Clamp returns settings with every field out of its allowed values brought to the nearest allowed one, and
+//what it changed like Validate
+func (s Settings) Clamp() (Settings, error) {
+	err := s.Validate()
+	if err == nil {
+		return s, nil
+	}
+
+	if _, ok := s.Labels[""]; ok {
+		labels := make(map[string]string, len(s.Labels))
+		for k, v := range s.Labels {
+			if k != "" {
+				labels[k] = v
+			}
+		}
+		s.Labels = labels
+	}
+	for _, d := range []*time.Duration{&s.RefreshInterval, &s.RecoveryInterval, &s.SleepWindow} {
+		if *d < 0 {
+			*d = 0
+		}
+	}
+	if p := s.ErrorThresholdPercent; p != 0 && p < minErrorThresholdPercent {
+		s.ErrorThresholdPercent = minErrorThresholdPercent
+	} else if p > maxErrorThresholdPercent {
+		s.ErrorThresholdPercent = maxErrorThresholdPercent
+	}
+	s.errorThresholdText = ""
+	if _, e := ParseMode(s.Mode); s.Mode != "" && e != nil {
+		s.Mode = ""
+	}
+	if _, e := ParseDecision(s.Decision); s.Decision != "" && e != nil {
+		s.Decision = ""
+	}
+
+	return s, err
+}
+
+//CheckConfigKeys returns an error naming every key of config of b, in the format of file extension ext, which
+//ReadConfig would ignore, e.g. a misspelled sleep_windw, or nil if there is none
+func CheckConfigKeys(b []byte, ext string) error {
+	var doc interface{}
+	var err error
+	switch ext {
+	case ".json":
+		err = json.Unmarshal(b, &doc)
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(b, &doc)
+	default:
+		return errConfigFormat
+	}
+	if err != nil {
+		return err
+	}
+
+	var unknown []string
+	unknownKeys(doc, reflect.TypeOf(Config{}), "", &unknown)
+	if len(unknown) > 0 {
+		return fmt.Errorf("unknown circuit breaker config keys: %s", strings.Join(unknown, ", "))
+	}
+
+	return nil
+}
+
+//unknownKeys appends keys of doc not in json tags of t, at is the path of doc
+func unknownKeys(doc interface{}, t reflect.Type, at string, unknown *[]string) {
+	if t == reflect.TypeOf(Settings{}) {
+		t = reflect.TypeOf(settingsDoc{})
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		m, ok := doc.(map[string]interface{})
+		if !ok {
+			return
+		}
+		fields := make(map[string]reflect.Type, t.NumField())
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			fields[strings.Split(f.Tag.Get("json"), ",")[0]] = f.Type
+		}
+		for _, k := range sortedKeys(m) {
+			if ft, ok := fields[k]; ok {
+				unknownKeys(m[k], ft, keyPath(at, k), unknown)
+			} else {
+				*unknown = append(*unknown, keyPath(at, k))
+			}
+		}
+	case reflect.Slice:
+		l, _ := doc.([]interface{})
+		for i, v := range l {
+			unknownKeys(v, t.Elem(), fmt.Sprintf("%s[%d]", at, i), unknown)
+		}
+	case reflect.Map:
+		m, _ := doc.(map[string]interface{})
+		for _, k := range sortedKeys(m) {
+			unknownKeys(m[k], t.Elem(), keyPath(at, k), unknown)
+		}
+	}
+}
+
+func keyPath(at, key string) string {
+	if at == "" {
+		return key
+	}
+
+	return at + "." + key
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
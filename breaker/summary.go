@@ -0,0 +1,40 @@
+package breaker
+
+import (
+	"log"
+	"sync/atomic"
+	"time"
+)
+
+//WithSummaryLog logs a one-line summary of circuit breaker to l every interval. l defaults to log.Default()
+func WithSummaryLog(interval time.Duration, l *log.Logger) CircuitBreakerOption {
+	return func(c *CircuitBreaker) {
+		if l == nil {
+			l = log.Default()
+		}
+
+		c.summaryInterval = interval
+		c.summaryLogger = l
+	}
+}
+
+func (c *CircuitBreaker) logSummary() {
+	t := time.NewTicker(c.summaryInterval)
+	for {
+		select {
+		case <-t.C:
+			requests, errors := c.counts()
+
+			var rate float64
+			if requests > 0 {
+				rate = float64(errors) / float64(requests) * 100
+			}
+
+			c.summaryLogger.Printf("circuit breaker %s: status=%s requests=%d errors=%d error_rate=%.1f%% rejected=%d",
+				c.name, StatusText(c.Status()), requests, errors, rate, atomic.SwapUint32(&c.rejectedVolume, 0))
+		case <-c.closeChan:
+			t.Stop()
+			return
+		}
+	}
+}
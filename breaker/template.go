@@ -0,0 +1,32 @@
+package breaker
+
+import (
+	"fmt"
+	"strings"
+)
+
+//extend returns s over the templates it extends, fields of s that are not zero override. templates may extend
+//other templates, a cycle or an unknown template is an error
+func (cfg *Config) extend(s Settings) (Settings, error) {
+	var chain []string
+	for s.Extends != "" {
+		name := s.Extends
+		for _, seen := range chain {
+			if seen == name {
+				return s, fmt.Errorf("circuit breaker config templates extend in a cycle: %s -> %s",
+					strings.Join(chain, " -> "), name)
+			}
+		}
+		chain = append(chain, name)
+
+		t, ok := cfg.Templates[name]
+		if !ok {
+			return s, fmt.Errorf("unknown circuit breaker config template %q", name)
+		}
+		t.Name = ""
+		s = t.Override(s)
+		s.Extends = t.Extends
+	}
+
+	return s, nil
+}
@@ -0,0 +1,126 @@
+package breaker
+
+import (
+	"context"
+	"time"
+)
+
+//TransitionCause why status of circuit breaker changed
+type TransitionCause uint8
+
+const (
+	CauseErrors       TransitionCause = iota + 1 //errors reached thresholds, or a request failed in half-open
+	CauseSleepWindow                             //sleep window ended
+	CauseSharedWindow                            //counts shared by WindowCounter reached thresholds
+	CauseManual                                  //Trip was called
+	CausePeer                                    //a peer tripped, see TripFromPeer
+	CauseRestored                                //state was restored by ImportState
+	CauseRecovered                               //requests in half-open succeeded, see CircuitBreakerCloseConfig
+)
+
+func (c TransitionCause) String() string {
+	switch c {
+	case CauseErrors:
+		return "errors"
+	case CauseSleepWindow:
+		return "sleep-window"
+	case CauseSharedWindow:
+		return "shared-window"
+	case CauseManual:
+		return "manual"
+	case CausePeer:
+		return "peer"
+	case CauseRestored:
+		return "restored"
+	case CauseRecovered:
+		return "recovered"
+	default:
+		return "unknown"
+	}
+}
+
+//Transition a status change of a circuit breaker, its JSON encoding is what distributed backends exchange
+type Transition struct {
+	Name  string          `json:"name"`
+	From  int32           `json:"from"`
+	To    int32           `json:"to"`
+	Cause TransitionCause `json:"cause"`
+	Time  time.Time       `json:"time"`
+	Until time.Time       `json:"until,omitempty"` //end of sleep window, only when To is open
+
+	Instance string `json:"instance,omitempty"` //instance the transition happened on, set by distributed backends
+	Zone     string `json:"zone,omitempty"`     //region or zone of the instance, see Zone
+}
+
+//WithTransitionListener calls f after every status change. f is called synchronously and must not block,
+//it can be given more than once
+func WithTransitionListener(f func(Transition)) CircuitBreakerOption {
+	return func(c *CircuitBreaker) {
+		if f != nil {
+			c.listeners = append(c.listeners, f)
+		}
+	}
+}
+
+func (c *CircuitBreaker) notifyTransition(from, to int32, cause TransitionCause) {
+	if len(c.listeners) == 0 {
+		return
+	}
+
+	t := Transition{
+		Name:  c.name,
+		From:  from,
+		To:    to,
+		Cause: cause,
+		Time:  time.Now(),
+	}
+	if to == CircuitBreakerStatusOpen {
+		t.Until = c.openUntil()
+	}
+
+	for _, f := range c.listeners {
+		f(t)
+	}
+}
+
+//Trip turns circuit breaker to open for its sleep window regardless of errors,
+//false if it is open already or closed by Close
+func (c *CircuitBreaker) Trip() bool {
+	return c.trip(CauseManual, c.nextSleepWindow())
+}
+
+//TripFor works like Trip for ttl instead of the sleep window. distributed stores keep the trip for ttl as well
+func (c *CircuitBreaker) TripFor(ttl time.Duration) bool {
+	if ttl <= 0 {
+		return false
+	}
+
+	return c.trip(CauseManual, ttl)
+}
+
+//TripFromPeer turns circuit breaker to open for sleepWindow because a peer tripped, e.g. with the remaining
+//sleep window of the peer. distributed backends don't publish transitions of this cause again.
+//circuit breakers of DecisionLocal ignore it
+func (c *CircuitBreaker) TripFromPeer(sleepWindow time.Duration) bool {
+	if sleepWindow <= 0 || !c.shares() {
+		return false
+	}
+
+	return c.trip(CausePeer, sleepWindow)
+}
+
+func (c *CircuitBreaker) trip(cause TransitionCause, sleepWindow time.Duration) bool {
+	if c.exited() {
+		return false
+	}
+
+	for {
+		from, _ := c.loadState()
+		if from == CircuitBreakerStatusOpen {
+			return false
+		}
+		if c.openFor(context.Background(), from, cause, sleepWindow) {
+			return true
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package breaker
+
+import (
+	"fmt"
+	"time"
+)
+
+//updateOpenConfig replaces open config by f applied to a copy of it, retrying if it's replaced meanwhile
+func (c *CircuitBreaker) updateOpenConfig(f func(oc *CircuitBreakerOpenConfig)) {
+	for {
+		cur := c.openConfig.Load()
+		oc := *cur
+		f(&oc)
+		oc.errorVolumeThreshold = errorVolumeThreshold(oc)
+		if c.openConfig.CompareAndSwap(cur, &oc) {
+			return
+		}
+	}
+}
+
+//updateCloseConfig replaces close config by f applied to a copy of it, retrying if it's replaced meanwhile
+func (c *CircuitBreaker) updateCloseConfig(f func(cc *CircuitBreakerCloseConfig)) {
+	for {
+		cur := c.closeConfig.Load()
+		cc := *cur
+		f(&cc)
+		if c.closeConfig.CompareAndSwap(cur, &cc) {
+			return
+		}
+	}
+}
+
+//SetErrorThresholdPercent changes error threshold of circuit breaker, it takes effect from the next error
+func (c *CircuitBreaker) SetErrorThresholdPercent(p uint8) error {
+	if p < minErrorThresholdPercent || p > maxErrorThresholdPercent {
+		return &FieldError{Field: "error_threshold_percent", Value: p,
+			Allowed: fmt.Sprintf("%d-%d", minErrorThresholdPercent, maxErrorThresholdPercent)}
+	}
+
+	c.updateOpenConfig(func(oc *CircuitBreakerOpenConfig) { oc.ErrorThresholdPercent = p })
+	return nil
+}
+
+//SetRequestVolumeThreshold changes request volume threshold of circuit breaker, it takes effect from the next error
+func (c *CircuitBreaker) SetRequestVolumeThreshold(n uint32) {
+	c.updateOpenConfig(func(oc *CircuitBreakerOpenConfig) { oc.RequestVolumeThreshold = n })
+}
+
+//SetRefreshInterval changes refresh interval of circuit breaker, counting starts anew in the new interval
+func (c *CircuitBreaker) SetRefreshInterval(d time.Duration) error {
+	if d <= 0 {
+		return &FieldError{Field: "refresh_interval", Value: d, Allowed: "a duration > 0"}
+	}
+
+	c.updateOpenConfig(func(oc *CircuitBreakerOpenConfig) { oc.RefreshInterval = d })
+	return nil
+}
+
+//SetSleepWindow changes sleep window of circuit breaker, it takes effect from the next trip
+func (c *CircuitBreaker) SetSleepWindow(d time.Duration) error {
+	if d <= 0 {
+		return &FieldError{Field: "sleep_window", Value: d, Allowed: "a duration > 0"}
+	}
+
+	WithSleepWindow(d)(c)
+	return nil
+}
+
+//SetRecoveryInterval changes recovery interval of circuit breaker, it takes effect from the next request in
+//half-open, counted from when circuit breaker turned half-open
+func (c *CircuitBreaker) SetRecoveryInterval(d time.Duration) error {
+	if d <= 0 {
+		return &FieldError{Field: "recovery_interval", Value: d, Allowed: "a duration > 0"}
+	}
+
+	c.updateCloseConfig(func(cc *CircuitBreakerCloseConfig) { cc.RecoveryInterval = d })
+	return nil
+}
+
+//SetSuccessVolumeThreshold changes success volume threshold of circuit breaker, it takes effect from the next
+//request in half-open, successes counted so far included
+func (c *CircuitBreaker) SetSuccessVolumeThreshold(n uint32) {
+	c.updateCloseConfig(func(cc *CircuitBreakerCloseConfig) { cc.SuccessVolumeThreshold = n })
+}
+
+//SetLabels replaces labels of circuit breaker
+func (c *CircuitBreaker) SetLabels(labels map[string]string) {
+	c.setLabels(labels)
+}
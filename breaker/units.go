@@ -0,0 +1,204 @@
+package breaker
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+//ParsePercent parses a whole percentage like "20%" or "20". it leaves range checks to Validate and Clamp, a
+//percentage over 255 saturates to 255 so it still fails one and is capped by the other. settings read from
+//config or environment keep the text of a saturated one for Validate to report it as written
+func ParsePercent(s string) (uint8, error) {
+	v, err := strconv.ParseUint(strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(s), "%")), 10, 64)
+	if err != nil && !errors.Is(err, strconv.ErrRange) {
+		return 0, fmt.Errorf("invalid percentage %q", s)
+	}
+	if err != nil || v > math.MaxUint8 {
+		v = math.MaxUint8
+	}
+
+	return uint8(v), nil
+}
+
+//duration of settings in a config file, a string like "250ms" or "3m", or nanoseconds
+type duration time.Duration
+
+func (d duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+func (d *duration) UnmarshalJSON(b []byte) error {
+	var v interface{}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+
+	switch v := v.(type) {
+	case string:
+		return d.parse(v)
+	case float64:
+		*d = duration(v)
+		return nil
+	default:
+		return fmt.Errorf("invalid duration %s", b)
+	}
+}
+
+func (d duration) MarshalYAML() (interface{}, error) {
+	return time.Duration(d).String(), nil
+}
+
+func (d *duration) UnmarshalYAML(n *yaml.Node) error {
+	return d.parse(n.Value)
+}
+
+func (d *duration) parse(s string) error {
+	if ns, err := strconv.ParseInt(s, 10, 64); err == nil {
+		*d = duration(ns)
+		return nil
+	}
+
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = duration(v)
+
+	return nil
+}
+
+//percent of settings in a config file, a string like "20%" or a number
+type percent uint8
+
+func (p *percent) UnmarshalJSON(b []byte) error {
+	var v interface{}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+
+	s, ok := v.(string)
+	if !ok {
+		s = string(b)
+	}
+	parsed, err := ParsePercent(s)
+	*p = percent(parsed)
+
+	return err
+}
+
+func (p *percent) UnmarshalYAML(n *yaml.Node) error {
+	parsed, err := ParsePercent(n.Value)
+	*p = percent(parsed)
+
+	return err
+}
+
+//settingsDoc is the serialized form of Settings with human-readable durations and percentages
+type settingsDoc struct {
+	Name   string            `json:"name" yaml:"name"`
+	Labels map[string]string `json:"labels,omitempty" yaml:"labels,omitempty"`
+
+	RefreshInterval        duration `json:"refresh_interval,omitempty" yaml:"refresh_interval,omitempty"`
+	ErrorThresholdPercent  percent  `json:"error_threshold_percent,omitempty" yaml:"error_threshold_percent,omitempty"`
+	RequestVolumeThreshold uint32   `json:"request_volume_threshold,omitempty" yaml:"request_volume_threshold,omitempty"`
+
+	RecoveryInterval       duration `json:"recovery_interval,omitempty" yaml:"recovery_interval,omitempty"`
+	SuccessVolumeThreshold uint32   `json:"success_volume_threshold,omitempty" yaml:"success_volume_threshold,omitempty"`
+
+	SleepWindow duration `json:"sleep_window,omitempty" yaml:"sleep_window,omitempty"`
+
+	Mode     string `json:"mode,omitempty" yaml:"mode,omitempty"`
+	Decision string `json:"decision,omitempty" yaml:"decision,omitempty"`
+
+	Extends string `json:"extends,omitempty" yaml:"extends,omitempty"`
+}
+
+func (s Settings) doc() settingsDoc {
+	return settingsDoc{
+		Name:                   s.Name,
+		Labels:                 s.Labels,
+		RefreshInterval:        duration(s.RefreshInterval),
+		ErrorThresholdPercent:  percent(s.ErrorThresholdPercent),
+		RequestVolumeThreshold: s.RequestVolumeThreshold,
+		RecoveryInterval:       duration(s.RecoveryInterval),
+		SuccessVolumeThreshold: s.SuccessVolumeThreshold,
+		SleepWindow:            duration(s.SleepWindow),
+		Mode:                   s.Mode,
+		Decision:               s.Decision,
+		Extends:                s.Extends,
+	}
+}
+
+func (d settingsDoc) settings() Settings {
+	return Settings{
+		Name:                   d.Name,
+		Labels:                 d.Labels,
+		RefreshInterval:        time.Duration(d.RefreshInterval),
+		ErrorThresholdPercent:  uint8(d.ErrorThresholdPercent),
+		RequestVolumeThreshold: d.RequestVolumeThreshold,
+		RecoveryInterval:       time.Duration(d.RecoveryInterval),
+		SuccessVolumeThreshold: d.SuccessVolumeThreshold,
+		SleepWindow:            time.Duration(d.SleepWindow),
+		Mode:                   d.Mode,
+		Decision:               d.Decision,
+		Extends:                d.Extends,
+	}
+}
+
+//MarshalJSON writes durations as strings like "30s"
+func (s Settings) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.doc())
+}
+
+//UnmarshalJSON reads durations as strings like "30s" or nanoseconds, and percentages as strings like "20%"
+//or numbers
+func (s *Settings) UnmarshalJSON(b []byte) error {
+	var d settingsDoc
+	if err := json.Unmarshal(b, &d); err != nil {
+		return err
+	}
+	*s = d.settings()
+
+	return s.keepPercentText(func(v interface{}) error { return json.Unmarshal(b, v) })
+}
+
+//MarshalYAML writes durations as strings like "30s"
+func (s Settings) MarshalYAML() (interface{}, error) {
+	return s.doc(), nil
+}
+
+//UnmarshalYAML reads durations as strings like "30s" or nanoseconds, and percentages as strings like "20%"
+//or numbers
+func (s *Settings) UnmarshalYAML(n *yaml.Node) error {
+	var d settingsDoc
+	if err := n.Decode(&d); err != nil {
+		return err
+	}
+	*s = d.settings()
+
+	return s.keepPercentText(n.Decode)
+}
+
+//keepPercentText keeps the text of a saturated error threshold of s decoded by decode
+func (s *Settings) keepPercentText(decode func(v interface{}) error) error {
+	if s.ErrorThresholdPercent != math.MaxUint8 {
+		return nil
+	}
+
+	var raw struct {
+		P interface{} `json:"error_threshold_percent" yaml:"error_threshold_percent"`
+	}
+	if err := decode(&raw); err != nil {
+		return err
+	}
+	s.errorThresholdText = fmt.Sprint(raw.P)
+
+	return nil
+}
@@ -0,0 +1,35 @@
+package breaker
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPercentOutOfRange(t *testing.T) {
+	for _, tc := range []struct {
+		config, ext, written string
+	}{
+		{`{"breakers":[{"name":"a","error_threshold_percent":"300%"}]}`, ".json", "300%"},
+		{`{"breakers":[{"name":"a","error_threshold_percent":300}]}`, ".json", "300"},
+		{"breakers:\n  - name: a\n    error_threshold_percent: 1000\n", ".yaml", "1000"},
+	} {
+		cfg, err := ConfigDefault.ParseConfig([]byte(tc.config), tc.ext)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.config, err)
+		}
+		if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), tc.written+" is not allowed") {
+			t.Errorf("%s: Validate = %v, want it to report %s", tc.config, err, tc.written)
+		}
+
+		cfg, err = ConfigLenient.ParseConfig([]byte(tc.config), tc.ext)
+		if err != nil {
+			t.Fatalf("%s: lenient: %v", tc.config, err)
+		}
+		if p := cfg.Breakers[0].ErrorThresholdPercent; p != maxErrorThresholdPercent {
+			t.Errorf("%s: lenient: error threshold %d, want clamped to %d", tc.config, p, maxErrorThresholdPercent)
+		}
+		if err := cfg.Validate(); err != nil {
+			t.Errorf("%s: lenient: %v", tc.config, err)
+		}
+	}
+}
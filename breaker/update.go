@@ -0,0 +1,348 @@
+package breaker
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+//ConfigChangeKind is what happened to a circuit breaker by UpdateConfig
+type ConfigChangeKind int
+
+const (
+	ConfigChangeUpdated ConfigChangeKind = iota + 1 //a setting of a live circuit breaker changed
+	ConfigChangeCreated                             //circuit breaker was created and registered
+	ConfigChangeRemoved                             //circuit breaker was removed from registry and closed
+)
+
+func (k ConfigChangeKind) String() string {
+	switch k {
+	case ConfigChangeUpdated:
+		return "updated"
+	case ConfigChangeCreated:
+		return "created"
+	case ConfigChangeRemoved:
+		return "removed"
+	default:
+		return "unknown"
+	}
+}
+
+//ConfigChange describes a change made by UpdateConfig
+type ConfigChange struct {
+	Name  string //name of circuit breaker
+	Kind  ConfigChangeKind
+	Field string //config key of the setting, e.g. sleep_window, only for ConfigChangeUpdated
+	Old   string
+	New   string
+}
+
+func (ch ConfigChange) String() string {
+	if ch.Kind != ConfigChangeUpdated {
+		return ch.Name + " " + ch.Kind.String()
+	}
+
+	return fmt.Sprintf("%s %s %s -> %s", ch.Name, ch.Field, ch.Old, ch.New)
+}
+
+//settingsFields are the settings UpdateConfig compares and applies one by one, so a setting changed at runtime,
+//e.g. by SetMode, is only overridden when config changes it
+var settingsFields = []struct {
+	name  string
+	value func(s Settings) string
+	apply func(c *CircuitBreaker, s Settings)
+}{
+	{"labels", func(s Settings) string { return formatLabels(s.Labels) }, func(c *CircuitBreaker, s Settings) {
+		c.SetLabels(s.Labels)
+	}},
+	{"refresh_interval", func(s Settings) string { return s.RefreshInterval.String() }, func(c *CircuitBreaker, s Settings) {
+		c.SetRefreshInterval(s.RefreshInterval)
+	}},
+	{"error_threshold_percent", func(s Settings) string { return strconv.Itoa(int(s.ErrorThresholdPercent)) }, func(c *CircuitBreaker, s Settings) {
+		c.SetErrorThresholdPercent(s.ErrorThresholdPercent)
+	}},
+	{"request_volume_threshold", func(s Settings) string { return strconv.Itoa(int(s.RequestVolumeThreshold)) }, func(c *CircuitBreaker, s Settings) {
+		c.SetRequestVolumeThreshold(s.RequestVolumeThreshold)
+	}},
+	{"recovery_interval", func(s Settings) string { return s.RecoveryInterval.String() }, func(c *CircuitBreaker, s Settings) {
+		c.SetRecoveryInterval(s.RecoveryInterval)
+	}},
+	{"success_volume_threshold", func(s Settings) string { return strconv.Itoa(int(s.SuccessVolumeThreshold)) }, func(c *CircuitBreaker, s Settings) {
+		c.SetSuccessVolumeThreshold(s.SuccessVolumeThreshold)
+	}},
+	{"sleep_window", func(s Settings) string { return s.SleepWindow.String() }, func(c *CircuitBreaker, s Settings) {
+		c.SetSleepWindow(s.SleepWindow)
+	}},
+	{"mode", func(s Settings) string { return s.Mode }, func(c *CircuitBreaker, s Settings) {
+		m, _ := ParseMode(s.Mode)
+		c.SetMode(m)
+	}},
+	{"decision", func(s Settings) string { return s.Decision }, func(c *CircuitBreaker, s Settings) {
+		d, _ := ParseDecision(s.Decision)
+		c.SetDecision(d)
+	}},
+}
+
+//withDefaults returns s with zero fields set to defaults of New
+func (s Settings) withDefaults() Settings {
+	return Defaults().Override(s)
+}
+
+//Settings returns current settings of circuit breaker
+func (c *CircuitBreaker) Settings() Settings {
+	oc := c.openConfig.Load()
+	cc := c.closeConfig.Load()
+
+	return Settings{
+		Name:                   c.name,
+		Labels:                 c.Labels(),
+		RefreshInterval:        oc.RefreshInterval,
+		ErrorThresholdPercent:  oc.ErrorThresholdPercent,
+		RequestVolumeThreshold: oc.RequestVolumeThreshold,
+		RecoveryInterval:       cc.RecoveryInterval,
+		SuccessVolumeThreshold: cc.SuccessVolumeThreshold,
+		SleepWindow:            c.SleepWindow(),
+		Mode:                   c.Mode().String(),
+		Decision:               c.Decision().String(),
+	}
+}
+
+//UpdateConfig applies settings s to the live circuit breaker, keeping its status and counts, and returns what
+//changed. settings are compared to the ones last applied by config, or to current settings if there are none,
+//zero fields meaning defaults of New
+func (c *CircuitBreaker) UpdateConfig(s Settings) ([]ConfigChange, error) {
+	next, changes, err := c.planConfig(s)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, ch := range changes {
+		for _, f := range settingsFields {
+			if f.name == ch.Field {
+				f.apply(c, next)
+			}
+		}
+	}
+	c.applied.Store(&next)
+
+	return changes, nil
+}
+
+//planConfig returns settings s with defaults and the changes UpdateConfig of s would make
+func (c *CircuitBreaker) planConfig(s Settings) (Settings, []ConfigChange, error) {
+	if s.Name != "" && s.Name != c.name {
+		return s, nil, fmt.Errorf("settings of %q given to circuit breaker %q", s.Name, c.name)
+	}
+	s.Name = c.name
+	if err := s.Validate(); err != nil {
+		return s, nil, err
+	}
+
+	next := s.withDefaults()
+	prev := c.Settings()
+	if applied := c.applied.Load(); applied != nil {
+		prev = *applied
+	}
+
+	var changes []ConfigChange
+	for _, f := range settingsFields {
+		if from, to := f.value(prev), f.value(next); from != to {
+			changes = append(changes, ConfigChange{Name: c.name, Kind: ConfigChangeUpdated, Field: f.name, Old: from, New: to})
+		}
+	}
+
+	return next, changes, nil
+}
+
+//Diff is what Registry.UpdateConfig of a config would do, see Registry.PlanConfig
+type Diff struct {
+	Created []string       //names of circuit breakers to create
+	Removed []string       //names of circuit breakers to remove and close
+	Updated []ConfigChange //settings to change of live circuit breakers
+}
+
+//Empty reports whether nothing would change
+func (d Diff) Empty() bool {
+	return len(d.Created) == 0 && len(d.Removed) == 0 && len(d.Updated) == 0
+}
+
+//Changes returns the diff as the changes UpdateConfig would return
+func (d Diff) Changes() []ConfigChange {
+	changes := make([]ConfigChange, 0, len(d.Updated)+len(d.Created)+len(d.Removed))
+	changes = append(changes, d.Updated...)
+	for _, name := range d.Created {
+		changes = append(changes, ConfigChange{Name: name, Kind: ConfigChangeCreated})
+	}
+	for _, name := range d.Removed {
+		changes = append(changes, ConfigChange{Name: name, Kind: ConfigChangeRemoved})
+	}
+
+	return changes
+}
+
+//PlanConfig returns what UpdateConfig of cfg would change without applying anything, e.g. to log or gate a
+//reload. it fails as UpdateConfig would
+func (r *Registry) PlanConfig(cfg *Config) (Diff, error) {
+	var d Diff
+	cfg, err := cfg.Resolve("")
+	if err != nil {
+		return d, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return d, err
+	}
+
+	names := make(map[string]bool, len(cfg.Breakers))
+	for _, s := range cfg.Breakers {
+		names[s.Name] = true
+
+		cb, ok := r.Get(s.Name)
+		if !ok {
+			d.Created = append(d.Created, s.Name)
+			continue
+		}
+		_, changes, err := cb.planConfig(s)
+		if err != nil {
+			return d, fmt.Errorf("%s: %w", s.Name, err)
+		}
+		d.Updated = append(d.Updated, changes...)
+	}
+
+	for _, name := range r.configuredNames() {
+		if !names[name] {
+			d.Removed = append(d.Removed, name)
+		}
+	}
+
+	return d, nil
+}
+
+//UpdateConfig brings registry to cfg: circuit breakers of cfg already registered are updated by their
+//UpdateConfig, new ones are created with opts before their settings, and ones created from an earlier config
+//but missing in cfg are removed and closed. circuit breakers registered by Register are never removed.
+//integrations of cfg replace earlier ones, see Registry.Route. nothing changes if a setting of cfg is invalid. defaults are folded in, profiles are ignored, see Config.Resolve
+func (r *Registry) UpdateConfig(cfg *Config, opts ...CircuitBreakerOption) ([]ConfigChange, error) {
+	cfg, err := cfg.Resolve("")
+	if err != nil {
+		return nil, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	names := make(map[string]bool, len(cfg.Breakers))
+	for _, s := range cfg.Breakers {
+		names[s.Name] = true
+	}
+
+	var changes []ConfigChange
+	for _, s := range cfg.Breakers {
+		if cb, ok := r.Get(s.Name); ok {
+			updated, err := cb.UpdateConfig(s)
+			if err != nil {
+				return changes, fmt.Errorf("%s: %w", s.Name, err)
+			}
+			changes = append(changes, updated...)
+			continue
+		}
+
+		so, _ := s.Options()
+		cb := New(append(append([]CircuitBreakerOption{}, opts...), so...)...)
+		if err := r.register(cb, s); err != nil {
+			cb.Close()
+			return changes, fmt.Errorf("%s: %w", s.Name, err)
+		}
+		changes = append(changes, ConfigChange{Name: s.Name, Kind: ConfigChangeCreated})
+	}
+
+	for _, name := range r.configuredNames() {
+		if names[name] {
+			continue
+		}
+		if cb, ok := r.Get(name); ok {
+			r.Remove(name)
+			cb.Close()
+			changes = append(changes, ConfigChange{Name: name, Kind: ConfigChangeRemoved})
+		}
+	}
+	if r.setIntegrations(cfg.Integrations) || len(changes) > 0 {
+		r.recordVersion(cfg)
+	}
+
+	return changes, nil
+}
+
+//configuredNames returns sorted names of circuit breakers created from config
+func (r *Registry) configuredNames() []string {
+	r.mu.RLock()
+	names := make([]string, 0, len(r.configured))
+	for name := range r.configured {
+		names = append(names, name)
+	}
+	r.mu.RUnlock()
+
+	sort.Strings(names)
+	return names
+}
+
+func formatLabels(labels map[string]string) string {
+	kvs := make([]string, 0, len(labels))
+	for k, v := range labels {
+		kvs = append(kvs, k+"="+v)
+	}
+	sort.Strings(kvs)
+
+	return strings.Join(kvs, ",")
+}
+
+//Override returns s with fields of o that are not zero, labels of o replace labels of s
+func (s Settings) Override(o Settings) Settings {
+	if o.Name != "" {
+		s.Name = o.Name
+	}
+	if o.Labels != nil {
+		s.Labels = o.Labels
+	}
+	if o.RefreshInterval > 0 {
+		s.RefreshInterval = o.RefreshInterval
+	}
+	if o.ErrorThresholdPercent > 0 {
+		s.ErrorThresholdPercent, s.errorThresholdText = o.ErrorThresholdPercent, o.errorThresholdText
+	}
+	if o.RequestVolumeThreshold > 0 {
+		s.RequestVolumeThreshold = o.RequestVolumeThreshold
+	}
+	if o.RecoveryInterval > 0 {
+		s.RecoveryInterval = o.RecoveryInterval
+	}
+	if o.SuccessVolumeThreshold > 0 {
+		s.SuccessVolumeThreshold = o.SuccessVolumeThreshold
+	}
+	if o.SleepWindow > 0 {
+		s.SleepWindow = o.SleepWindow
+	}
+	if o.Mode != "" {
+		s.Mode = o.Mode
+	}
+	if o.Decision != "" {
+		s.Decision = o.Decision
+	}
+
+	return s
+}
+
+//OverrideConfig overrides settings of the registered circuit breaker of s.Name by fields of s that are not zero,
+//see CircuitBreaker.UpdateConfig, e.g. for settings tuned centrally. names not registered are ignored
+func (r *Registry) OverrideConfig(s Settings) ([]ConfigChange, error) {
+	cb, ok := r.Get(s.Name)
+	if !ok {
+		return nil, nil
+	}
+
+	base := cb.Settings()
+	if applied := cb.applied.Load(); applied != nil {
+		base = *applied
+	}
+
+	return cb.UpdateConfig(base.Override(s))
+}
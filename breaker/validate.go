@@ -0,0 +1,73 @@
+package breaker
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+//FieldError is a setting out of its allowed values, returned by Settings.Validate
+type FieldError struct {
+	Field   string //config key of the setting, e.g. error_threshold_percent
+	Value   interface{}
+	Allowed string //allowed values, e.g. "0 or 5-100"
+}
+
+func (e *FieldError) Error() string {
+	return fmt.Sprintf("%s: %v is not allowed, want %s", e.Field, e.Value, e.Allowed)
+}
+
+//Validate returns all settings out of their allowed values joined by errors.Join, each a *FieldError,
+//or nil if there are none. zero values are always allowed, they take defaults
+func (s Settings) Validate() error {
+	var errs []error
+	invalid := func(field string, value interface{}, allowed string) {
+		errs = append(errs, &FieldError{Field: field, Value: value, Allowed: allowed})
+	}
+	duration := func(field string, d time.Duration) {
+		if d < 0 {
+			invalid(field, d, "a duration >= 0")
+		}
+	}
+
+	for k := range s.Labels {
+		if k == "" {
+			invalid("labels", formatLabels(s.Labels), "non-empty keys")
+			break
+		}
+	}
+	duration("refresh_interval", s.RefreshInterval)
+	if p := s.ErrorThresholdPercent; p != 0 && (p < minErrorThresholdPercent || p > maxErrorThresholdPercent) {
+		var value interface{} = p
+		if p == math.MaxUint8 && s.errorThresholdText != "" {
+			value = s.errorThresholdText
+		}
+		invalid("error_threshold_percent", value, fmt.Sprintf("0 or %d-%d", minErrorThresholdPercent, maxErrorThresholdPercent))
+	}
+	duration("recovery_interval", s.RecoveryInterval)
+	duration("sleep_window", s.SleepWindow)
+	if s.Mode != "" {
+		if _, err := ParseMode(s.Mode); err != nil {
+			invalid("mode", s.Mode, "normal, force-open, force-closed or shadow")
+		}
+	}
+	if s.Decision != "" {
+		if _, err := ParseDecision(s.Decision); err != nil {
+			invalid("decision", s.Decision, "hybrid, local or global")
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+//NewFromSettings return a new circuit breaker of settings s, opts apply before them. it fails if s is not valid,
+//see Settings.Validate
+func NewFromSettings(s Settings, opts ...CircuitBreakerOption) (*CircuitBreaker, error) {
+	so, err := s.Options()
+	if err != nil {
+		return nil, err
+	}
+
+	return New(append(append([]CircuitBreakerOption{}, opts...), so...)...), nil
+}
@@ -0,0 +1,74 @@
+package breaker
+
+import (
+	"fmt"
+	"time"
+)
+
+const defaultConfigHistory = 10
+
+//ConfigVersion is a config applied to a registry by Config.Apply, or by UpdateConfig if it changed anything
+type ConfigVersion struct {
+	Version int //increases by one for every config applied
+	Applied time.Time
+	Config  *Config //with defaults folded in, don't modify it
+}
+
+//SetConfigHistory sets how many applied configs registry keeps for RollbackConfig, default 10
+func (r *Registry) SetConfigHistory(n int) {
+	r.versionsMu.Lock()
+	defer r.versionsMu.Unlock()
+
+	r.historySize = n
+	r.trimVersions()
+}
+
+//ConfigVersions returns configs kept for RollbackConfig, oldest first
+func (r *Registry) ConfigVersions() []ConfigVersion {
+	r.versionsMu.Lock()
+	defer r.versionsMu.Unlock()
+
+	return append([]ConfigVersion(nil), r.versions...)
+}
+
+//RollbackConfig applies the config of version again by UpdateConfig, which makes it the newest version.
+//opts are used for circuit breakers created by it, as in UpdateConfig
+func (r *Registry) RollbackConfig(version int, opts ...CircuitBreakerOption) ([]ConfigChange, error) {
+	var cfg *Config
+	r.versionsMu.Lock()
+	for _, v := range r.versions {
+		if v.Version == version {
+			cfg = v.Config
+		}
+	}
+	r.versionsMu.Unlock()
+
+	if cfg == nil {
+		return nil, fmt.Errorf("no circuit breaker config of version %d", version)
+	}
+
+	return r.UpdateConfig(cfg, opts...)
+}
+
+//recordVersion keeps cfg as a new version
+func (r *Registry) recordVersion(cfg *Config) {
+	r.versionsMu.Lock()
+	defer r.versionsMu.Unlock()
+
+	r.lastVersion++
+	r.versions = append(r.versions, ConfigVersion{Version: r.lastVersion, Applied: time.Now(), Config: cfg})
+	r.trimVersions()
+}
+
+func (r *Registry) trimVersions() {
+	n := r.historySize
+	if n == 0 {
+		n = defaultConfigHistory
+	}
+	if n < 0 {
+		n = 0
+	}
+	if over := len(r.versions) - n; over > 0 {
+		r.versions = append(r.versions[:0:0], r.versions[over:]...)
+	}
+}
@@ -0,0 +1,113 @@
+package breaker
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	wheelTick  = 10 * time.Millisecond
+	wheelSlots = 512
+)
+
+//sleepWheel ends sleep windows of all circuit breakers
+var sleepWheel = &timerWheel{tick: wheelTick}
+
+//timerWheel is a hashed timer wheel running funcs after their delay, rounded up to a tick. one goroutine serves
+//all timers and runs the funcs in order, so they must not block. it exits when no timer is left and starts
+//again with the next one
+type timerWheel struct {
+	tick time.Duration
+
+	mu      sync.Mutex
+	slots   [wheelSlots]*wheelTimer //timers of tick mod wheelSlots, linked by next
+	base    time.Time               //time of tick 0
+	cur     int64                   //last tick whose timers ran
+	pending int
+	running bool
+}
+
+type wheelTimer struct {
+	tick int64 //tick since base to run at
+	f    func()
+	next *wheelTimer
+}
+
+//after runs f on the wheel goroutine once d has passed
+func (w *timerWheel) after(d time.Duration, f func()) {
+	now := time.Now()
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if !w.running {
+		w.base, w.cur = now, 0
+	}
+
+	tick := int64((now.Sub(w.base) + d + w.tick - 1) / w.tick)
+	if tick <= w.cur {
+		tick = w.cur + 1
+	}
+	t := &wheelTimer{tick: tick, f: f}
+	slot := &w.slots[tick%wheelSlots]
+	t.next, *slot = *slot, t
+	w.pending++
+
+	if !w.running {
+		w.running = true
+		go w.run()
+	}
+}
+
+func (w *timerWheel) run() {
+	t := time.NewTicker(w.tick)
+	defer t.Stop()
+
+	for now := range t.C {
+		if !w.advance(now) {
+			return
+		}
+	}
+}
+
+//advance runs timers due until now, catching up on ticks missed by a late ticker. it reports whether timers
+//are left
+func (w *timerWheel) advance(now time.Time) bool {
+	w.mu.Lock()
+	target := int64(now.Sub(w.base) / w.tick)
+	steps := target - w.cur
+	if steps > wheelSlots {
+		steps = wheelSlots
+	}
+
+	var due []*wheelTimer
+	for i := int64(1); i <= steps; i++ {
+		slot := &w.slots[(w.cur+i)%wheelSlots]
+		var keep *wheelTimer
+		for t := *slot; t != nil; {
+			next := t.next
+			if t.tick <= target {
+				due = append(due, t)
+			} else {
+				t.next, keep = keep, t
+			}
+			t = next
+		}
+		*slot = keep
+	}
+	if target > w.cur {
+		w.cur = target
+	}
+	w.pending -= len(due)
+	if w.pending == 0 {
+		w.running = false
+	}
+	running := w.running
+	w.mu.Unlock()
+
+	for _, t := range due {
+		t.f()
+	}
+
+	return running
+}
@@ -0,0 +1,93 @@
+package breaker
+
+import (
+	"testing"
+	"time"
+)
+
+//stoppedWheel returns a wheel of hour ticks started at base, marked running so after starts no goroutine and
+//tests drive it by advance
+func stoppedWheel() (*timerWheel, time.Time) {
+	base := time.Now()
+	return &timerWheel{tick: time.Hour, base: base, running: true}, base
+}
+
+func TestWheelSlotWrap(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		ticks    []int64 //delays of timers in ticks
+		advances []int64 //ticks advanced to, in order
+		want     []int   //timers run by each advance
+	}{
+		{"in order", []int64{1, 2, 3}, []int64{1, 2, 3}, []int{1, 1, 1}},
+		{"same slot a lap apart", []int64{3, wheelSlots + 3}, []int64{3, wheelSlots + 2, wheelSlots + 3}, []int{1, 0, 1}},
+		{"same slot laps apart", []int64{5, 3*wheelSlots + 5}, []int64{5, 2*wheelSlots + 5, 3*wheelSlots + 5}, []int{1, 0, 1}},
+		{"late ticker catches up", []int64{1, 7, 100}, []int64{100}, []int{3}},
+		{"late ticker past a lap", []int64{1, wheelSlots - 1, wheelSlots + 1}, []int64{2 * wheelSlots}, []int{3}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			w, base := stoppedWheel()
+			ran := 0
+			for _, n := range tc.ticks {
+				//half a tick short, so the time after took doesn't round it up to the next one
+				w.after(time.Duration(n)*w.tick-w.tick/2, func() { ran++ })
+			}
+
+			for i, n := range tc.advances {
+				before := ran
+				running := w.advance(base.Add(time.Duration(n) * w.tick))
+				if got := ran - before; got != tc.want[i] {
+					t.Fatalf("advance to tick %d ran %d timers, want %d", n, got, tc.want[i])
+				}
+				if left := ran < len(tc.ticks); running != left {
+					t.Fatalf("advance to tick %d reports running %v with %d of %d timers run", n, running, ran, len(tc.ticks))
+				}
+			}
+		})
+	}
+}
+
+func TestEndSleepWindowGeneration(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		gen  func(opened uint32) uint32
+		want int32
+	}{
+		{"current trip", func(opened uint32) uint32 { return opened }, CircuitBreakerStatusHalfOpen},
+		{"earlier trip", func(opened uint32) uint32 { return opened - 2 }, CircuitBreakerStatusOpen},
+		{"later generation", func(opened uint32) uint32 { return opened + 1 }, CircuitBreakerStatusOpen},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			//sleep windows of an hour leave firing the wheel to the test
+			c := New(WithSleepWindow(time.Hour))
+			defer c.Close()
+
+			//trip, end that sleep window early and trip again, so a timer of the first trip is outstanding
+			c.TripFor(time.Hour)
+			_, gen := c.loadState()
+			if !c.transition(CircuitBreakerStatusOpen, gen, CircuitBreakerStatusHalfOpen) {
+				t.Fatal("transition to half-open failed")
+			}
+			c.reportHalfOpen(gen+1, time.Hour)
+			c.ReportError()
+			_, opened := c.loadState()
+
+			c.endSleepWindow(tc.gen(opened), time.Hour)
+			if s, _ := c.loadState(); s != tc.want {
+				t.Errorf("status %s, want %s", StatusText(s), StatusText(tc.want))
+			}
+		})
+	}
+}
+
+func TestEndSleepWindowClosed(t *testing.T) {
+	c := New(WithSleepWindow(time.Hour))
+	c.TripFor(time.Hour)
+	_, gen := c.loadState()
+	c.Close()
+
+	c.endSleepWindow(gen, time.Hour)
+	if s, _ := c.loadState(); s != CircuitBreakerStatusOpen {
+		t.Errorf("status %s of a closed circuit breaker, want it left open", StatusText(s))
+	}
+}
@@ -0,0 +1,108 @@
+package breaker
+
+import (
+	"context"
+	"math"
+	"sync/atomic"
+	"time"
+)
+
+const defaultWindowSyncInterval = time.Second
+
+//Window request and error volume of a circuit breaker in a refresh interval
+type Window struct {
+	Start    time.Time //start of the refresh interval, aligned to multiples of RefreshInterval since the unix epoch
+	Requests uint32
+	Errors   uint32
+
+	//OpenFor remaining sleep window of a trip decided by the counter, for counters evaluating thresholds
+	//themselves, e.g. in a redis script. circuit breaker opens for it regardless of its own thresholds
+	OpenFor time.Duration
+}
+
+//SaturatedCount returns n as a count of a Window, math.MaxUint32 if it's more. a window too busy to count stays
+//over the thresholds instead of wrapping below them, e.g. for stores summing counts in 64 bits
+func SaturatedCount(n uint64) uint32 {
+	if n > math.MaxUint32 {
+		return math.MaxUint32
+	}
+
+	return uint32(n)
+}
+
+//WindowCounter shares window counts of circuit breakers between instances, e.g. in Redis.
+//circuit breakers sharing a counter and a name make trip decisions on the counts of all of them
+type WindowCounter interface {
+	//IncrWindow adds requests and errors to the window of name starting at start and returns the totals after adding
+	IncrWindow(ctx context.Context, name string, start time.Time, requests, errors uint32) (Window, error)
+}
+
+//WithWindowCounter shares counts with wc every interval, default 1s. circuit breaker still trips on its own counts,
+//and also when the shared counts reach the thresholds. it must be named, see WithName
+func WithWindowCounter(wc WindowCounter, interval time.Duration) CircuitBreakerOption {
+	return func(c *CircuitBreaker) {
+		if interval <= 0 {
+			interval = defaultWindowSyncInterval
+		}
+
+		c.windowCounter = wc
+		c.windowSyncInterval = interval
+	}
+}
+
+//windowStart returns start of the shared window at t
+func windowStart(t time.Time, refreshInterval time.Duration) time.Time {
+	return t.Truncate(refreshInterval)
+}
+
+func (c *CircuitBreaker) syncWindow() {
+	t := time.NewTicker(c.windowSyncInterval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-t.C:
+			c.flushWindow()
+		case <-c.closeChan:
+			return
+		}
+	}
+}
+
+//flushWindow adds counts since the last flush to the shared window and trips if its totals reach thresholds
+func (c *CircuitBreaker) flushWindow() {
+	if !c.shares() {
+		return
+	}
+
+	requests := atomic.SwapUint32(&c.pendingRequests, 0)
+	errors := atomic.SwapUint32(&c.pendingErrors, 0)
+
+	oc := c.openConfig.Load()
+	ctx, cancel := context.WithTimeout(context.Background(), c.windowSyncInterval)
+	defer cancel()
+
+	w, err := c.windowCounter.IncrWindow(ctx, c.name, windowStart(time.Now(), oc.RefreshInterval), requests, errors)
+	if err != nil {
+		//keep counts for the next flush
+		atomic.AddUint32(&c.pendingRequests, requests)
+		atomic.AddUint32(&c.pendingErrors, errors)
+		c.metrics.IncrCounter(MetricSyncErrors, 1)
+		return
+	}
+
+	switch Mode(atomic.LoadInt32(&c.mode)) {
+	case ModeForceOpen, ModeForceClosed:
+		return
+	}
+
+	if status, _ := c.loadState(); !c.tripsOnSharedCounts() || status != CircuitBreakerStatusClosed {
+		return
+	}
+
+	if w.OpenFor > 0 {
+		c.openFor(ctx, CircuitBreakerStatusClosed, CauseSharedWindow, w.OpenFor)
+	} else if oc.shouldOpen(uint64(w.Requests), uint64(w.Errors)) {
+		c.open(ctx, CircuitBreakerStatusClosed, CauseSharedWindow)
+	}
+}
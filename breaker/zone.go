@@ -0,0 +1,159 @@
+package breaker
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type ZoneOption func(z *Zone)
+
+//WithEscalation makes trips of a breaker in at least zones distinct zones, counting this one, open it here too,
+//for dependencies failing everywhere. default 0, trips of other zones are ignored
+func WithEscalation(zones int) ZoneOption {
+	return func(z *Zone) {
+		z.escalateAt = zones
+	}
+}
+
+//Zone partitions distributed state by a region or zone label, so a dependency failing in eu-west only opens
+//circuit breakers of instances in eu-west. wrap the window counter, publisher and watcher of every instance
+//with the Zone of its label
+type Zone struct {
+	name       string
+	escalateAt int
+
+	mu    sync.Mutex
+	trips map[string]map[string]time.Time //breaker name => zone => end of sleep window
+}
+
+//NewZone return the zone of label name, e.g. "eu-west-1"
+func NewZone(name string, opts ...ZoneOption) *Zone {
+	z := &Zone{
+		name:  name,
+		trips: make(map[string]map[string]time.Time),
+	}
+
+	for _, opt := range opts {
+		opt(z)
+	}
+
+	return z
+}
+
+//Name returns label of zone
+func (z *Zone) Name() string {
+	return z.name
+}
+
+//WindowCounter returns wc counting windows of this zone only
+func (z *Zone) WindowCounter(wc WindowCounter) WindowCounter {
+	return zoneCounter{z: z, wc: wc}
+}
+
+//Publisher returns p publishing transitions labeled with this zone
+func (z *Zone) Publisher(p TransitionPublisher) TransitionPublisher {
+	return zonePublisher{z: z, p: p}
+}
+
+//Watcher returns w passing transitions of this zone and unlabeled ones, and trips escalated by WithEscalation
+func (z *Zone) Watcher(w TransitionWatcher) TransitionWatcher {
+	return zoneWatcher{z: z, w: w}
+}
+
+//Store returns s with the counter, publisher and watcher of this zone
+func (z *Zone) Store(s Store) Store {
+	return zoneStore{
+		zoneCounter:   zoneCounter{z: z, wc: s},
+		zonePublisher: zonePublisher{z: z, p: s},
+		zoneWatcher:   zoneWatcher{z: z, w: s},
+		r:             s,
+	}
+}
+
+type zoneStore struct {
+	zoneCounter
+	zonePublisher
+	zoneWatcher
+	r WindowReader
+}
+
+func (s zoneStore) ReadWindow(ctx context.Context, name string, start time.Time) (Window, error) {
+	return s.r.ReadWindow(ctx, s.zoneCounter.z.name+"/"+name, start)
+}
+
+type zoneCounter struct {
+	z  *Zone
+	wc WindowCounter
+}
+
+func (c zoneCounter) IncrWindow(ctx context.Context, name string, start time.Time, requests, errors uint32) (Window, error) {
+	return c.wc.IncrWindow(ctx, c.z.name+"/"+name, start, requests, errors)
+}
+
+type zonePublisher struct {
+	z *Zone
+	p TransitionPublisher
+}
+
+func (p zonePublisher) PublishTransition(ctx context.Context, t Transition) error {
+	if t.Zone == "" {
+		t.Zone = p.z.name
+	}
+	if t.To == CircuitBreakerStatusOpen && p.z.escalateAt > 0 {
+		//own trips count to escalation, watchers don't see them
+		p.z.recordTrip(t)
+	}
+
+	return p.p.PublishTransition(ctx, t)
+}
+
+type zoneWatcher struct {
+	z *Zone
+	w TransitionWatcher
+}
+
+func (w zoneWatcher) WatchTransitions(ctx context.Context, fn func(Transition)) error {
+	return w.w.WatchTransitions(ctx, func(t Transition) {
+		if w.z.admit(t) {
+			fn(t)
+		}
+	})
+}
+
+//admit reports whether t concerns instances of this zone
+func (z *Zone) admit(t Transition) bool {
+	if t.To == CircuitBreakerStatusOpen && z.escalateAt > 0 {
+		if z.recordTrip(t) >= z.escalateAt {
+			return true
+		}
+	}
+
+	return t.Zone == "" || t.Zone == z.name
+}
+
+//recordTrip returns the number of zones in which the breaker of t is in its sleep window
+func (z *Zone) recordTrip(t Transition) int {
+	z.mu.Lock()
+	defer z.mu.Unlock()
+
+	byZone, ok := z.trips[t.Name]
+	if !ok {
+		byZone = make(map[string]time.Time)
+		z.trips[t.Name] = byZone
+	}
+	zone := t.Zone
+	if zone == "" {
+		zone = z.name
+	}
+	byZone[zone] = t.Until
+
+	now := time.Now()
+	for zone, until := range byZone {
+		if !until.After(now) {
+			delete(byZone, zone)
+		}
+	}
+
+	return len(byZone)
+}
@@ -0,0 +1,190 @@
+package breakeramqp
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/carl-leopard/circuitbreaker/breaker"
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+var (
+	ErrNacked     = errors.New("breakeramqp: message nacked by broker")
+	ErrUnroutable = errors.New("breakeramqp: message returned as unroutable")
+
+	errSpoolFull = errors.New("breakeramqp: spool is full")
+)
+
+type Option func(p *Publisher)
+
+//WithSpool keeps up to size messages published while the breaker is open and replays them
+//before the next message once it lets requests pass again, or on Flush. spooled messages the broker nacks or
+//returns as unroutable are dropped, see WithDeadLetter
+func WithSpool(size int) Option {
+	return func(p *Publisher) {
+		p.spoolSize = size
+	}
+}
+
+//WithDeadLetter passes spooled messages dropped on replay to f with the error they failed with, instead of
+//discarding them. f is called with the publisher locked and must not publish with it
+func WithDeadLetter(f func(exchange, key string, msg amqp.Publishing, err error)) Option {
+	return func(p *Publisher) {
+		p.deadLetter = f
+	}
+}
+
+type pending struct {
+	exchange, key        string
+	mandatory, immediate bool
+	msg                  amqp.Publishing
+}
+
+//Publisher publishes with confirms on a channel under a circuit breaker. nacked and returned messages
+//as well as channel errors count as failures. publishes are serialized so returns can be matched to them
+type Publisher struct {
+	ch      *amqp.Channel
+	cb      *breaker.CircuitBreaker
+	returns chan amqp.Return
+
+	mu         sync.Mutex
+	spoolSize  int
+	spool      []pending
+	deadLetter func(exchange, key string, msg amqp.Publishing, err error)
+}
+
+//NewPublisher puts ch into confirm mode and return a publisher guarded by cb
+func NewPublisher(ch *amqp.Channel, cb *breaker.CircuitBreaker, opts ...Option) (*Publisher, error) {
+	if err := ch.Confirm(false); err != nil {
+		return nil, err
+	}
+
+	p := &Publisher{
+		ch:      ch,
+		cb:      cb,
+		returns: ch.NotifyReturn(make(chan amqp.Return, 1)),
+	}
+
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	return p, nil
+}
+
+//PublishWithContext publishes msg and waits for its confirmation. unroutable messages are only
+//detected with mandatory set
+func (p *Publisher) PublishWithContext(ctx context.Context, exchange, key string, mandatory, immediate bool, msg amqp.Publishing) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if err := p.cb.ReportRequest(); err != nil {
+		if p.spoolSize > 0 && errors.Is(err, breaker.ErrTooManyErrors) {
+			return p.push(pending{exchange, key, mandatory, immediate, msg})
+		}
+		return err
+	}
+
+	//errors of spooled messages are theirs, msg waits behind those left
+	if err := p.replay(ctx); err != nil && len(p.spool) > 0 {
+		return p.push(pending{exchange, key, mandatory, immediate, msg})
+	}
+
+	start := time.Now()
+	err := p.publish(ctx, pending{exchange, key, mandatory, immediate, msg})
+	p.cb.ReportLatency(time.Since(start))
+	p.report(ctx, err)
+
+	return err
+}
+
+//Flush replays spooled messages if the breaker lets requests pass
+func (p *Publisher) Flush(ctx context.Context) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if len(p.spool) == 0 {
+		return nil
+	}
+
+	if err := p.cb.ReportRequest(); err != nil {
+		return err
+	}
+
+	return p.replay(ctx)
+}
+
+//replay publishes spooled messages in order. a message returned as unroutable is dropped and replay goes on, one
+//nacked is dropped and replay stops, so neither is retried forever. it stops at other errors too, keeping the
+//message for the next replay
+func (p *Publisher) replay(ctx context.Context) error {
+	for len(p.spool) > 0 {
+		next := p.spool[0]
+		err := p.publish(ctx, next)
+		p.report(ctx, err)
+		if err == nil {
+			p.spool = p.spool[1:]
+			continue
+		}
+		if !errors.Is(err, ErrUnroutable) && !errors.Is(err, ErrNacked) {
+			return err
+		}
+
+		p.spool = p.spool[1:]
+		if p.deadLetter != nil {
+			p.deadLetter(next.exchange, next.key, next.msg, err)
+		}
+		if errors.Is(err, ErrNacked) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (p *Publisher) push(m pending) error {
+	if len(p.spool) >= p.spoolSize {
+		return errSpoolFull
+	}
+	p.spool = append(p.spool, m)
+
+	return nil
+}
+
+func (p *Publisher) report(ctx context.Context, err error) {
+	if err != nil && !errors.Is(err, context.Canceled) {
+		p.cb.ReportErrorContext(ctx)
+	}
+}
+
+func (p *Publisher) publish(ctx context.Context, m pending) error {
+	//drop returns of messages whose publish has been given up
+	for len(p.returns) > 0 {
+		<-p.returns
+	}
+
+	dc, err := p.ch.PublishWithDeferredConfirmWithContext(ctx, m.exchange, m.key, m.mandatory, m.immediate, m.msg)
+	if err != nil {
+		return err
+	}
+
+	ack, err := dc.WaitContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	//broker sends basic.return before basic.ack of the same message
+	select {
+	case <-p.returns:
+		return ErrUnroutable
+	default:
+	}
+
+	if !ack {
+		return ErrNacked
+	}
+
+	return nil
+}
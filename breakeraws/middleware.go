@@ -0,0 +1,99 @@
+package breakeraws
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	awsmiddleware "github.com/aws/aws-sdk-go-v2/aws/middleware"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+	"github.com/aws/smithy-go"
+	"github.com/aws/smithy-go/middleware"
+	"github.com/carl-leopard/circuitbreaker/breaker"
+)
+
+const middlewareID = "CircuitBreaker"
+
+type Option func(m *finalizeMiddleware)
+
+//WithPerRegion keys circuit breakers by service and region, e.g. "DynamoDB/eu-west-1"
+func WithPerRegion() Option {
+	return func(m *finalizeMiddleware) {
+		m.perRegion = true
+	}
+}
+
+//IsFailure treats throttling, 5xx and errors without response as failures
+func IsFailure(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) {
+		if _, ok := retry.DefaultThrottleErrorCodes[apiErr.ErrorCode()]; ok {
+			return true
+		}
+	}
+
+	var respErr interface{ HTTPStatusCode() int }
+	if errors.As(err, &respErr) {
+		code := respErr.HTTPStatusCode()
+		return code >= http.StatusInternalServerError || code == http.StatusTooManyRequests
+	}
+
+	//no response at all, e.g. network error or timeout
+	return true
+}
+
+//AddMiddleware return an API option, usable in aws.Config.APIOptions, which runs every attempt of a call
+//under the circuit breaker of its service from g
+func AddMiddleware(g *breaker.Group, opts ...Option) func(stack *middleware.Stack) error {
+	m := &finalizeMiddleware{g: g}
+	for _, opt := range opts {
+		opt(m)
+	}
+
+	return func(stack *middleware.Stack) error {
+		//after Retry, so every attempt is a request and an open breaker stops retries
+		if _, ok := stack.Finalize.Get("Retry"); ok {
+			return stack.Finalize.Insert(m, "Retry", middleware.After)
+		}
+
+		return stack.Finalize.Add(m, middleware.After)
+	}
+}
+
+type finalizeMiddleware struct {
+	g         *breaker.Group
+	perRegion bool
+}
+
+func (m *finalizeMiddleware) ID() string {
+	return middlewareID
+}
+
+func (m *finalizeMiddleware) HandleFinalize(ctx context.Context, in middleware.FinalizeInput, next middleware.FinalizeHandler) (
+	out middleware.FinalizeOutput, metadata middleware.Metadata, err error,
+) {
+	key := awsmiddleware.GetServiceID(ctx)
+	if m.perRegion {
+		key += "/" + awsmiddleware.GetRegion(ctx)
+	}
+
+	cb := m.g.Get(key)
+	if err := cb.ReportRequestWithMeta(breaker.RequestMeta{Key: key, Method: awsmiddleware.GetOperationName(ctx)}); err != nil {
+		return out, metadata, err
+	}
+
+	start := time.Now()
+	out, metadata, err = next.HandleFinalize(ctx, in)
+	cb.ReportLatency(time.Since(start))
+
+	if IsFailure(err) {
+		cb.ReportErrorContext(ctx)
+	}
+
+	return out, metadata, err
+}
@@ -0,0 +1,35 @@
+package breakerchi
+
+import (
+	"net/http"
+
+	"github.com/carl-leopard/circuitbreaker/breaker"
+	"github.com/carl-leopard/circuitbreaker/breakerhttp"
+	"github.com/go-chi/chi/v5"
+)
+
+//RoutePattern keys requests by the chi route pattern they match, e.g. "GET /users/{id}". it resolves the
+//pattern up front, so it also works in middlewares registered by Use before routing happens
+func RoutePattern(r *http.Request) string {
+	rctx := chi.RouteContext(r.Context())
+	if rctx == nil || rctx.Routes == nil {
+		return ""
+	}
+
+	path := r.URL.RawPath
+	if path == "" {
+		path = r.URL.Path
+	}
+
+	pattern := rctx.Routes.Find(chi.NewRouteContext(), r.Method, path)
+	if pattern == "" {
+		return ""
+	}
+
+	return r.Method + " " + pattern
+}
+
+//Middleware guards chi routes with a circuit breaker per route pattern from g, see breakerhttp.Middleware
+func Middleware(g *breaker.Group, opts ...breakerhttp.MiddlewareOption) func(http.Handler) http.Handler {
+	return breakerhttp.GroupMiddleware(g, RoutePattern, opts...)
+}
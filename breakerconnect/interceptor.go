@@ -0,0 +1,200 @@
+package breakerconnect
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"sync"
+	"time"
+
+	"connectrpc.com/connect"
+	"github.com/carl-leopard/circuitbreaker/breaker"
+)
+
+type Option func(i *Interceptor)
+
+//WithFailureCodes sets codes reported as errors to circuit breaker, default Unavailable and DeadlineExceeded
+func WithFailureCodes(cs ...connect.Code) Option {
+	return func(i *Interceptor) {
+		i.failureCodes = make(map[connect.Code]struct{}, len(cs))
+		for _, c := range cs {
+			i.failureCodes[c] = struct{}{}
+		}
+	}
+}
+
+//WithStreamEstablishmentOnly makes streams ignore errors after the first message is received,
+//for long-lived streams whose eventual end says nothing about backend health
+func WithStreamEstablishmentOnly() Option {
+	return func(i *Interceptor) {
+		i.establishmentOnly = true
+	}
+}
+
+//Interceptor implements connect.Interceptor for both clients and handlers. calls are rejected with
+//connect.CodeUnavailable while the breaker of their procedure is open, failure codes are reported as errors
+type Interceptor struct {
+	breakerFor        func(procedure string) *breaker.CircuitBreaker
+	failureCodes      map[connect.Code]struct{}
+	establishmentOnly bool
+}
+
+var _ connect.Interceptor = (*Interceptor)(nil)
+
+//NewInterceptor return an interceptor guarding all procedures with cb
+func NewInterceptor(cb *breaker.CircuitBreaker, opts ...Option) *Interceptor {
+	return newInterceptor(func(string) *breaker.CircuitBreaker { return cb }, opts)
+}
+
+//NewGroupInterceptor return an interceptor with a circuit breaker per procedure from g, e.g. "/acme.foo.v1.FooService/Bar"
+func NewGroupInterceptor(g *breaker.Group, opts ...Option) *Interceptor {
+	return newInterceptor(g.Get, opts)
+}
+
+func newInterceptor(breakerFor func(procedure string) *breaker.CircuitBreaker, opts []Option) *Interceptor {
+	i := &Interceptor{
+		breakerFor: breakerFor,
+		failureCodes: map[connect.Code]struct{}{
+			connect.CodeUnavailable:      {},
+			connect.CodeDeadlineExceeded: {},
+		},
+	}
+
+	for _, opt := range opts {
+		opt(i)
+	}
+
+	return i
+}
+
+func (i *Interceptor) classify(err error) breaker.Outcome {
+	if err == nil || errors.Is(err, io.EOF) {
+		return breaker.OutcomeSuccess
+	}
+
+	if _, ok := i.failureCodes[connect.CodeOf(err)]; ok {
+		return breaker.OutcomeFailure
+	}
+
+	return breaker.OutcomeSuccess
+}
+
+//rejectError tells the peer to retry after the rest of the sleep window, grpc-retry-pushback-ms is honored by gRPC retry policies
+func rejectError(cb *breaker.CircuitBreaker, err error, isClient bool) error {
+	e := connect.NewError(connect.CodeUnavailable, fmt.Errorf("circuit breaker: %w", err))
+	if !isClient {
+		e.Meta().Set("grpc-retry-pushback-ms", strconv.FormatInt(cb.RemainingSleepWindow().Milliseconds(), 10))
+	}
+
+	return e
+}
+
+//WrapUnary implements connect.Interceptor
+func (i *Interceptor) WrapUnary(next connect.UnaryFunc) connect.UnaryFunc {
+	return func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+		spec := req.Spec()
+		cb := i.breakerFor(spec.Procedure)
+		if err := cb.ReportRequestWithMeta(breaker.RequestMeta{Method: spec.Procedure}); err != nil {
+			return nil, rejectError(cb, err, spec.IsClient)
+		}
+
+		start := time.Now()
+		resp, err := next(ctx, req)
+		cb.ReportLatency(time.Since(start))
+
+		if i.classify(err) == breaker.OutcomeFailure {
+			cb.ReportErrorContext(ctx)
+		}
+
+		return resp, err
+	}
+}
+
+//WrapStreamingClient implements connect.Interceptor. connect opens streams lazily, so a rejected stream
+//fails on its first Send or Receive, and the first failure of a stream is reported once
+func (i *Interceptor) WrapStreamingClient(next connect.StreamingClientFunc) connect.StreamingClientFunc {
+	return func(ctx context.Context, spec connect.Spec) connect.StreamingClientConn {
+		conn := next(ctx, spec)
+		cb := i.breakerFor(spec.Procedure)
+
+		s := &clientConn{StreamingClientConn: conn, ctx: ctx, cb: cb, i: i}
+		if err := cb.ReportRequestWithMeta(breaker.RequestMeta{Method: spec.Procedure}); err != nil {
+			s.rejected = rejectError(cb, err, true)
+		}
+
+		return s
+	}
+}
+
+type clientConn struct {
+	connect.StreamingClientConn
+	ctx      context.Context
+	cb       *breaker.CircuitBreaker
+	i        *Interceptor
+	rejected error
+
+	mu       sync.Mutex
+	received bool
+	reported bool
+}
+
+func (s *clientConn) Send(m any) error {
+	if s.rejected != nil {
+		return s.rejected
+	}
+
+	err := s.StreamingClientConn.Send(m)
+	s.report(err)
+	return err
+}
+
+func (s *clientConn) Receive(m any) error {
+	if s.rejected != nil {
+		return s.rejected
+	}
+
+	err := s.StreamingClientConn.Receive(m)
+	if err == nil {
+		s.mu.Lock()
+		s.received = true
+		s.mu.Unlock()
+	}
+	s.report(err)
+	return err
+}
+
+func (s *clientConn) report(err error) {
+	if s.i.classify(err) != breaker.OutcomeFailure {
+		return
+	}
+
+	s.mu.Lock()
+	skip := s.reported || (s.i.establishmentOnly && s.received)
+	s.reported = true
+	s.mu.Unlock()
+
+	if !skip {
+		s.cb.ReportErrorContext(s.ctx)
+	}
+}
+
+//WrapStreamingHandler implements connect.Interceptor, errors returned by handlers are reported
+//unless WithStreamEstablishmentOnly is given
+func (i *Interceptor) WrapStreamingHandler(next connect.StreamingHandlerFunc) connect.StreamingHandlerFunc {
+	return func(ctx context.Context, conn connect.StreamingHandlerConn) error {
+		procedure := conn.Spec().Procedure
+		cb := i.breakerFor(procedure)
+		if err := cb.ReportRequestWithMeta(breaker.RequestMeta{Method: procedure}); err != nil {
+			return rejectError(cb, err, false)
+		}
+
+		err := next(ctx, conn)
+		if !i.establishmentOnly && i.classify(err) == breaker.OutcomeFailure {
+			cb.ReportErrorContext(ctx)
+		}
+
+		return err
+	}
+}
@@ -0,0 +1,92 @@
+package breakerconsul
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/carl-leopard/circuitbreaker/breaker"
+	"github.com/hashicorp/consul/api"
+)
+
+const defaultConfigPrefix = "circuitbreaker-config/"
+
+//WithConfigPrefix sets prefix of settings keys, default "circuitbreaker-config/"
+func WithConfigPrefix(prefix string) Option {
+	return func(s *State) {
+		s.configPrefix = prefix
+	}
+}
+
+//PublishSettings stores settings of circuit breaker bs.Name for WatchConfig of every instance,
+//fields left zero keep the value of each instance
+func (s *State) PublishSettings(ctx context.Context, bs breaker.Settings) error {
+	v, err := json.Marshal(bs)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.client.KV().Put(&api.KVPair{Key: s.configPrefix + bs.Name, Value: v}, (&api.WriteOptions{}).WithContext(ctx))
+	return err
+}
+
+//WatchConfig applies settings stored under the config prefix to circuit breakers of r as they change, see
+//breaker.Registry.OverrideConfig, and calls fn, if not nil, with every change. the key of the settings of a
+//circuit breaker is the prefix followed by its name, its value JSON of breaker.Settings.
+//deleting a key leaves circuit breakers as they are, invalid values are logged and skipped. circuit breakers
+//registered after a key is applied only pick up its next change
+func (s *State) WatchConfig(ctx context.Context, r *breaker.Registry, fn func(breaker.ConfigChange)) error {
+	var index uint64
+	applied := make(map[string]uint64) //key => modify index applied
+	for {
+		qo := (&api.QueryOptions{WaitIndex: index, WaitTime: s.propagationDelay}).WithContext(ctx)
+		pairs, meta, err := s.client.KV().List(s.configPrefix, qo)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+
+			//consul unavailable, retry after a while
+			select {
+			case <-time.After(s.propagationDelay):
+				continue
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+
+		for _, p := range pairs {
+			if applied[p.Key] == p.ModifyIndex {
+				continue
+			}
+			applied[p.Key] = p.ModifyIndex
+
+			var bs breaker.Settings
+			if err := json.Unmarshal(p.Value, &bs); err != nil {
+				log.Printf("circuit breaker settings %s: %v", p.Key, err)
+				continue
+			}
+			bs.Name = strings.TrimPrefix(p.Key, s.configPrefix)
+
+			changes, err := r.OverrideConfig(bs)
+			if err != nil {
+				log.Printf("circuit breaker settings %s: %v", p.Key, err)
+			}
+			if fn != nil {
+				for _, ch := range changes {
+					fn(ch)
+				}
+			}
+		}
+
+		if meta.LastIndex < index {
+			//index went backwards, e.g. consul restored, start over
+			index = 0
+			applied = make(map[string]uint64)
+			continue
+		}
+		index = meta.LastIndex
+	}
+}
@@ -0,0 +1,61 @@
+package breakerconsul
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/carl-leopard/circuitbreaker/breaker"
+	"github.com/hashicorp/consul/api"
+)
+
+//HealthCheck registers a TTL check of service on the local agent, which is critical while any of cbs is open,
+//and updates it every interval until ctx is done. the check is deregistered on return
+func HealthCheck(ctx context.Context, client *api.Client, checkID, serviceID string, interval time.Duration, cbs ...*breaker.CircuitBreaker) error {
+	agent := client.Agent()
+
+	err := agent.CheckRegister(&api.AgentCheckRegistration{
+		ID:        checkID,
+		Name:      "circuit breakers",
+		ServiceID: serviceID,
+		AgentServiceCheck: api.AgentServiceCheck{
+			//agent marks the check critical by itself if this process stops updating it
+			TTL: (3 * interval).String(),
+		},
+	})
+	if err != nil {
+		return err
+	}
+	defer agent.CheckDeregister(checkID)
+
+	t := time.NewTicker(interval)
+	defer t.Stop()
+
+	for {
+		status, output := checkStatus(cbs)
+		if err := agent.UpdateTTLOpts(checkID, output, status, (&api.QueryOptions{}).WithContext(ctx)); err != nil && ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func checkStatus(cbs []*breaker.CircuitBreaker) (string, string) {
+	var open []string
+	for _, cb := range cbs {
+		if cb.Status() == breaker.CircuitBreakerStatusOpen {
+			open = append(open, cb.Name())
+		}
+	}
+
+	if len(open) > 0 {
+		return api.HealthCritical, "open circuit breakers: " + strings.Join(open, ", ")
+	}
+
+	return api.HealthPassing, "all circuit breakers allow requests"
+}
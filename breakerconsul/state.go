@@ -0,0 +1,152 @@
+package breakerconsul
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/carl-leopard/circuitbreaker/breaker"
+	"github.com/hashicorp/consul/api"
+)
+
+const (
+	defaultPrefix           = "circuitbreaker/"
+	defaultPropagationDelay = 5 * time.Second
+
+	minSessionTTL = 10 * time.Second //smallest TTL consul accepts
+)
+
+type Option func(s *State)
+
+//WithPrefix sets prefix of consul keys, default "circuitbreaker/"
+func WithPrefix(prefix string) Option {
+	return func(s *State) {
+		s.prefix = prefix
+	}
+}
+
+//WithInstance sets id of this instance, default hostname-pid
+func WithInstance(id string) Option {
+	return func(s *State) {
+		s.instance = id
+	}
+}
+
+//WithPropagationDelay sets wait time of blocking queries, which bounds how late peers learn about a trip
+//when changes are not delivered. default 5s
+func WithPropagationDelay(d time.Duration) Option {
+	return func(s *State) {
+		if d > 0 {
+			s.propagationDelay = d
+		}
+	}
+}
+
+//State implements breaker.Store in consul KV, it shares window counts, publishes trips of circuit breakers and
+//watches trips of peers by blocking queries. a trip is a key of the breaker name held by a session with the
+//sleep window as TTL, which deletes it on expiry. use it with breaker.WithStore, or
+//breaker.WithTransitionPublisher, and breaker.FollowPeers
+type State struct {
+	client           *api.Client
+	prefix           string
+	windowPrefix     string
+	configPrefix     string
+	instance         string
+	propagationDelay time.Duration
+}
+
+var _ breaker.Store = (*State)(nil)
+
+//New return state stored in consul by client
+func New(client *api.Client, opts ...Option) *State {
+	host, _ := os.Hostname()
+	s := &State{
+		client:           client,
+		prefix:           defaultPrefix,
+		windowPrefix:     defaultWindowPrefix,
+		configPrefix:     defaultConfigPrefix,
+		instance:         fmt.Sprintf("%s-%d", host, os.Getpid()),
+		propagationDelay: defaultPropagationDelay,
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+//PublishTransition implements breaker.TransitionPublisher, only trips are stored.
+//nothing is written if a peer holds the key of the breaker already
+func (s *State) PublishTransition(ctx context.Context, t breaker.Transition) error {
+	if t.To != breaker.CircuitBreakerStatusOpen {
+		return nil
+	}
+
+	t.Instance = s.instance
+	v, err := json.Marshal(t)
+	if err != nil {
+		return err
+	}
+
+	ttl := time.Until(t.Until)
+	if ttl < minSessionTTL {
+		ttl = minSessionTTL
+	}
+
+	wo := (&api.WriteOptions{}).WithContext(ctx)
+	session, _, err := s.client.Session().Create(&api.SessionEntry{
+		Name:     "circuitbreaker " + t.Name,
+		TTL:      ttl.Truncate(time.Second).String(),
+		Behavior: api.SessionBehaviorDelete,
+	}, wo)
+	if err != nil {
+		return err
+	}
+
+	acquired, _, err := s.client.KV().Acquire(&api.KVPair{Key: s.prefix + t.Name, Value: v, Session: session}, wo)
+	if err != nil || !acquired {
+		s.client.Session().Destroy(session, wo)
+	}
+
+	return err
+}
+
+//WatchTransitions implements breaker.TransitionWatcher
+func (s *State) WatchTransitions(ctx context.Context, fn func(breaker.Transition)) error {
+	var index uint64
+	for {
+		qo := (&api.QueryOptions{WaitIndex: index, WaitTime: s.propagationDelay}).WithContext(ctx)
+		pairs, meta, err := s.client.KV().List(s.prefix, qo)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+
+			//consul unavailable, retry after a while
+			select {
+			case <-time.After(s.propagationDelay):
+				continue
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+
+		for _, p := range pairs {
+			var t breaker.Transition
+			if err := json.Unmarshal(p.Value, &t); err != nil || t.Instance == s.instance {
+				continue
+			}
+			fn(t)
+		}
+
+		if meta.LastIndex < index {
+			//index went backwards, e.g. consul restored, start over
+			index = 0
+			continue
+		}
+		index = meta.LastIndex
+	}
+}
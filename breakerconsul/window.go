@@ -0,0 +1,94 @@
+package breakerconsul
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/carl-leopard/circuitbreaker/breaker"
+	"github.com/hashicorp/consul/api"
+)
+
+const defaultWindowPrefix = "circuitbreaker-windows/"
+
+//WithWindowPrefix sets prefix of window keys, default "circuitbreaker-windows/". it must not be under the
+//prefix of trips, or watchers wake up on every count
+func WithWindowPrefix(prefix string) Option {
+	return func(s *State) {
+		s.windowPrefix = prefix
+	}
+}
+
+//window value of the window key of a breaker, a newer window replaces an older one
+type window struct {
+	Start    int64  `json:"start"` //unix nano
+	Requests uint32 `json:"requests"`
+	Errors   uint32 `json:"errors"`
+}
+
+//IncrWindow implements breaker.WindowCounter. every breaker has one key holding its latest window,
+//updated by check-and-set on its modify index, so updates retry under contention
+func (s *State) IncrWindow(ctx context.Context, name string, start time.Time, requests, errors uint32) (breaker.Window, error) {
+	key := s.windowPrefix + name
+	for {
+		p, _, err := s.client.KV().Get(key, (&api.QueryOptions{}).WithContext(ctx))
+		if err != nil {
+			return breaker.Window{}, err
+		}
+
+		w := window{Start: start.UnixNano()}
+		var index uint64
+		if p != nil {
+			var cur window
+			if json.Unmarshal(p.Value, &cur) == nil {
+				if cur.Start > w.Start {
+					//a peer moved on to the next window already, counts of a past one are not kept
+					return breaker.Window{Start: start, Requests: requests, Errors: errors}, nil
+				}
+				if cur.Start == w.Start {
+					w = cur
+				}
+			}
+			index = p.ModifyIndex
+		}
+		w.Requests = breaker.SaturatedCount(uint64(w.Requests) + uint64(requests))
+		w.Errors = breaker.SaturatedCount(uint64(w.Errors) + uint64(errors))
+
+		v, err := json.Marshal(w)
+		if err != nil {
+			return breaker.Window{}, err
+		}
+
+		//index 0 only creates the key
+		ok, _, err := s.client.KV().CAS(&api.KVPair{Key: key, Value: v, ModifyIndex: index}, (&api.WriteOptions{}).WithContext(ctx))
+		if err != nil {
+			return breaker.Window{}, err
+		}
+		if ok {
+			return breaker.Window{Start: start, Requests: w.Requests, Errors: w.Errors}, nil
+		}
+	}
+}
+
+//ReadWindow implements breaker.WindowReader
+func (s *State) ReadWindow(ctx context.Context, name string, start time.Time) (breaker.Window, error) {
+	p, _, err := s.client.KV().Get(s.windowPrefix+name, (&api.QueryOptions{}).WithContext(ctx))
+	if err != nil {
+		return breaker.Window{}, err
+	}
+
+	out := breaker.Window{Start: start}
+	if p == nil {
+		return out, nil
+	}
+
+	var w window
+	if err := json.Unmarshal(p.Value, &w); err != nil {
+		return out, err
+	}
+	if w.Start == start.UnixNano() {
+		out.Requests, out.Errors = w.Requests, w.Errors
+	}
+
+	return out, nil
+}
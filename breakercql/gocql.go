@@ -0,0 +1,103 @@
+package breakercql
+
+import (
+	"context"
+	"errors"
+	"net"
+	"time"
+
+	"github.com/carl-leopard/circuitbreaker/breaker"
+	"github.com/gocql/gocql"
+)
+
+//IsFailure treats timeouts, unavailable replicas and broken connections as failures of the coordinator,
+//other errors like invalid queries are answered by a healthy host
+func IsFailure(err error) bool {
+	var (
+		unavailable  *gocql.RequestErrUnavailable
+		writeTimeout *gocql.RequestErrWriteTimeout
+		readTimeout  *gocql.RequestErrReadTimeout
+		netErr       net.Error
+	)
+
+	switch {
+	case err == nil, errors.Is(err, context.Canceled):
+		return false
+	case errors.Is(err, gocql.ErrTimeoutNoResponse),
+		errors.Is(err, gocql.ErrConnectionClosed),
+		errors.Is(err, context.DeadlineExceeded),
+		errors.As(err, &unavailable),
+		errors.As(err, &writeTimeout),
+		errors.As(err, &readTimeout),
+		errors.As(err, &netErr):
+		return true
+	default:
+		return false
+	}
+}
+
+func hostKey(h *gocql.HostInfo) string {
+	return h.ConnectAddressAndPort()
+}
+
+//Observer is a gocql.QueryObserver and gocql.BatchObserver feeding every attempt into the breaker
+//of its host. set it as both QueryObserver and BatchObserver of the cluster config
+type Observer struct {
+	g *breaker.Group
+}
+
+//NewObserver return an observer keyed by host address from g
+func NewObserver(g *breaker.Group) *Observer {
+	return &Observer{g: g}
+}
+
+func (o *Observer) ObserveQuery(ctx context.Context, q gocql.ObservedQuery) {
+	o.observe(ctx, q.Host, q.End.Sub(q.Start), q.Err)
+}
+
+func (o *Observer) ObserveBatch(ctx context.Context, b gocql.ObservedBatch) {
+	o.observe(ctx, b.Host, b.End.Sub(b.Start), b.Err)
+}
+
+func (o *Observer) observe(ctx context.Context, host *gocql.HostInfo, latency time.Duration, err error) {
+	if host == nil {
+		return
+	}
+
+	cb := o.g.Get(hostKey(host))
+	cb.ReportRequest()
+	cb.ReportLatency(latency)
+
+	if IsFailure(err) {
+		cb.ReportErrorContext(ctx)
+	}
+}
+
+//HostSelectionPolicy wraps a policy and skips hosts whose breaker in g is open when picking coordinators
+type HostSelectionPolicy struct {
+	gocql.HostSelectionPolicy
+
+	g *breaker.Group
+}
+
+//NewHostSelectionPolicy wraps next, e.g. gocql.TokenAwareHostPolicy(gocql.RoundRobinHostPolicy())
+func NewHostSelectionPolicy(next gocql.HostSelectionPolicy, g *breaker.Group) *HostSelectionPolicy {
+	return &HostSelectionPolicy{HostSelectionPolicy: next, g: g}
+}
+
+func (p *HostSelectionPolicy) Pick(q gocql.ExecutableQuery) gocql.NextHost {
+	next := p.HostSelectionPolicy.Pick(q)
+
+	return func() gocql.SelectedHost {
+		for {
+			h := next()
+			if h == nil {
+				return nil
+			}
+
+			if p.g.Get(hostKey(h.Info())).Status() != breaker.CircuitBreakerStatusOpen {
+				return h
+			}
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package breakercrdt
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/carl-leopard/circuitbreaker/breaker"
+)
+
+//Counts cumulative requests and errors of a circuit breaker by instance
+type Counts struct {
+	Requests GCounter `json:"requests"`
+	Errors   GCounter `json:"errors"`
+}
+
+//State counts by circuit breaker name, what replicas exchange
+type State map[string]Counts
+
+//WindowCounter implements breaker.WindowCounter on grow-only counters instead of a shared store.
+//every instance only adds to its own entries and replicas are merged by the transport of choice, e.g. gossip,
+//so counts survive partitions and are merged without coordination once peers meet again.
+//
+//windows are measured on the local clock: the window returned is the growth of the fleet-wide totals since
+//the first flush of the local window, so instances do not need to agree on time. counts merged late are
+//attributed to the window they arrive in
+type WindowCounter struct {
+	instance string
+
+	mu     sync.Mutex
+	counts State
+	bases  map[string]base
+}
+
+//base fleet-wide totals at the start of the local window
+type base struct {
+	start    time.Time
+	requests uint64
+	errors   uint64
+}
+
+var _ breaker.WindowCounter = (*WindowCounter)(nil)
+
+//New return a counter identified by instance. instance must be unique per process run, e.g. hostname and pid,
+//since replicas ignore entries lower than what they have seen of it
+func New(instance string) *WindowCounter {
+	return &WindowCounter{
+		instance: instance,
+		counts:   make(State),
+		bases:    make(map[string]base),
+	}
+}
+
+//IncrWindow implements breaker.WindowCounter
+func (wc *WindowCounter) IncrWindow(_ context.Context, name string, start time.Time, requests, errors uint32) (breaker.Window, error) {
+	wc.mu.Lock()
+	defer wc.mu.Unlock()
+
+	c := wc.countsOf(name)
+	b, ok := wc.bases[name]
+	if !ok || !b.start.Equal(start) {
+		b = base{start: start, requests: c.Requests.Value(), errors: c.Errors.Value()}
+		wc.bases[name] = b
+	}
+
+	c.Requests.Incr(wc.instance, uint64(requests))
+	c.Errors.Incr(wc.instance, uint64(errors))
+
+	return breaker.Window{
+		Start:    start,
+		Requests: breaker.SaturatedCount(c.Requests.Value() - b.requests),
+		Errors:   breaker.SaturatedCount(c.Errors.Value() - b.errors),
+	}, nil
+}
+
+func (wc *WindowCounter) countsOf(name string) Counts {
+	c, ok := wc.counts[name]
+	if !ok {
+		c = Counts{Requests: make(GCounter), Errors: make(GCounter)}
+		wc.counts[name] = c
+	}
+
+	return c
+}
+
+//State returns a copy of the counts, to send to replicas
+func (wc *WindowCounter) State() State {
+	wc.mu.Lock()
+	defer wc.mu.Unlock()
+
+	s := make(State, len(wc.counts))
+	for name, c := range wc.counts {
+		s[name] = Counts{Requests: c.Requests.Clone(), Errors: c.Errors.Clone()}
+	}
+
+	return s
+}
+
+//Merge merges counts received from a replica
+func (wc *WindowCounter) Merge(s State) {
+	wc.mu.Lock()
+	defer wc.mu.Unlock()
+
+	for name, c := range s {
+		local := wc.countsOf(name)
+		local.Requests.Merge(c.Requests)
+		local.Errors.Merge(c.Errors)
+	}
+}
+
+//Encode returns the counts as JSON, e.g. for memberlist.Delegate.LocalState
+func (wc *WindowCounter) Encode() ([]byte, error) {
+	return json.Marshal(wc.State())
+}
+
+//MergeEncoded merges counts encoded by Encode of a replica
+func (wc *WindowCounter) MergeEncoded(b []byte) error {
+	var s State
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+
+	wc.Merge(s)
+	return nil
+}
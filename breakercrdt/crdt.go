@@ -0,0 +1,71 @@
+package breakercrdt
+
+//GCounter grow-only counter, one entry per instance. instances only increase their own entry and
+//replicas converge by merging in any order, any number of times
+type GCounter map[string]uint64
+
+//Incr adds n to the entry of instance
+func (g GCounter) Incr(instance string, n uint64) {
+	g[instance] += n
+}
+
+//Value returns the sum of all entries
+func (g GCounter) Value() uint64 {
+	var v uint64
+	for _, n := range g {
+		v += n
+	}
+
+	return v
+}
+
+//Merge keeps the larger entry of each instance of g and o
+func (g GCounter) Merge(o GCounter) {
+	for instance, n := range o {
+		if n > g[instance] {
+			g[instance] = n
+		}
+	}
+}
+
+//Clone returns a copy of g
+func (g GCounter) Clone() GCounter {
+	c := make(GCounter, len(g))
+	for instance, n := range g {
+		c[instance] = n
+	}
+
+	return c
+}
+
+//PNCounter counter supporting decrements as a pair of grow-only counters, e.g. for in-flight requests
+type PNCounter struct {
+	P GCounter `json:"p"`
+	N GCounter `json:"n"`
+}
+
+//NewPNCounter return an empty counter
+func NewPNCounter() *PNCounter {
+	return &PNCounter{P: make(GCounter), N: make(GCounter)}
+}
+
+//Incr adds n to the counter of instance
+func (c *PNCounter) Incr(instance string, n uint64) {
+	c.P.Incr(instance, n)
+}
+
+//Decr subtracts n from the counter of instance
+func (c *PNCounter) Decr(instance string, n uint64) {
+	c.N.Incr(instance, n)
+}
+
+//Value returns increments minus decrements of all instances
+func (c *PNCounter) Value() int64 {
+	return int64(c.P.Value() - c.N.Value())
+}
+
+//Merge merges o into c
+func (c *PNCounter) Merge(o *PNCounter) {
+	c.P.Merge(o.P)
+	c.N.Merge(o.N)
+}
@@ -0,0 +1,256 @@
+package breakerdynamodb
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/carl-leopard/circuitbreaker/breaker"
+)
+
+const (
+	defaultPollInterval = 5 * time.Second
+	defaultWindowTTL    = 15 * time.Minute
+
+	tripsPartition = "trips"
+	windowPrefix   = "window#"
+)
+
+//API is the part of *dynamodb.Client used by Store
+type API interface {
+	UpdateItem(ctx context.Context, in *dynamodb.UpdateItemInput, opts ...func(*dynamodb.Options)) (*dynamodb.UpdateItemOutput, error)
+	GetItem(ctx context.Context, in *dynamodb.GetItemInput, opts ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error)
+	PutItem(ctx context.Context, in *dynamodb.PutItemInput, opts ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error)
+	Query(ctx context.Context, in *dynamodb.QueryInput, opts ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error)
+}
+
+type Option func(s *Store)
+
+//WithInstance sets id of this instance, default hostname-pid
+func WithInstance(id string) Option {
+	return func(s *Store) {
+		s.instance = id
+	}
+}
+
+//WithPollInterval sets how often trips of peers are read, which bounds how late peers learn about a trip. default 5s
+func WithPollInterval(d time.Duration) Option {
+	return func(s *Store) {
+		if d > 0 {
+			s.pollInterval = d
+		}
+	}
+}
+
+//WithWindowTTL sets how long a window is kept, it must be longer than the refresh interval. default 15m
+func WithWindowTTL(t time.Duration) Option {
+	return func(s *Store) {
+		if t > 0 {
+			s.windowTTL = t
+		}
+	}
+}
+
+//Store implements breaker.Store in a DynamoDB table with a string partition key "pk", a string sort key "sk"
+//and time to live enabled on the number attribute "ttl".
+//
+//a window is an item pk=window#<name> sk=<unix start> whose counts are added atomically by UpdateItem.
+//a trip is an item pk=trips sk=<name> written by a conditional put that keeps a peer's trip still in its
+//sleep window. all trips are in one partition, read by Query every poll interval. since DynamoDB deletes
+//expired items late, trips are filtered by the end of their sleep window.
+//use it with breaker.WithStore and breaker.FollowPeers
+type Store struct {
+	api          API
+	table        string
+	instance     string
+	pollInterval time.Duration
+	windowTTL    time.Duration
+}
+
+var _ breaker.Store = (*Store)(nil)
+
+//New return a store in table by api, e.g. *dynamodb.Client
+func New(api API, table string, opts ...Option) *Store {
+	host, _ := os.Hostname()
+	s := &Store{
+		api:          api,
+		table:        table,
+		instance:     fmt.Sprintf("%s-%d", host, os.Getpid()),
+		pollInterval: defaultPollInterval,
+		windowTTL:    defaultWindowTTL,
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+func str(v string) types.AttributeValue {
+	return &types.AttributeValueMemberS{Value: v}
+}
+
+func num(v int64) types.AttributeValue {
+	return &types.AttributeValueMemberN{Value: strconv.FormatInt(v, 10)}
+}
+
+func numOf(item map[string]types.AttributeValue, name string) int64 {
+	n, ok := item[name].(*types.AttributeValueMemberN)
+	if !ok {
+		return 0
+	}
+
+	v, _ := strconv.ParseInt(n.Value, 10, 64)
+	return v
+}
+
+func windowKey(name string, start time.Time) map[string]types.AttributeValue {
+	return map[string]types.AttributeValue{
+		"pk": str(windowPrefix + name),
+		"sk": str(strconv.FormatInt(start.Unix(), 10)),
+	}
+}
+
+//IncrWindow implements breaker.WindowCounter
+func (s *Store) IncrWindow(ctx context.Context, name string, start time.Time, requests, errors uint32) (breaker.Window, error) {
+	out, err := s.api.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName:                aws.String(s.table),
+		Key:                      windowKey(name, start),
+		UpdateExpression:         aws.String("ADD requests :r, errors :e SET #ttl = :ttl"),
+		ExpressionAttributeNames: map[string]string{"#ttl": "ttl"},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":r":   num(int64(requests)),
+			":e":   num(int64(errors)),
+			":ttl": num(start.Add(s.windowTTL).Unix()),
+		},
+		ReturnValues: types.ReturnValueUpdatedNew,
+	})
+	if err != nil {
+		return breaker.Window{}, err
+	}
+
+	return breaker.Window{
+		Start:    start,
+		Requests: breaker.SaturatedCount(uint64(numOf(out.Attributes, "requests"))),
+		Errors:   breaker.SaturatedCount(uint64(numOf(out.Attributes, "errors"))),
+	}, nil
+}
+
+//ReadWindow implements breaker.WindowReader
+func (s *Store) ReadWindow(ctx context.Context, name string, start time.Time) (breaker.Window, error) {
+	out, err := s.api.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName:      aws.String(s.table),
+		Key:            windowKey(name, start),
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return breaker.Window{}, err
+	}
+
+	return breaker.Window{
+		Start:    start,
+		Requests: breaker.SaturatedCount(uint64(numOf(out.Item, "requests"))),
+		Errors:   breaker.SaturatedCount(uint64(numOf(out.Item, "errors"))),
+	}, nil
+}
+
+//PublishTransition implements breaker.TransitionPublisher, only trips are stored.
+//nothing is written if a peer's trip of the breaker is still in its sleep window
+func (s *Store) PublishTransition(ctx context.Context, t breaker.Transition) error {
+	if t.To != breaker.CircuitBreakerStatusOpen {
+		return nil
+	}
+
+	t.Instance = s.instance
+	v, err := json.Marshal(t)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.api.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(s.table),
+		Item: map[string]types.AttributeValue{
+			"pk":         str(tripsPartition),
+			"sk":         str(t.Name),
+			"transition": str(string(v)),
+			"until":      num(t.Until.UnixMilli()),
+			"ttl":        num(t.Until.Unix() + 1),
+		},
+		ConditionExpression:       aws.String("attribute_not_exists(pk) OR #until < :now"),
+		ExpressionAttributeNames:  map[string]string{"#until": "until"},
+		ExpressionAttributeValues: map[string]types.AttributeValue{":now": num(time.Now().UnixMilli())},
+	})
+
+	var ccf *types.ConditionalCheckFailedException
+	if errors.As(err, &ccf) {
+		return nil
+	}
+
+	return err
+}
+
+//WatchTransitions implements breaker.TransitionWatcher. every trip is passed once,
+//failed reads, e.g. throttled ones, are retried at the next poll
+func (s *Store) WatchTransitions(ctx context.Context, fn func(breaker.Transition)) error {
+	t := time.NewTicker(s.pollInterval)
+	defer t.Stop()
+
+	seen := make(map[string]time.Time) //breaker name => time of the trip passed last
+	for {
+		s.poll(ctx, seen, fn)
+
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func (s *Store) poll(ctx context.Context, seen map[string]time.Time, fn func(breaker.Transition)) error {
+	in := &dynamodb.QueryInput{
+		TableName:                aws.String(s.table),
+		KeyConditionExpression:   aws.String("pk = :pk"),
+		FilterExpression:         aws.String("#until > :now"),
+		ExpressionAttributeNames: map[string]string{"#until": "until"},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":pk":  str(tripsPartition),
+			":now": num(time.Now().UnixMilli()),
+		},
+	}
+
+	p := dynamodb.NewQueryPaginator(s.api, in)
+	for p.HasMorePages() {
+		out, err := p.NextPage(ctx)
+		if err != nil {
+			return err
+		}
+
+		for _, item := range out.Items {
+			v, ok := item["transition"].(*types.AttributeValueMemberS)
+			if !ok {
+				continue
+			}
+
+			var t breaker.Transition
+			if err := json.Unmarshal([]byte(v.Value), &t); err != nil || t.Instance == s.instance {
+				continue
+			}
+			if last, ok := seen[t.Name]; ok && last.Equal(t.Time) {
+				continue
+			}
+			seen[t.Name] = t.Time
+			fn(t)
+		}
+	}
+
+	return nil
+}
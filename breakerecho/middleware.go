@@ -0,0 +1,118 @@
+package breakerecho
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/carl-leopard/circuitbreaker/breaker"
+	"github.com/labstack/echo/v4"
+)
+
+//RejectBody is the JSON body of the 503 response while the breaker of a route is open
+type RejectBody struct {
+	Error      string `json:"error"`
+	Breaker    string `json:"breaker,omitempty"`
+	RetryAfter int    `json:"retry_after"` //seconds
+}
+
+type Option func(m *middleware)
+
+//WithStatusClassifier decides by status code whether a handled request counts as a failure, default 5xx
+func WithStatusClassifier(f func(code int) breaker.Outcome) Option {
+	return func(m *middleware) {
+		if f != nil {
+			m.classify = f
+		}
+	}
+}
+
+type middleware struct {
+	breakerFor func(c echo.Context) *breaker.CircuitBreaker
+	classify   func(code int) breaker.Outcome
+}
+
+//Middleware sheds requests with 503 and a RejectBody while cb is open. handler errors and responses
+//are reported as errors if their status is classified as failure
+func Middleware(cb *breaker.CircuitBreaker, opts ...Option) echo.MiddlewareFunc {
+	return newMiddleware(func(echo.Context) *breaker.CircuitBreaker { return cb }, opts)
+}
+
+//GroupMiddleware works like Middleware with a circuit breaker per route from g, keyed like "GET /users/:id".
+//requests not matching any route are not guarded
+func GroupMiddleware(g *breaker.Group, opts ...Option) echo.MiddlewareFunc {
+	return newMiddleware(func(c echo.Context) *breaker.CircuitBreaker {
+		route := c.Path()
+		if route == "" {
+			return nil
+		}
+		return g.Get(c.Request().Method + " " + route)
+	}, opts)
+}
+
+func newMiddleware(breakerFor func(c echo.Context) *breaker.CircuitBreaker, opts []Option) echo.MiddlewareFunc {
+	m := &middleware{
+		breakerFor: breakerFor,
+		classify: func(code int) breaker.Outcome {
+			if code >= http.StatusInternalServerError {
+				return breaker.OutcomeFailure
+			}
+			return breaker.OutcomeSuccess
+		},
+	}
+
+	for _, opt := range opts {
+		opt(m)
+	}
+
+	return m.wrap
+}
+
+func (m *middleware) wrap(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		cb := m.breakerFor(c)
+		if cb == nil {
+			return next(c)
+		}
+
+		if err := cb.ReportRequest(); err != nil {
+			//rest of the sleep window, rounded up
+			secs := int((cb.RemainingSleepWindow() + time.Second - 1) / time.Second)
+			if secs < 1 {
+				secs = 1
+			}
+
+			c.Response().Header().Set("Retry-After", strconv.Itoa(secs))
+			return c.JSON(http.StatusServiceUnavailable, RejectBody{
+				Error:      err.Error(),
+				Breaker:    cb.Name(),
+				RetryAfter: secs,
+			})
+		}
+
+		start := time.Now()
+		err := next(c)
+		cb.ReportLatency(time.Since(start))
+
+		if m.classify(status(c, err)) == breaker.OutcomeFailure {
+			cb.ReportErrorContext(c.Request().Context())
+		}
+
+		return err
+	}
+}
+
+//status of the response, errors are turned into responses by echo only after middlewares return
+func status(c echo.Context, err error) int {
+	if err == nil {
+		return c.Response().Status
+	}
+
+	var he *echo.HTTPError
+	if errors.As(err, &he) {
+		return he.Code
+	}
+
+	return http.StatusInternalServerError
+}
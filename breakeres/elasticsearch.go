@@ -0,0 +1,36 @@
+package breakeres
+
+import (
+	"errors"
+	"net/url"
+
+	"github.com/carl-leopard/circuitbreaker/breaker"
+	"github.com/elastic/elastic-transport-go/v8/elastictransport"
+)
+
+var errNoConnection = errors.New("no connection available")
+
+//Selector return an elastictransport.Selector skipping nodes whose breaker in g is open,
+//the remaining are picked by next, round robin if nil
+func Selector(g *breaker.Group, next elastictransport.Selector) elastictransport.Selector {
+	return &esSelector{g: g, next: next}
+}
+
+type esSelector struct {
+	g    *breaker.Group
+	next elastictransport.Selector
+	rr   roundRobin
+}
+
+func (s *esSelector) Select(conns []*elastictransport.Connection) (*elastictransport.Connection, error) {
+	conns = available(s.g, conns, func(c *elastictransport.Connection) *url.URL { return c.URL })
+	if s.next != nil {
+		return s.next.Select(conns)
+	}
+
+	if len(conns) == 0 {
+		return nil, errNoConnection
+	}
+
+	return conns[s.rr.index(len(conns))], nil
+}
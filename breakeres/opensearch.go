@@ -0,0 +1,32 @@
+package breakeres
+
+import (
+	"net/url"
+
+	"github.com/carl-leopard/circuitbreaker/breaker"
+	"github.com/opensearch-project/opensearch-go/v4/opensearchtransport"
+)
+
+//OpenSearchSelector works like Selector for the OpenSearch client
+func OpenSearchSelector(g *breaker.Group, next opensearchtransport.Selector) opensearchtransport.Selector {
+	return &osSelector{g: g, next: next}
+}
+
+type osSelector struct {
+	g    *breaker.Group
+	next opensearchtransport.Selector
+	rr   roundRobin
+}
+
+func (s *osSelector) Select(conns []*opensearchtransport.Connection) (*opensearchtransport.Connection, error) {
+	conns = available(s.g, conns, func(c *opensearchtransport.Connection) *url.URL { return c.URL })
+	if s.next != nil {
+		return s.next.Select(conns)
+	}
+
+	if len(conns) == 0 {
+		return nil, errNoConnection
+	}
+
+	return conns[s.rr.index(len(conns))], nil
+}
@@ -0,0 +1,43 @@
+package breakeres
+
+import (
+	"net/http"
+	"net/url"
+	"sync/atomic"
+
+	"github.com/carl-leopard/circuitbreaker/breaker"
+	"github.com/carl-leopard/circuitbreaker/breakerhttp"
+)
+
+//Transport return a transport with a circuit breaker per node (host:port) from g. 5xx, 429 and network
+//errors count as failures. use it as Transport of the Elasticsearch/OpenSearch client config together
+//with the Selector of the same group, so open nodes are skipped
+func Transport(g *breaker.Group, next http.RoundTripper, opts ...breakerhttp.TransportOption) http.RoundTripper {
+	opts = append([]breakerhttp.TransportOption{breakerhttp.WithHostPortKey()}, opts...)
+	return breakerhttp.NewGroupTransport(g, next, opts...)
+}
+
+//available keeps connections whose breaker is not open. all connections are kept if every breaker is open,
+//the transport then fails fast on its own
+func available[C any](g *breaker.Group, conns []C, urlOf func(C) *url.URL) []C {
+	out := make([]C, 0, len(conns))
+	for _, c := range conns {
+		if g.Get(urlOf(c).Host).Status() != breaker.CircuitBreakerStatusOpen {
+			out = append(out, c)
+		}
+	}
+
+	if len(out) == 0 {
+		return conns
+	}
+
+	return out
+}
+
+type roundRobin struct {
+	next uint64
+}
+
+func (r *roundRobin) index(n int) int {
+	return int((atomic.AddUint64(&r.next, 1) - 1) % uint64(n))
+}
@@ -0,0 +1,96 @@
+package breakeretcd
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"strings"
+
+	"github.com/carl-leopard/circuitbreaker/breaker"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+const defaultConfigPrefix = "/circuitbreaker-config/"
+
+//WithConfigPrefix sets prefix of settings keys, default "/circuitbreaker-config/"
+func WithConfigPrefix(prefix string) Option {
+	return func(s *State) {
+		s.configPrefix = prefix
+	}
+}
+
+//PublishSettings stores settings of circuit breaker bs.Name for WatchConfig of every instance,
+//fields left zero keep the value of each instance
+func (s *State) PublishSettings(ctx context.Context, bs breaker.Settings) error {
+	v, err := json.Marshal(bs)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.cli.Put(ctx, s.configPrefix+bs.Name, string(v))
+	return err
+}
+
+//WatchConfig applies settings stored under the config prefix to circuit breakers of r as they change, see
+//breaker.Registry.OverrideConfig, and calls fn, if not nil, with every change. the key of the settings of a
+//circuit breaker is the prefix followed by its name, its value JSON of breaker.Settings.
+//deleting a key leaves circuit breakers as they are, invalid values are logged and skipped. circuit breakers
+//registered after a key is applied only pick up its next change
+func (s *State) WatchConfig(ctx context.Context, r *breaker.Registry, fn func(breaker.ConfigChange)) error {
+	apply := func(v []byte, key string) {
+		var bs breaker.Settings
+		if err := json.Unmarshal(v, &bs); err != nil {
+			log.Printf("circuit breaker settings %s: %v", key, err)
+			return
+		}
+		bs.Name = strings.TrimPrefix(key, s.configPrefix)
+
+		changes, err := r.OverrideConfig(bs)
+		if err != nil {
+			log.Printf("circuit breaker settings %s: %v", key, err)
+		}
+		if fn != nil {
+			for _, ch := range changes {
+				fn(ch)
+			}
+		}
+	}
+	load := func() (int64, error) {
+		resp, err := s.cli.Get(ctx, s.configPrefix, clientv3.WithPrefix())
+		if err != nil {
+			return 0, err
+		}
+		for _, kv := range resp.Kvs {
+			apply(kv.Value, string(kv.Key))
+		}
+		return resp.Header.Revision, nil
+	}
+
+	rev, err := load()
+	if err != nil {
+		return err
+	}
+
+	for {
+		wch := s.cli.Watch(ctx, s.configPrefix, clientv3.WithPrefix(), clientv3.WithRev(rev+1))
+		for resp := range wch {
+			if resp.Err() != nil {
+				break
+			}
+			for _, ev := range resp.Events {
+				if ev.Type == clientv3.EventTypePut {
+					apply(ev.Kv.Value, string(ev.Kv.Key))
+				}
+			}
+			rev = resp.Header.Revision
+		}
+
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		//canceled or compacted, start over from current revision
+		if rev, err = load(); err != nil {
+			return err
+		}
+	}
+}
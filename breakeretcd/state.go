@@ -0,0 +1,168 @@
+package breakeretcd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/carl-leopard/circuitbreaker/breaker"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+const (
+	defaultPrefix           = "/circuitbreaker/"
+	defaultPropagationDelay = 5 * time.Second
+)
+
+type Option func(s *State)
+
+//WithPrefix sets prefix of etcd keys, default "/circuitbreaker/"
+func WithPrefix(prefix string) Option {
+	return func(s *State) {
+		s.prefix = prefix
+	}
+}
+
+//WithInstance sets id of this instance, default hostname-pid
+func WithInstance(id string) Option {
+	return func(s *State) {
+		s.instance = id
+	}
+}
+
+//WithPropagationDelay bounds how late peers learn about a trip. trips arrive by watch right away,
+//in addition all keys are read again every d in case watch events are lost. default 5s
+func WithPropagationDelay(d time.Duration) Option {
+	return func(s *State) {
+		if d > 0 {
+			s.propagationDelay = d
+		}
+	}
+}
+
+//State implements breaker.Store in etcd, it shares window counts, publishes trips of circuit breakers and watches
+//trips of peers. a trip is a key of the breaker name kept alive by a lease of its sleep window, so it disappears
+//when the window ends. use it with breaker.WithStore, or breaker.WithTransitionPublisher, and breaker.FollowPeers
+type State struct {
+	cli              *clientv3.Client
+	prefix           string
+	windowPrefix     string
+	configPrefix     string
+	instance         string
+	propagationDelay time.Duration
+}
+
+var _ breaker.Store = (*State)(nil)
+
+//New return state stored in etcd by cli
+func New(cli *clientv3.Client, opts ...Option) *State {
+	host, _ := os.Hostname()
+	s := &State{
+		cli:              cli,
+		prefix:           defaultPrefix,
+		windowPrefix:     defaultWindowPrefix,
+		configPrefix:     defaultConfigPrefix,
+		instance:         fmt.Sprintf("%s-%d", host, os.Getpid()),
+		propagationDelay: defaultPropagationDelay,
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+//PublishTransition implements breaker.TransitionPublisher, only trips are stored
+func (s *State) PublishTransition(ctx context.Context, t breaker.Transition) error {
+	if t.To != breaker.CircuitBreakerStatusOpen {
+		return nil
+	}
+
+	ttl := int64(time.Until(t.Until)/time.Second) + 1
+	t.Instance = s.instance
+	v, err := json.Marshal(t)
+	if err != nil {
+		return err
+	}
+
+	lease, err := s.cli.Grant(ctx, ttl)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.cli.Put(ctx, s.prefix+t.Name, string(v), clientv3.WithLease(lease.ID))
+	return err
+}
+
+//WatchTransitions implements breaker.TransitionWatcher
+func (s *State) WatchTransitions(ctx context.Context, fn func(breaker.Transition)) error {
+	resync := time.NewTicker(s.propagationDelay)
+	defer resync.Stop()
+
+	rev, err := s.load(ctx, fn)
+	if err != nil {
+		return err
+	}
+
+	for {
+		wch := s.cli.Watch(ctx, s.prefix, clientv3.WithPrefix(), clientv3.WithRev(rev+1))
+
+	watch:
+		for {
+			select {
+			case resp, ok := <-wch:
+				if !ok || resp.Err() != nil {
+					//canceled or compacted, start over from current revision
+					break watch
+				}
+
+				for _, ev := range resp.Events {
+					if ev.Type == clientv3.EventTypePut {
+						s.dispatch(ev.Kv.Value, fn)
+					}
+				}
+				rev = resp.Header.Revision
+			case <-resync.C:
+				if rev, err = s.load(ctx, fn); err != nil {
+					return err
+				}
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if rev, err = s.load(ctx, fn); err != nil {
+			return err
+		}
+	}
+}
+
+//load passes all stored trips to fn and returns the revision read
+func (s *State) load(ctx context.Context, fn func(breaker.Transition)) (int64, error) {
+	resp, err := s.cli.Get(ctx, s.prefix, clientv3.WithPrefix())
+	if err != nil {
+		return 0, err
+	}
+
+	for _, kv := range resp.Kvs {
+		s.dispatch(kv.Value, fn)
+	}
+
+	return resp.Header.Revision, nil
+}
+
+func (s *State) dispatch(v []byte, fn func(breaker.Transition)) {
+	var t breaker.Transition
+	if err := json.Unmarshal(v, &t); err != nil || t.Instance == s.instance || strings.TrimSpace(t.Name) == "" {
+		return
+	}
+
+	fn(t)
+}
@@ -0,0 +1,94 @@
+package breakeretcd
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/carl-leopard/circuitbreaker/breaker"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+const defaultWindowPrefix = "/circuitbreaker-windows/"
+
+//WithWindowPrefix sets prefix of window keys, default "/circuitbreaker-windows/". it must not be under the
+//prefix of trips, or watchers wake up on every count
+func WithWindowPrefix(prefix string) Option {
+	return func(s *State) {
+		s.windowPrefix = prefix
+	}
+}
+
+//window value of the window key of a breaker, a newer window replaces an older one
+type window struct {
+	Start    int64  `json:"start"` //unix nano
+	Requests uint32 `json:"requests"`
+	Errors   uint32 `json:"errors"`
+}
+
+//IncrWindow implements breaker.WindowCounter. every breaker has one key holding its latest window,
+//updated by compare-and-swap on its revision, so updates retry under contention
+func (s *State) IncrWindow(ctx context.Context, name string, start time.Time, requests, errors uint32) (breaker.Window, error) {
+	key := s.windowPrefix + name
+	for {
+		resp, err := s.cli.Get(ctx, key)
+		if err != nil {
+			return breaker.Window{}, err
+		}
+
+		w := window{Start: start.UnixNano()}
+		cmp := clientv3.Compare(clientv3.CreateRevision(key), "=", 0)
+		if len(resp.Kvs) > 0 {
+			kv := resp.Kvs[0]
+			var cur window
+			if json.Unmarshal(kv.Value, &cur) == nil {
+				if cur.Start > w.Start {
+					//a peer moved on to the next window already, counts of a past one are not kept
+					return breaker.Window{Start: start, Requests: requests, Errors: errors}, nil
+				}
+				if cur.Start == w.Start {
+					w = cur
+				}
+			}
+			cmp = clientv3.Compare(clientv3.ModRevision(key), "=", kv.ModRevision)
+		}
+		w.Requests = breaker.SaturatedCount(uint64(w.Requests) + uint64(requests))
+		w.Errors = breaker.SaturatedCount(uint64(w.Errors) + uint64(errors))
+
+		v, err := json.Marshal(w)
+		if err != nil {
+			return breaker.Window{}, err
+		}
+
+		txn, err := s.cli.Txn(ctx).If(cmp).Then(clientv3.OpPut(key, string(v))).Commit()
+		if err != nil {
+			return breaker.Window{}, err
+		}
+		if txn.Succeeded {
+			return breaker.Window{Start: start, Requests: w.Requests, Errors: w.Errors}, nil
+		}
+	}
+}
+
+//ReadWindow implements breaker.WindowReader
+func (s *State) ReadWindow(ctx context.Context, name string, start time.Time) (breaker.Window, error) {
+	resp, err := s.cli.Get(ctx, s.windowPrefix+name)
+	if err != nil {
+		return breaker.Window{}, err
+	}
+
+	out := breaker.Window{Start: start}
+	if len(resp.Kvs) == 0 {
+		return out, nil
+	}
+
+	var w window
+	if err := json.Unmarshal(resp.Kvs[0].Value, &w); err != nil {
+		return out, err
+	}
+	if w.Start == start.UnixNano() {
+		out.Requests, out.Errors = w.Requests, w.Errors
+	}
+
+	return out, nil
+}
@@ -0,0 +1,60 @@
+package breakerfasthttp
+
+import (
+	"errors"
+	"time"
+
+	"github.com/carl-leopard/circuitbreaker/breaker"
+	"github.com/gofiber/fiber/v2"
+)
+
+//FiberMiddleware is the Fiber equivalent of Handler, handler errors are classified by their fiber.Error code
+func FiberMiddleware(cb *breaker.CircuitBreaker, opts ...Option) fiber.Handler {
+	return fiberMiddleware(func(*fiber.Ctx) *breaker.CircuitBreaker { return cb }, newOptions(opts))
+}
+
+//FiberGroupMiddleware works like FiberMiddleware with a circuit breaker per key from g,
+//requests of an empty key are not guarded
+func FiberGroupMiddleware(g *breaker.Group, key func(c *fiber.Ctx) string, opts ...Option) fiber.Handler {
+	return fiberMiddleware(func(c *fiber.Ctx) *breaker.CircuitBreaker {
+		k := key(c)
+		if k == "" {
+			return nil
+		}
+		return g.Get(k)
+	}, newOptions(opts))
+}
+
+func fiberMiddleware(breakerFor func(c *fiber.Ctx) *breaker.CircuitBreaker, o *options) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		cb := breakerFor(c)
+		if cb == nil {
+			return c.Next()
+		}
+
+		if err := cb.ReportRequest(); err != nil {
+			c.Set(fiber.HeaderRetryAfter, retryAfter(cb))
+			return fiber.ErrServiceUnavailable
+		}
+
+		start := time.Now()
+		err := c.Next()
+		cb.ReportLatency(time.Since(start))
+
+		code := c.Response().StatusCode()
+		if err != nil {
+			code = fiber.StatusInternalServerError
+
+			var fe *fiber.Error
+			if errors.As(err, &fe) {
+				code = fe.Code
+			}
+		}
+
+		if o.classify(code) == breaker.OutcomeFailure {
+			cb.ReportErrorContext(c.UserContext())
+		}
+
+		return err
+	}
+}
@@ -0,0 +1,100 @@
+package breakerfasthttp
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/carl-leopard/circuitbreaker/breaker"
+	"github.com/valyala/fasthttp"
+)
+
+type Option func(o *options)
+
+//WithStatusClassifier decides by status code whether a handled request counts as a failure, default 5xx
+func WithStatusClassifier(f func(code int) breaker.Outcome) Option {
+	return func(o *options) {
+		if f != nil {
+			o.classify = f
+		}
+	}
+}
+
+type options struct {
+	classify func(code int) breaker.Outcome
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{
+		classify: func(code int) breaker.Outcome {
+			if code >= fasthttp.StatusInternalServerError {
+				return breaker.OutcomeFailure
+			}
+			return breaker.OutcomeSuccess
+		},
+	}
+
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	return o
+}
+
+//Handler sheds requests with 503 and Retry-After while cb is open, responses classified as failures
+//and panics are reported as errors
+func Handler(cb *breaker.CircuitBreaker, next fasthttp.RequestHandler, opts ...Option) fasthttp.RequestHandler {
+	return handler(func(*fasthttp.RequestCtx) *breaker.CircuitBreaker { return cb }, next, newOptions(opts))
+}
+
+//GroupHandler works like Handler with a circuit breaker per key from g, requests of an empty key are not guarded
+func GroupHandler(g *breaker.Group, key func(ctx *fasthttp.RequestCtx) string, next fasthttp.RequestHandler, opts ...Option) fasthttp.RequestHandler {
+	return handler(func(ctx *fasthttp.RequestCtx) *breaker.CircuitBreaker {
+		k := key(ctx)
+		if k == "" {
+			return nil
+		}
+		return g.Get(k)
+	}, next, newOptions(opts))
+}
+
+func handler(breakerFor func(ctx *fasthttp.RequestCtx) *breaker.CircuitBreaker, next fasthttp.RequestHandler, o *options) fasthttp.RequestHandler {
+	return func(ctx *fasthttp.RequestCtx) {
+		cb := breakerFor(ctx)
+		if cb == nil {
+			next(ctx)
+			return
+		}
+
+		if err := cb.ReportRequest(); err != nil {
+			ctx.Response.Header.Set("Retry-After", retryAfter(cb))
+			ctx.Error(fasthttp.StatusMessage(fasthttp.StatusServiceUnavailable), fasthttp.StatusServiceUnavailable)
+			return
+		}
+
+		start := time.Now()
+		defer func() {
+			cb.ReportLatency(time.Since(start))
+
+			if p := recover(); p != nil {
+				cb.ReportErrorContext(ctx)
+				panic(p)
+			}
+
+			if o.classify(ctx.Response.StatusCode()) == breaker.OutcomeFailure {
+				cb.ReportErrorContext(ctx)
+			}
+		}()
+
+		next(ctx)
+	}
+}
+
+func retryAfter(cb *breaker.CircuitBreaker) string {
+	//seconds left of the sleep window, a floored value would bring clients back early
+	secs := int((cb.RemainingSleepWindow() + time.Second - 1) / time.Second)
+	if secs < 1 {
+		secs = 1
+	}
+
+	return strconv.Itoa(secs)
+}
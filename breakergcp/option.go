@@ -0,0 +1,22 @@
+package breakergcp
+
+import (
+	"github.com/carl-leopard/circuitbreaker/breaker"
+	"github.com/carl-leopard/circuitbreaker/breakergrpc"
+	"google.golang.org/api/option"
+	"google.golang.org/grpc"
+)
+
+//ClientOptions return options for gRPC based Google Cloud clients, running all calls of api, e.g. "pubsub",
+//under the circuit breaker of api from g. opts configure the interceptors
+func ClientOptions(g *breaker.Group, api string, opts ...breakergrpc.Option) []option.ClientOption {
+	return ClientOptionsOf(g.Get(api), opts...)
+}
+
+//ClientOptionsOf works like ClientOptions with a given circuit breaker
+func ClientOptionsOf(cb *breaker.CircuitBreaker, opts ...breakergrpc.Option) []option.ClientOption {
+	return []option.ClientOption{
+		option.WithGRPCDialOption(grpc.WithChainUnaryInterceptor(breakergrpc.UnaryClientInterceptor(cb, opts...))),
+		option.WithGRPCDialOption(grpc.WithChainStreamInterceptor(breakergrpc.StreamClientInterceptor(cb, opts...))),
+	}
+}
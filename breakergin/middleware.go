@@ -0,0 +1,116 @@
+package breakergin
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/carl-leopard/circuitbreaker/breaker"
+	"github.com/gin-gonic/gin"
+)
+
+type Option func(m *middleware)
+
+//WithStatusClassifier decides by status code whether a handled request counts as a failure, default 5xx
+func WithStatusClassifier(f func(code int) breaker.Outcome) Option {
+	return func(m *middleware) {
+		if f != nil {
+			m.classify = f
+		}
+	}
+}
+
+//WithRejectHandler replaces the default 503 response when circuit breaker is open, it must abort c
+func WithRejectHandler(h gin.HandlerFunc) Option {
+	return func(m *middleware) {
+		if h != nil {
+			m.reject = h
+		}
+	}
+}
+
+type middleware struct {
+	breakerFor func(c *gin.Context) *breaker.CircuitBreaker
+	classify   func(code int) breaker.Outcome
+	reject     gin.HandlerFunc
+}
+
+//Middleware sheds requests with 503 and Retry-After while cb is open, 5xx responses and panics reported as errors
+func Middleware(cb *breaker.CircuitBreaker, opts ...Option) gin.HandlerFunc {
+	return newMiddleware(func(*gin.Context) *breaker.CircuitBreaker { return cb }, opts)
+}
+
+//GroupMiddleware works like Middleware with a circuit breaker per route from g, keyed like "GET /users/:id".
+//requests not matching any route are not guarded
+func GroupMiddleware(g *breaker.Group, opts ...Option) gin.HandlerFunc {
+	return newMiddleware(func(c *gin.Context) *breaker.CircuitBreaker {
+		route := c.FullPath()
+		if route == "" {
+			return nil
+		}
+		return g.Get(c.Request.Method + " " + route)
+	}, opts)
+}
+
+func newMiddleware(breakerFor func(c *gin.Context) *breaker.CircuitBreaker, opts []Option) gin.HandlerFunc {
+	m := &middleware{
+		breakerFor: breakerFor,
+		classify: func(code int) breaker.Outcome {
+			if code >= http.StatusInternalServerError {
+				return breaker.OutcomeFailure
+			}
+			return breaker.OutcomeSuccess
+		},
+	}
+
+	for _, opt := range opts {
+		opt(m)
+	}
+
+	return m.handle
+}
+
+func (m *middleware) handle(c *gin.Context) {
+	cb := m.breakerFor(c)
+	if cb == nil {
+		c.Next()
+		return
+	}
+
+	if err := cb.ReportRequest(); err != nil {
+		if m.reject != nil {
+			m.reject(c)
+			return
+		}
+
+		c.Header("Retry-After", retryAfter(cb))
+		c.AbortWithStatus(http.StatusServiceUnavailable)
+		return
+	}
+
+	start := time.Now()
+	defer func() {
+		cb.ReportLatency(time.Since(start))
+
+		if p := recover(); p != nil {
+			cb.ReportErrorContext(c.Request.Context())
+			panic(p)
+		}
+
+		if m.classify(c.Writer.Status()) == breaker.OutcomeFailure {
+			cb.ReportErrorContext(c.Request.Context())
+		}
+	}()
+
+	c.Next()
+}
+
+func retryAfter(cb *breaker.CircuitBreaker) string {
+	//the rest of the sleep window rounded up, so clients don't come back while circuit breaker is still open
+	secs := int((cb.RemainingSleepWindow() + time.Second - 1) / time.Second)
+	if secs < 1 {
+		secs = 1
+	}
+
+	return strconv.Itoa(secs)
+}
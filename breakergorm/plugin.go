@@ -0,0 +1,84 @@
+package breakergorm
+
+import (
+	"time"
+
+	"github.com/carl-leopard/circuitbreaker/breaker"
+	"github.com/carl-leopard/circuitbreaker/breakersql"
+	"gorm.io/gorm"
+)
+
+const (
+	pluginName  = "circuitbreaker"
+	startKey    = "circuitbreaker:start"
+	rejectedKey = "circuitbreaker:rejected"
+)
+
+//Plugin is a gorm.Plugin running every statement under a circuit breaker
+type Plugin struct {
+	guard *breakersql.Guard
+}
+
+//New return a plugin of cb, opts are the same as breakersql's
+func New(cb *breaker.CircuitBreaker, opts ...breakersql.Option) *Plugin {
+	return &Plugin{guard: breakersql.NewGuard(cb, opts...)}
+}
+
+//Name implements gorm.Plugin
+func (p *Plugin) Name() string {
+	return pluginName
+}
+
+//Initialize implements gorm.Plugin
+func (p *Plugin) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+
+	//processors of gorm are unexported, so register through method values
+	type register func(name string, fn func(*gorm.DB)) error
+	hooks := []struct {
+		name          string
+		before, after register
+	}{
+		{"create", cb.Create().Before("gorm:create").Register, cb.Create().After("gorm:create").Register},
+		{"query", cb.Query().Before("gorm:query").Register, cb.Query().After("gorm:query").Register},
+		{"update", cb.Update().Before("gorm:update").Register, cb.Update().After("gorm:update").Register},
+		{"delete", cb.Delete().Before("gorm:delete").Register, cb.Delete().After("gorm:delete").Register},
+		{"row", cb.Row().Before("gorm:row").Register, cb.Row().After("gorm:row").Register},
+		{"raw", cb.Raw().Before("gorm:raw").Register, cb.Raw().After("gorm:raw").Register},
+	}
+
+	for _, h := range hooks {
+		if err := h.before(pluginName+":before_"+h.name, p.before); err != nil {
+			return err
+		}
+		if err := h.after(pluginName+":after_"+h.name, p.after); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (p *Plugin) before(db *gorm.DB) {
+	if err := p.guard.Before(); err != nil {
+		db.InstanceSet(rejectedKey, true)
+		//gorm skips executing the statement once db.Error is set
+		db.AddError(err)
+		return
+	}
+
+	db.InstanceSet(startKey, time.Now())
+}
+
+func (p *Plugin) after(db *gorm.DB) {
+	if _, ok := db.InstanceGet(rejectedKey); ok {
+		return
+	}
+
+	start, ok := db.InstanceGet(startKey)
+	if !ok {
+		return
+	}
+
+	p.guard.After(db.Statement.Context, start.(time.Time), db.Error)
+}
@@ -0,0 +1,200 @@
+package breakergossip
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/carl-leopard/circuitbreaker/breaker"
+	"github.com/hashicorp/memberlist"
+)
+
+const defaultRetransmitMult = 4
+
+//Gossip broadcasts trips of circuit breakers among peers by memberlist, without any central store.
+//members joining later receive trips still in their sleep window by push/pull state sync.
+//use it with breaker.WithTransitionPublisher and breaker.FollowPeers, only one watcher is supported
+type Gossip struct {
+	ml       *memberlist.Memberlist
+	queue    *memberlist.TransmitLimitedQueue
+	instance string
+
+	mu    sync.Mutex
+	trips map[string]breaker.Transition //latest trip by breaker name, local or remote
+
+	incoming chan breaker.Transition
+}
+
+var (
+	_ breaker.TransitionPublisher = (*Gossip)(nil)
+	_ breaker.TransitionWatcher   = (*Gossip)(nil)
+	_ memberlist.Delegate         = (*delegate)(nil)
+)
+
+//New creates a memberlist by conf, e.g. memberlist.DefaultLANConfig(). conf.Delegate is replaced and
+//conf.Name identifies this instance
+func New(conf *memberlist.Config) (*Gossip, error) {
+	g := &Gossip{
+		instance: conf.Name,
+		trips:    make(map[string]breaker.Transition),
+		incoming: make(chan breaker.Transition, 64),
+	}
+
+	conf.Delegate = &delegate{g: g}
+	ml, err := memberlist.Create(conf)
+	if err != nil {
+		return nil, err
+	}
+
+	g.ml = ml
+	g.queue = &memberlist.TransmitLimitedQueue{
+		NumNodes:       ml.NumMembers,
+		RetransmitMult: defaultRetransmitMult,
+	}
+
+	return g, nil
+}
+
+//Join joins the cluster by any of peers, see memberlist.Memberlist.Join
+func (g *Gossip) Join(peers ...string) (int, error) {
+	return g.ml.Join(peers)
+}
+
+//Leave leaves the cluster and shuts down memberlist
+func (g *Gossip) Leave(timeout time.Duration) error {
+	if err := g.ml.Leave(timeout); err != nil {
+		return err
+	}
+
+	return g.ml.Shutdown()
+}
+
+//Members returns the members of the cluster
+func (g *Gossip) Members() []*memberlist.Node {
+	return g.ml.Members()
+}
+
+//PublishTransition implements breaker.TransitionPublisher, only trips are broadcast
+func (g *Gossip) PublishTransition(_ context.Context, t breaker.Transition) error {
+	if t.To != breaker.CircuitBreakerStatusOpen {
+		return nil
+	}
+
+	t.Instance = g.instance
+	msg, err := json.Marshal(t)
+	if err != nil {
+		return err
+	}
+
+	g.remember(t)
+	g.queue.QueueBroadcast(&broadcast{name: t.Name, msg: msg})
+
+	return nil
+}
+
+//WatchTransitions implements breaker.TransitionWatcher, transitions arriving while fn blocks may be dropped
+func (g *Gossip) WatchTransitions(ctx context.Context, fn func(breaker.Transition)) error {
+	for {
+		select {
+		case t := <-g.incoming:
+			fn(t)
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+//remember keeps t for state sync, reporting whether it is newer than the known trip of the breaker
+func (g *Gossip) remember(t breaker.Transition) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if known, ok := g.trips[t.Name]; ok && !t.Until.After(known.Until) {
+		return false
+	}
+	g.trips[t.Name] = t
+
+	return true
+}
+
+func (g *Gossip) receive(t breaker.Transition) {
+	if t.Instance == g.instance || !t.Until.After(time.Now()) || !g.remember(t) {
+		return
+	}
+
+	select {
+	case g.incoming <- t:
+	default:
+	}
+}
+
+//activeTrips returns trips still in their sleep window, forgetting the others
+func (g *Gossip) activeTrips() []breaker.Transition {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	now := time.Now()
+	trips := make([]breaker.Transition, 0, len(g.trips))
+	for name, t := range g.trips {
+		if !t.Until.After(now) {
+			delete(g.trips, name)
+			continue
+		}
+		trips = append(trips, t)
+	}
+
+	return trips
+}
+
+type delegate struct {
+	g *Gossip
+}
+
+func (d *delegate) NodeMeta(int) []byte {
+	return nil
+}
+
+func (d *delegate) NotifyMsg(msg []byte) {
+	var t breaker.Transition
+	if err := json.Unmarshal(msg, &t); err == nil {
+		d.g.receive(t)
+	}
+}
+
+func (d *delegate) GetBroadcasts(overhead, limit int) [][]byte {
+	return d.g.queue.GetBroadcasts(overhead, limit)
+}
+
+func (d *delegate) LocalState(bool) []byte {
+	state, _ := json.Marshal(d.g.activeTrips())
+	return state
+}
+
+func (d *delegate) MergeRemoteState(buf []byte, _ bool) {
+	var trips []breaker.Transition
+	if err := json.Unmarshal(buf, &trips); err != nil {
+		return
+	}
+
+	for _, t := range trips {
+		d.g.receive(t)
+	}
+}
+
+//broadcast a trip, newer trips of the same breaker replace queued ones
+type broadcast struct {
+	name string
+	msg  []byte
+}
+
+func (b *broadcast) Invalidates(other memberlist.Broadcast) bool {
+	o, ok := other.(*broadcast)
+	return ok && o.name == b.name
+}
+
+func (b *broadcast) Message() []byte {
+	return b.msg
+}
+
+func (b *broadcast) Finished() {}
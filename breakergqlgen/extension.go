@@ -0,0 +1,100 @@
+package breakergqlgen
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/carl-leopard/circuitbreaker/breaker"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+//Classifier decides whether an error returned by a resolver counts as a failure
+type Classifier func(err error) breaker.Outcome
+
+//DefaultClassifier treats resolver errors as failures, except cancellation and *gqlerror.Error,
+//which resolvers return on purpose to tell the client something
+func DefaultClassifier(err error) breaker.Outcome {
+	var gqlErr *gqlerror.Error
+
+	switch {
+	case err == nil, errors.Is(err, context.Canceled), errors.As(err, &gqlErr):
+		return breaker.OutcomeSuccess
+	default:
+		return breaker.OutcomeFailure
+	}
+}
+
+type Option func(e *Extension)
+
+//WithClassifier replaces DefaultClassifier
+func WithClassifier(f Classifier) Option {
+	return func(e *Extension) {
+		if f != nil {
+			e.classify = f
+		}
+	}
+}
+
+//Extension is a gqlgen handler extension running each resolver under the breaker of its "Object.field" from a group.
+//a rejected or failed resolver only nulls its own field and adds an error, the rest of the query still resolves
+type Extension struct {
+	g        *breaker.Group
+	classify Classifier
+}
+
+var (
+	_ graphql.HandlerExtension = (*Extension)(nil)
+	_ graphql.FieldInterceptor = (*Extension)(nil)
+)
+
+//New return an extension with breakers from g, add it with handler.Server.Use
+func New(g *breaker.Group, opts ...Option) *Extension {
+	e := &Extension{
+		g:        g,
+		classify: DefaultClassifier,
+	}
+
+	for _, opt := range opts {
+		opt(e)
+	}
+
+	return e
+}
+
+//ExtensionName implements graphql.HandlerExtension
+func (e *Extension) ExtensionName() string {
+	return "CircuitBreaker"
+}
+
+//Validate implements graphql.HandlerExtension
+func (e *Extension) Validate(graphql.ExecutableSchema) error {
+	return nil
+}
+
+//InterceptField implements graphql.FieldInterceptor. fields without a resolver, which only read a struct field
+//or call a plain method, are not guarded
+func (e *Extension) InterceptField(ctx context.Context, next graphql.Resolver) (interface{}, error) {
+	fc := graphql.GetFieldContext(ctx)
+	if fc == nil || !fc.IsResolver {
+		return next(ctx)
+	}
+
+	key := fc.Object + "." + fc.Field.Name
+	cb := e.g.Get(key)
+	if err := cb.ReportRequestWithMeta(breaker.RequestMeta{Key: key}); err != nil {
+		return nil, fmt.Errorf("circuit breaker: %w", err)
+	}
+
+	start := time.Now()
+	res, err := next(ctx)
+	cb.ReportLatency(time.Since(start))
+
+	if e.classify(err) == breaker.OutcomeFailure {
+		cb.ReportErrorContext(ctx)
+	}
+
+	return res, err
+}
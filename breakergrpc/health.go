@@ -0,0 +1,113 @@
+package breakergrpc
+
+import (
+	"context"
+	"time"
+
+	"github.com/carl-leopard/circuitbreaker/breaker"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+const defaultHealthPollInterval = time.Second
+
+type HealthOption func(h *HealthServer)
+
+//WithHealthPollInterval sets how often Watch re-evaluates breakers of a service
+func WithHealthPollInterval(t time.Duration) HealthOption {
+	return func(h *HealthServer) {
+		if t > 0 {
+			h.pollInterval = t
+		}
+	}
+}
+
+//HealthServer implements grpc.health.v1.Health. a service is NOT_SERVING while any of its breakers is open
+type HealthServer struct {
+	healthpb.UnimplementedHealthServer
+
+	services     map[string][]*breaker.CircuitBreaker //service name => critical dependency breakers, "" is the whole server
+	pollInterval time.Duration
+}
+
+//NewHealthServer return a health server driven by breakers of each service.
+//the overall status (service "") covers all breakers unless it is given explicitly.
+func NewHealthServer(services map[string][]*breaker.CircuitBreaker, opts ...HealthOption) *HealthServer {
+	h := &HealthServer{
+		services:     make(map[string][]*breaker.CircuitBreaker, len(services)+1),
+		pollInterval: defaultHealthPollInterval,
+	}
+
+	var all []*breaker.CircuitBreaker
+	for name, cbs := range services {
+		h.services[name] = cbs
+		all = append(all, cbs...)
+	}
+	if _, ok := h.services[""]; !ok {
+		h.services[""] = all
+	}
+
+	for _, opt := range opts {
+		opt(h)
+	}
+
+	return h
+}
+
+//Check implements healthpb.HealthServer
+func (h *HealthServer) Check(ctx context.Context, req *healthpb.HealthCheckRequest) (*healthpb.HealthCheckResponse, error) {
+	cbs, ok := h.services[req.GetService()]
+	if !ok {
+		return nil, status.Error(codes.NotFound, "unknown service")
+	}
+
+	return &healthpb.HealthCheckResponse{Status: servingStatus(cbs)}, nil
+}
+
+//Watch implements healthpb.HealthServer, sends a new response whenever the status of service changes
+func (h *HealthServer) Watch(req *healthpb.HealthCheckRequest, stream healthpb.Health_WatchServer) error {
+	cbs, ok := h.services[req.GetService()]
+	last := healthpb.HealthCheckResponse_SERVICE_UNKNOWN
+	if ok {
+		last = servingStatus(cbs)
+	}
+	if err := stream.Send(&healthpb.HealthCheckResponse{Status: last}); err != nil {
+		return err
+	}
+	if !ok {
+		//status of unknown service never changes
+		<-stream.Context().Done()
+		return status.FromContextError(stream.Context().Err()).Err()
+	}
+
+	t := time.NewTicker(h.pollInterval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-t.C:
+			cur := servingStatus(cbs)
+			if cur == last {
+				continue
+			}
+
+			if err := stream.Send(&healthpb.HealthCheckResponse{Status: cur}); err != nil {
+				return err
+			}
+			last = cur
+		case <-stream.Context().Done():
+			return status.FromContextError(stream.Context().Err()).Err()
+		}
+	}
+}
+
+func servingStatus(cbs []*breaker.CircuitBreaker) healthpb.HealthCheckResponse_ServingStatus {
+	for _, cb := range cbs {
+		if cb.Status() == breaker.CircuitBreakerStatusOpen {
+			return healthpb.HealthCheckResponse_NOT_SERVING
+		}
+	}
+
+	return healthpb.HealthCheckResponse_SERVING
+}
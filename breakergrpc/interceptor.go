@@ -0,0 +1,257 @@
+package breakergrpc
+
+import (
+	"context"
+	"io"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/carl-leopard/circuitbreaker/breaker"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+type Option func(o *options)
+
+//WithFailureCodes sets codes reported as errors to circuit breaker, default Unavailable and DeadlineExceeded
+func WithFailureCodes(cs ...codes.Code) Option {
+	return func(o *options) {
+		o.failureCodes = make(map[codes.Code]struct{}, len(cs))
+		for _, c := range cs {
+			o.failureCodes[c] = struct{}{}
+		}
+	}
+}
+
+//WithStreamEstablishmentOnly makes stream interceptors ignore errors after a stream is established,
+//for long-lived streams whose eventual end says nothing about backend health
+func WithStreamEstablishmentOnly() Option {
+	return func(o *options) {
+		o.establishmentOnly = true
+	}
+}
+
+type options struct {
+	failureCodes      map[codes.Code]struct{}
+	establishmentOnly bool
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{
+		failureCodes: map[codes.Code]struct{}{
+			codes.Unavailable:      {},
+			codes.DeadlineExceeded: {},
+		},
+	}
+
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	return o
+}
+
+func (o *options) classify(err error) breaker.Outcome {
+	if err == nil {
+		return breaker.OutcomeSuccess
+	}
+
+	if _, ok := o.failureCodes[status.Code(err)]; ok {
+		return breaker.OutcomeFailure
+	}
+
+	return breaker.OutcomeSuccess
+}
+
+//UnaryClientInterceptor short-circuits calls with codes.Unavailable when cb is open,
+//reports failure codes as errors and records latency
+func UnaryClientInterceptor(cb *breaker.CircuitBreaker, opts ...Option) grpc.UnaryClientInterceptor {
+	return unaryClientInterceptor(func(string) *breaker.CircuitBreaker { return cb }, newOptions(opts))
+}
+
+//GroupUnaryClientInterceptor works like UnaryClientInterceptor with a circuit breaker per full method name from g
+func GroupUnaryClientInterceptor(g *breaker.Group, opts ...Option) grpc.UnaryClientInterceptor {
+	return unaryClientInterceptor(g.Get, newOptions(opts))
+}
+
+//RegistryUnaryClientInterceptor works like UnaryClientInterceptor with the circuit breaker the grpc integrations
+//of r route the full method name to, see breaker.Integration. calls of methods routed nowhere are not guarded
+func RegistryUnaryClientInterceptor(r *breaker.Registry, opts ...Option) grpc.UnaryClientInterceptor {
+	return unaryClientInterceptor(route(r), newOptions(opts))
+}
+
+//route returns circuit breaker of grpc integrations of r for a method, nil if there is none
+func route(r *breaker.Registry) func(method string) *breaker.CircuitBreaker {
+	return func(method string) *breaker.CircuitBreaker {
+		cb, _ := r.Route(breaker.IntegrationGRPC, method)
+		return cb
+	}
+}
+
+func unaryClientInterceptor(breakerFor func(method string) *breaker.CircuitBreaker, o *options) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, callOpts ...grpc.CallOption) error {
+		cb := breakerFor(method)
+		if cb == nil {
+			return invoker(ctx, method, req, reply, cc, callOpts...)
+		}
+		if err := cb.ReportRequestWithMeta(breaker.RequestMeta{Method: method}); err != nil {
+			return rejectError(err)
+		}
+
+		start := time.Now()
+		err := invoker(ctx, method, req, reply, cc, callOpts...)
+		cb.ReportLatency(time.Since(start))
+
+		if o.classify(err) == breaker.OutcomeFailure {
+			cb.ReportErrorContext(ctx)
+		}
+
+		return err
+	}
+}
+
+//pushbackTrailer tells clients when to retry, grpc-retry-pushback-ms is honored by gRPC retry policies
+func pushbackTrailer(cb *breaker.CircuitBreaker) metadata.MD {
+	return metadata.Pairs("grpc-retry-pushback-ms", strconv.FormatInt(cb.RemainingSleepWindow().Milliseconds(), 10))
+}
+
+func rejectError(err error) error {
+	return status.Error(codes.Unavailable, "circuit breaker: "+err.Error())
+}
+
+//StreamClientInterceptor short-circuits new streams with codes.Unavailable when cb is open. stream-establishment
+//failures and the first mid-stream failure of a stream are reported as errors, see WithStreamEstablishmentOnly
+func StreamClientInterceptor(cb *breaker.CircuitBreaker, opts ...Option) grpc.StreamClientInterceptor {
+	return streamClientInterceptor(func(string) *breaker.CircuitBreaker { return cb }, newOptions(opts))
+}
+
+//GroupStreamClientInterceptor works like StreamClientInterceptor with a circuit breaker per full method name from g
+func GroupStreamClientInterceptor(g *breaker.Group, opts ...Option) grpc.StreamClientInterceptor {
+	return streamClientInterceptor(g.Get, newOptions(opts))
+}
+
+//RegistryStreamClientInterceptor works like StreamClientInterceptor with the circuit breaker the grpc integrations
+//of r route the full method name to, see breaker.Integration. streams of methods routed nowhere are not guarded
+func RegistryStreamClientInterceptor(r *breaker.Registry, opts ...Option) grpc.StreamClientInterceptor {
+	return streamClientInterceptor(route(r), newOptions(opts))
+}
+
+func streamClientInterceptor(breakerFor func(method string) *breaker.CircuitBreaker, o *options) grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, callOpts ...grpc.CallOption) (grpc.ClientStream, error) {
+		cb := breakerFor(method)
+		if cb == nil {
+			return streamer(ctx, desc, cc, method, callOpts...)
+		}
+		if err := cb.ReportRequestWithMeta(breaker.RequestMeta{Method: method}); err != nil {
+			return nil, rejectError(err)
+		}
+
+		start := time.Now()
+		cs, err := streamer(ctx, desc, cc, method, callOpts...)
+		cb.ReportLatency(time.Since(start))
+
+		if err != nil {
+			if o.classify(err) == breaker.OutcomeFailure {
+				cb.ReportErrorContext(ctx)
+			}
+			return nil, err
+		}
+
+		if o.establishmentOnly {
+			return cs, nil
+		}
+
+		return &clientStream{ClientStream: cs, cb: cb, o: o}, nil
+	}
+}
+
+type clientStream struct {
+	grpc.ClientStream
+	cb   *breaker.CircuitBreaker
+	o    *options
+	once sync.Once
+}
+
+func (s *clientStream) SendMsg(m interface{}) error {
+	err := s.ClientStream.SendMsg(m)
+	s.report(err)
+	return err
+}
+
+func (s *clientStream) RecvMsg(m interface{}) error {
+	err := s.ClientStream.RecvMsg(m)
+	s.report(err)
+	return err
+}
+
+func (s *clientStream) report(err error) {
+	if err == nil || err == io.EOF || s.o.classify(err) != breaker.OutcomeFailure {
+		return
+	}
+
+	s.once.Do(func() {
+		s.cb.ReportErrorContext(s.Context())
+	})
+}
+
+//UnaryServerInterceptor rejects incoming calls with codes.Unavailable when cb is open, with the sleep window
+//as retry delay in trailer grpc-retry-pushback-ms. failure codes returned by handlers are reported as errors
+func UnaryServerInterceptor(cb *breaker.CircuitBreaker, opts ...Option) grpc.UnaryServerInterceptor {
+	return unaryServerInterceptor(func(string) *breaker.CircuitBreaker { return cb }, newOptions(opts))
+}
+
+//GroupUnaryServerInterceptor works like UnaryServerInterceptor with a circuit breaker per full method name from g
+func GroupUnaryServerInterceptor(g *breaker.Group, opts ...Option) grpc.UnaryServerInterceptor {
+	return unaryServerInterceptor(g.Get, newOptions(opts))
+}
+
+func unaryServerInterceptor(breakerFor func(method string) *breaker.CircuitBreaker, o *options) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		cb := breakerFor(info.FullMethod)
+		if err := cb.ReportRequestWithMeta(breaker.RequestMeta{Method: info.FullMethod}); err != nil {
+			grpc.SetTrailer(ctx, pushbackTrailer(cb))
+			return nil, rejectError(err)
+		}
+
+		start := time.Now()
+		resp, err := handler(ctx, req)
+		cb.ReportLatency(time.Since(start))
+
+		if o.classify(err) == breaker.OutcomeFailure {
+			cb.ReportErrorContext(ctx)
+		}
+
+		return resp, err
+	}
+}
+
+//StreamServerInterceptor rejects new streams with codes.Unavailable when cb is open and reports
+//failure codes returned by handlers as errors, unless WithStreamEstablishmentOnly is given
+func StreamServerInterceptor(cb *breaker.CircuitBreaker, opts ...Option) grpc.StreamServerInterceptor {
+	return streamServerInterceptor(func(string) *breaker.CircuitBreaker { return cb }, newOptions(opts))
+}
+
+//GroupStreamServerInterceptor works like StreamServerInterceptor with a circuit breaker per full method name from g
+func GroupStreamServerInterceptor(g *breaker.Group, opts ...Option) grpc.StreamServerInterceptor {
+	return streamServerInterceptor(g.Get, newOptions(opts))
+}
+
+func streamServerInterceptor(breakerFor func(method string) *breaker.CircuitBreaker, o *options) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		cb := breakerFor(info.FullMethod)
+		if err := cb.ReportRequestWithMeta(breaker.RequestMeta{Method: info.FullMethod}); err != nil {
+			ss.SetTrailer(pushbackTrailer(cb))
+			return rejectError(err)
+		}
+
+		err := handler(srv, ss)
+		if !o.establishmentOnly && o.classify(err) == breaker.OutcomeFailure {
+			cb.ReportErrorContext(ss.Context())
+		}
+
+		return err
+	}
+}
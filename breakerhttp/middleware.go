@@ -0,0 +1,154 @@
+package breakerhttp
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/carl-leopard/circuitbreaker/breaker"
+)
+
+type MiddlewareOption func(m *middleware)
+
+//WithStatusClassifier decides by status code whether a handled request counts as a failure, default 5xx
+func WithStatusClassifier(f func(code int) breaker.Outcome) MiddlewareOption {
+	return func(m *middleware) {
+		if f != nil {
+			m.classify = f
+		}
+	}
+}
+
+//WithRejectHandler replaces the default 503 response when circuit breaker is open
+func WithRejectHandler(h http.Handler) MiddlewareOption {
+	return func(m *middleware) {
+		if h != nil {
+			m.reject = h
+		}
+	}
+}
+
+type middleware struct {
+	breakerFor func(r *http.Request) *breaker.CircuitBreaker
+	next       http.Handler
+	classify   func(code int) breaker.Outcome
+	reject     http.Handler
+}
+
+//Middleware protects handlers from overload: 5xx responses and panics are reported as errors to cb,
+//requests are answered with 503 and Retry-After while cb is open
+func Middleware(cb *breaker.CircuitBreaker, opts ...MiddlewareOption) func(http.Handler) http.Handler {
+	return newMiddleware(func(*http.Request) *breaker.CircuitBreaker { return cb }, opts)
+}
+
+//GroupMiddleware works like Middleware with a circuit breaker per key from g. key should return a route
+//pattern rather than the raw path, so high-cardinality paths don't explode the breaker set, see MuxPattern.
+//requests of an empty key are not guarded
+func GroupMiddleware(g *breaker.Group, key func(r *http.Request) string, opts ...MiddlewareOption) func(http.Handler) http.Handler {
+	return newMiddleware(func(r *http.Request) *breaker.CircuitBreaker {
+		k := key(r)
+		if k == "" {
+			return nil
+		}
+		return g.Get(k)
+	}, opts)
+}
+
+//MuxPattern keys requests by the pattern of mux they match, e.g. "GET /users/{id}"
+func MuxPattern(mux *http.ServeMux) func(r *http.Request) string {
+	return func(r *http.Request) string {
+		_, pattern := mux.Handler(r)
+		return pattern
+	}
+}
+
+func newMiddleware(breakerFor func(r *http.Request) *breaker.CircuitBreaker, opts []MiddlewareOption) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		m := &middleware{
+			breakerFor: breakerFor,
+			next:       next,
+			classify: func(code int) breaker.Outcome {
+				if code >= http.StatusInternalServerError {
+					return breaker.OutcomeFailure
+				}
+				return breaker.OutcomeSuccess
+			},
+		}
+
+		for _, opt := range opts {
+			opt(m)
+		}
+
+		return m
+	}
+}
+
+func (m *middleware) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	cb := m.breakerFor(r)
+	if cb == nil {
+		m.next.ServeHTTP(w, r)
+		return
+	}
+
+	if err := cb.ReportRequest(); err != nil {
+		if m.reject != nil {
+			m.reject.ServeHTTP(w, r)
+			return
+		}
+
+		writeUnavailable(w, cb.RemainingSleepWindow())
+		return
+	}
+
+	rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+	start := time.Now()
+	defer func() {
+		cb.ReportLatency(time.Since(start))
+
+		if p := recover(); p != nil {
+			cb.ReportErrorContext(r.Context())
+			panic(p)
+		}
+
+		if m.classify(rec.status) == breaker.OutcomeFailure {
+			cb.ReportErrorContext(r.Context())
+		}
+	}()
+
+	m.next.ServeHTTP(rec, r)
+}
+
+func writeUnavailable(w http.ResponseWriter, retryAfter time.Duration) {
+	//rounded up, so clients don't come back while circuit breaker is still open
+	secs := int((retryAfter + time.Second - 1) / time.Second)
+	if secs < 1 {
+		secs = 1
+	}
+
+	w.Header().Set("Retry-After", strconv.Itoa(secs))
+	http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+}
+
+func (r *statusRecorder) WriteHeader(code int) {
+	if !r.wroteHeader {
+		r.status = code
+		r.wroteHeader = true
+	}
+	r.ResponseWriter.WriteHeader(code)
+}
+
+func (r *statusRecorder) Write(b []byte) (int, error) {
+	r.wroteHeader = true
+	return r.ResponseWriter.Write(b)
+}
+
+//Unwrap lets http.ResponseController reach the underlying writer
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
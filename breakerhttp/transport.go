@@ -0,0 +1,217 @@
+package breakerhttp
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/carl-leopard/circuitbreaker/breaker"
+)
+
+type TransportOption func(t *Transport)
+
+//Classifier decides whether a response counts as a failure
+type Classifier func(resp *http.Response) breaker.Outcome
+
+//DefaultClassifier treats 5xx and 429 as failures, other responses including 4xx as successes
+func DefaultClassifier(resp *http.Response) breaker.Outcome {
+	if resp.StatusCode >= http.StatusInternalServerError || resp.StatusCode == http.StatusTooManyRequests {
+		return breaker.OutcomeFailure
+	}
+
+	return breaker.OutcomeSuccess
+}
+
+//WithClassifier replaces DefaultClassifier
+func WithClassifier(f Classifier) TransportOption {
+	return func(t *Transport) {
+		if f != nil {
+			t.classify = f
+		}
+	}
+}
+
+//WithFailureStatusCodes treats responses of codes as failures and all others as successes
+func WithFailureStatusCodes(codes ...int) TransportOption {
+	set := make(map[int]struct{}, len(codes))
+	for _, code := range codes {
+		set[code] = struct{}{}
+	}
+
+	return WithClassifier(func(resp *http.Response) breaker.Outcome {
+		if _, ok := set[resp.StatusCode]; ok {
+			return breaker.OutcomeFailure
+		}
+
+		return breaker.OutcomeSuccess
+	})
+}
+
+//WithHostPortKey keys circuit breakers of a group transport by host+port instead of host
+func WithHostPortKey() TransportOption {
+	return func(t *Transport) {
+		t.key = func(req *http.Request) string {
+			return req.URL.Host
+		}
+	}
+}
+
+//WithKeyFunc keys circuit breakers of a group transport by f
+func WithKeyFunc(f func(req *http.Request) string) TransportOption {
+	return func(t *Transport) {
+		t.key = f
+	}
+}
+
+//WithRetryAfter makes Retry-After of 429 and 503 failure responses the sleep window of the trip they cause,
+//bounded by max
+func WithRetryAfter(max time.Duration) TransportOption {
+	return func(t *Transport) {
+		t.maxRetryAfter = max
+	}
+}
+
+//Transport is a http.RoundTripper guarded by circuit breakers
+type Transport struct {
+	breakerFor func(req *http.Request) *breaker.CircuitBreaker
+	key        func(req *http.Request) string //only for group transport
+	next       http.RoundTripper
+
+	classify      Classifier
+	maxRetryAfter time.Duration
+}
+
+//NewTransport return a transport which short-circuits requests when cb is open, reports network errors
+//and failure responses as errors and records latency. next defaults to http.DefaultTransport
+func NewTransport(cb *breaker.CircuitBreaker, next http.RoundTripper, opts ...TransportOption) *Transport {
+	t := newTransport(next, opts)
+	t.breakerFor = func(*http.Request) *breaker.CircuitBreaker {
+		return cb
+	}
+
+	return t
+}
+
+//NewNamedTransport works like NewTransport with the circuit breaker of r named name, looked up per request so
+//config reloads creating or removing it take effect. requests pass through unguarded while r has none of name
+func NewNamedTransport(r *breaker.Registry, name string, next http.RoundTripper, opts ...TransportOption) *Transport {
+	t := newTransport(next, opts)
+	t.breakerFor = func(*http.Request) *breaker.CircuitBreaker {
+		cb, _ := r.Get(name)
+		return cb
+	}
+
+	return t
+}
+
+//NewGroupTransport works like NewTransport with a circuit breaker per upstream host from g,
+//so one failing host doesn't short-circuit requests to healthy hosts
+func NewGroupTransport(g *breaker.Group, next http.RoundTripper, opts ...TransportOption) *Transport {
+	t := newTransport(next, opts)
+	t.breakerFor = func(req *http.Request) *breaker.CircuitBreaker {
+		return g.Get(t.key(req))
+	}
+
+	return t
+}
+
+//NewRegistryTransport works like NewTransport with the circuit breaker the http integrations of r route the host
+//of a request to, see breaker.Integration. requests to hosts routed nowhere pass through unguarded
+func NewRegistryTransport(r *breaker.Registry, next http.RoundTripper, opts ...TransportOption) *Transport {
+	t := newTransport(next, opts)
+	t.breakerFor = func(req *http.Request) *breaker.CircuitBreaker {
+		cb, _ := r.Route(breaker.IntegrationHTTP, t.key(req))
+		return cb
+	}
+
+	return t
+}
+
+func newTransport(next http.RoundTripper, opts []TransportOption) *Transport {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+
+	t := &Transport{
+		key: func(req *http.Request) string {
+			return req.URL.Hostname()
+		},
+		next: next,
+
+		classify: DefaultClassifier,
+	}
+
+	for _, opt := range opts {
+		opt(t)
+	}
+
+	return t
+}
+
+//RoundTrip implements http.RoundTripper
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	cb := t.breakerFor(req)
+	if cb == nil {
+		return t.next.RoundTrip(req)
+	}
+	if err := cb.ReportRequest(); err != nil {
+		if req.Body != nil {
+			req.Body.Close()
+		}
+		return nil, err
+	}
+
+	start := time.Now()
+	resp, err := t.next.RoundTrip(req)
+	cb.ReportLatency(time.Since(start))
+
+	switch {
+	case err != nil:
+		//canceled by caller, not a fault of backend
+		if !errors.Is(err, context.Canceled) {
+			cb.ReportErrorContext(req.Context())
+		}
+	case t.classify(resp) == breaker.OutcomeFailure:
+		if t.maxRetryAfter > 0 &&
+			(resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusServiceUnavailable) {
+			if d, ok := parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()); ok {
+				if d > t.maxRetryAfter {
+					d = t.maxRetryAfter
+				}
+				cb.SuggestSleepWindow(d)
+			}
+		}
+
+		cb.ReportErrorContext(req.Context())
+	}
+
+	return resp, err
+}
+
+//parseRetryAfter parses Retry-After in delay-seconds or HTTP-date
+func parseRetryAfter(v string, now time.Time) (time.Duration, bool) {
+	if v == "" {
+		return 0, false
+	}
+
+	if secs, err := strconv.Atoi(v); err == nil {
+		if secs <= 0 {
+			return 0, false
+		}
+		//clamped before the multiply so a huge delay doesn't overflow into a short or negative one
+		n := int64(secs)
+		if n > int64(math.MaxInt64/time.Second) {
+			n = int64(math.MaxInt64 / time.Second)
+		}
+		return time.Duration(n) * time.Second, true
+	}
+
+	if at, err := http.ParseTime(v); err == nil && at.After(now) {
+		return at.Sub(now), true
+	}
+
+	return 0, false
+}
@@ -0,0 +1,133 @@
+package breakerjob
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"time"
+
+	"github.com/carl-leopard/circuitbreaker/breaker"
+)
+
+//Handler processes a job, J is the job type of the queue library, e.g. *asynq.Task or *river.Job
+type Handler[J any] func(ctx context.Context, job J) error
+
+//Classifier decides whether an error returned by a handler counts as a failure
+type Classifier func(err error) breaker.Outcome
+
+//DefaultClassifier treats any error as failure except cancellation, which happens on worker shutdown
+func DefaultClassifier(err error) breaker.Outcome {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return breaker.OutcomeSuccess
+	}
+
+	return breaker.OutcomeFailure
+}
+
+//Backoff returns how long a job rejected by the open cb should wait before it is retried
+type Backoff func(cb *breaker.CircuitBreaker) time.Duration
+
+//DefaultBackoff waits the remaining sleep window of cb plus up to 20% jitter, so rejected jobs do not all come back at once
+func DefaultBackoff(cb *breaker.CircuitBreaker) time.Duration {
+	d := cb.RemainingSleepWindow()
+	if d <= 0 {
+		return time.Second
+	}
+
+	return d + time.Duration(rand.Int63n(int64(d)/5+1))
+}
+
+//RejectedError is returned by a wrapped handler without running the job while its breaker is open.
+//queue adapters should nack the job and reschedule it after Delay instead of counting it as a failed attempt
+type RejectedError struct {
+	Key   string
+	Delay time.Duration
+	Err   error
+}
+
+func (e *RejectedError) Error() string {
+	return "breakerjob: " + e.Key + ": " + e.Err.Error()
+}
+
+func (e *RejectedError) Unwrap() error {
+	return e.Err
+}
+
+//IsRejected reports whether err is a *RejectedError and returns its delay
+func IsRejected(err error) (time.Duration, bool) {
+	var re *RejectedError
+	if errors.As(err, &re) {
+		return re.Delay, true
+	}
+
+	return 0, false
+}
+
+type Option func(o *options)
+
+//WithClassifier replaces DefaultClassifier
+func WithClassifier(f Classifier) Option {
+	return func(o *options) {
+		if f != nil {
+			o.classify = f
+		}
+	}
+}
+
+//WithBackoff replaces DefaultBackoff
+func WithBackoff(f Backoff) Option {
+	return func(o *options) {
+		if f != nil {
+			o.backoff = f
+		}
+	}
+}
+
+type options struct {
+	classify Classifier
+	backoff  Backoff
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{
+		classify: DefaultClassifier,
+		backoff:  DefaultBackoff,
+	}
+
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	return o
+}
+
+//Wrap runs h under cb. while cb is open jobs are not run and *RejectedError is returned, failed jobs are reported to cb
+func Wrap[J any](cb *breaker.CircuitBreaker, h Handler[J], opts ...Option) Handler[J] {
+	return wrap(func(J) *breaker.CircuitBreaker { return cb }, func(J) string { return cb.Name() }, h, newOptions(opts))
+}
+
+//WrapGroup works like Wrap with a circuit breaker per job type from g, jobType returns the type of a job,
+//e.g. the task name
+func WrapGroup[J any](g *breaker.Group, jobType func(J) string, h Handler[J], opts ...Option) Handler[J] {
+	return wrap(func(job J) *breaker.CircuitBreaker { return g.Get(jobType(job)) }, jobType, h, newOptions(opts))
+}
+
+func wrap[J any](breakerFor func(J) *breaker.CircuitBreaker, keyOf func(J) string, h Handler[J], o *options) Handler[J] {
+	return func(ctx context.Context, job J) error {
+		cb := breakerFor(job)
+		key := keyOf(job)
+		if err := cb.ReportRequestWithMeta(breaker.RequestMeta{Key: key}); err != nil {
+			return &RejectedError{Key: key, Delay: o.backoff(cb), Err: err}
+		}
+
+		start := time.Now()
+		err := h(ctx, job)
+		cb.ReportLatency(time.Since(start))
+
+		if o.classify(err) == breaker.OutcomeFailure {
+			cb.ReportErrorContext(ctx)
+		}
+
+		return err
+	}
+}
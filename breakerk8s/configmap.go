@@ -0,0 +1,65 @@
+package breakerk8s
+
+import (
+	"context"
+	"path/filepath"
+
+	"github.com/carl-leopard/circuitbreaker/breaker"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+)
+
+//WatchConfigMapDir reloads config of key of a ConfigMap mounted at dir into r until ctx is done, e.g.
+//key breakers.yaml of a volume mounted at /etc/circuitbreaker. kubelet updates the volume by swapping
+//its ..data symlink, which the watcher follows as it watches the directory, see breaker.ConfigWatcher.
+//subPath mounts are never updated by kubelet, mount the whole volume
+func WatchConfigMapDir(ctx context.Context, r *breaker.Registry, dir, key string, opts ...breaker.ReloadOption) error {
+	return breaker.WatchConfig(r, filepath.Join(dir, key), opts...).Run(ctx)
+}
+
+//WatchConfigMap reloads config of key of ConfigMap namespace/name into r from the API server until ctx is
+//done, its format is chosen by extension of key. the ConfigMap is watched by an informer, so it's reloaded
+//on every update without mounting it. while the ConfigMap or its key is missing r keeps its config
+func WatchConfigMap(ctx context.Context, client kubernetes.Interface, namespace, name, key string, r *breaker.Registry,
+	opts ...breaker.ReloadOption) error {
+	w := breaker.WatchConfig(r, key, opts...)
+	reload := func(obj interface{}) {
+		cm, ok := obj.(*corev1.ConfigMap)
+		if !ok || cm.Name != name {
+			return
+		}
+		if data, ok := cm.Data[key]; ok {
+			w.Notify([]byte(data))
+		} else if data, ok := cm.BinaryData[key]; ok {
+			w.Notify(data)
+		}
+	}
+
+	selector := fields.OneTermEqualSelector("metadata.name", name).String()
+	cms := client.CoreV1().ConfigMaps(namespace)
+	lw := &cache.ListWatch{
+		ListWithContextFunc: func(ctx context.Context, opts metav1.ListOptions) (runtime.Object, error) {
+			opts.FieldSelector = selector
+			return cms.List(ctx, opts)
+		},
+		WatchFuncWithContext: func(ctx context.Context, opts metav1.ListOptions) (watch.Interface, error) {
+			opts.FieldSelector = selector
+			return cms.Watch(ctx, opts)
+		},
+	}
+	informer := cache.NewSharedInformer(cache.ToListWatcherWithWatchListSemantics(lw, client), &corev1.ConfigMap{}, 0)
+	if _, err := informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    reload,
+		UpdateFunc: func(_, obj interface{}) { reload(obj) },
+	}); err != nil {
+		return err
+	}
+
+	informer.RunWithContext(ctx)
+	return ctx.Err()
+}
@@ -0,0 +1,83 @@
+package breakerk8s
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/carl-leopard/circuitbreaker/breaker"
+	"github.com/carl-leopard/circuitbreaker/breakerhttp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/transport"
+)
+
+//Wrapper returns a transport.WrapperFunc guarding API-server calls with cb, see breakerhttp.NewTransport for opts.
+//while cb is open calls fail fast with a 429 Status suggesting the rest of the sleep window as delay, so callers see
+//apierrors.IsTooManyRequests and apierrors.SuggestsClientDelay instead of opaque transport errors.
+//Retry-After header is left out on purpose, otherwise the rest client would block retrying up to 10 sleep windows
+func Wrapper(cb *breaker.CircuitBreaker, opts ...breakerhttp.TransportOption) transport.WrapperFunc {
+	return func(rt http.RoundTripper) http.RoundTripper {
+		return &roundTripper{
+			cb:   cb,
+			next: breakerhttp.NewTransport(cb, rt, opts...),
+		}
+	}
+}
+
+//Configure adds Wrapper to cfg, e.g. before creating a clientset or controller manager
+func Configure(cfg *rest.Config, cb *breaker.CircuitBreaker, opts ...breakerhttp.TransportOption) {
+	cfg.Wrap(Wrapper(cb, opts...))
+}
+
+type roundTripper struct {
+	cb   *breaker.CircuitBreaker
+	next http.RoundTripper
+}
+
+func (t *roundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.next.RoundTrip(req)
+	if !errors.Is(err, breaker.ErrTooManyErrors) {
+		return resp, err
+	}
+
+	return rejectResponse(req, t.cb.RemainingSleepWindow()), nil
+}
+
+//WrappedRoundTripper implements net.RoundTripperWrapper, so client-go can reach the underlying transport
+func (t *roundTripper) WrappedRoundTripper() http.RoundTripper {
+	return t.next
+}
+
+func rejectResponse(req *http.Request, retryAfter time.Duration) *http.Response {
+	//rounded up, so clients don't come back while circuit breaker is still open
+	secs := int((retryAfter + time.Second - 1) / time.Second)
+	if secs < 1 {
+		secs = 1
+	}
+
+	body, _ := json.Marshal(&metav1.Status{
+		TypeMeta: metav1.TypeMeta{Kind: "Status", APIVersion: "v1"},
+		Status:   metav1.StatusFailure,
+		Message:  "circuit breaker: " + breaker.ErrTooManyErrors.Error(),
+		Reason:   metav1.StatusReasonTooManyRequests,
+		Details:  &metav1.StatusDetails{RetryAfterSeconds: int32(secs)},
+		Code:     http.StatusTooManyRequests,
+	})
+
+	return &http.Response{
+		Status:        strconv.Itoa(http.StatusTooManyRequests) + " " + http.StatusText(http.StatusTooManyRequests),
+		StatusCode:    http.StatusTooManyRequests,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header{"Content-Type": []string{"application/json"}},
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
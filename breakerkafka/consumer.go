@@ -0,0 +1,72 @@
+package breakerkafka
+
+import (
+	"context"
+	"time"
+
+	"github.com/carl-leopard/circuitbreaker/breaker"
+)
+
+//Pauser is implemented by sarama.ConsumerGroup and sarama.Consumer
+type Pauser interface {
+	PauseAll()
+	ResumeAll()
+}
+
+//PauseOnOpen pauses consumption of p while any of cbs, e.g. the database messages are written to, is open
+//and resumes it once all of them let requests pass again. it checks every interval and blocks until ctx is done.
+//paused consumers keep heartbeating, so outages don't cause poison loops or rebalance storms
+func PauseOnOpen(ctx context.Context, p Pauser, interval time.Duration, cbs ...*breaker.CircuitBreaker) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+
+	paused := false
+	for {
+		if open := anyOpen(cbs); open != paused {
+			if open {
+				p.PauseAll()
+			} else {
+				p.ResumeAll()
+			}
+			paused = open
+		}
+
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+//WaitClosed blocks while any of cbs is open, checking every interval. pull-based consumers like kafka-go's
+//Reader call it before fetching the next message
+func WaitClosed(ctx context.Context, interval time.Duration, cbs ...*breaker.CircuitBreaker) error {
+	if !anyOpen(cbs) {
+		return nil
+	}
+
+	t := time.NewTicker(interval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-t.C:
+			if !anyOpen(cbs) {
+				return nil
+			}
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func anyOpen(cbs []*breaker.CircuitBreaker) bool {
+	for _, cb := range cbs {
+		if cb.Status() == breaker.CircuitBreakerStatusOpen {
+			return true
+		}
+	}
+
+	return false
+}
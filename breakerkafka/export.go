@@ -0,0 +1,184 @@
+package breakerkafka
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"github.com/carl-leopard/circuitbreaker/breaker"
+	"github.com/segmentio/kafka-go"
+)
+
+const (
+	//EventSchemaVersion version of EventRecord, bumped on incompatible changes
+	EventSchemaVersion = 1
+
+	defaultExportBuffer = 4096
+	defaultExportBatch  = 100
+)
+
+//EventRecord is the value of every message written by Exporter, JSON encoded and keyed by breaker name,
+//so events of a breaker stay in order within a partition.
+//
+//	{"version":1,"breaker":"api.example.com","instance":"web-1-4242","type":"trip","time":"2024-05-01T12:00:00.123456789Z",
+//	 "status":"open","cause":"errors","open_until":"2024-05-01T12:03:00.123456789Z","sleep_window_ms":180000}
+//
+//type is one of trip, half-open, reject and skip, status one of closed, open and half-open.
+//cause is one of errors, sleep-window, shared-window, manual, peer and restored.
+//fields without a value are omitted. new fields may be added within a version, consumers should ignore unknown ones
+type EventRecord struct {
+	Version  int    `json:"version"`            //EventSchemaVersion
+	Breaker  string `json:"breaker"`            //name of the breaker
+	Instance string `json:"instance,omitempty"` //instance the event happened on, see WithExportInstance
+
+	Type   string    `json:"type"`
+	Time   time.Time `json:"time"`   //RFC 3339 with nanoseconds
+	Status string    `json:"status"` //status after the event
+
+	Cause         string     `json:"cause,omitempty"`           //why status changed, for trip and half-open
+	OpenUntil     *time.Time `json:"open_until,omitempty"`      //end of sleep window, for trip
+	SleepWindowMs int64      `json:"sleep_window_ms,omitempty"` //length of sleep window, for trip
+
+	Key    string `json:"key,omitempty"`    //caller supplied key, for sampled reject and skip
+	Method string `json:"method,omitempty"` //caller supplied method, for sampled reject
+}
+
+//NewEventRecord converts e into its exported form
+func NewEventRecord(instance string, e breaker.Event) EventRecord {
+	r := EventRecord{
+		Version:  EventSchemaVersion,
+		Breaker:  e.Name,
+		Instance: instance,
+		Type:     e.Type.String(),
+		Time:     e.Time,
+		Status:   breaker.StatusText(e.Status),
+		Key:      e.Meta.Key,
+		Method:   e.Meta.Method,
+	}
+	if e.Cause != 0 {
+		r.Cause = e.Cause.String()
+	}
+	if !e.Until.IsZero() {
+		until := e.Until
+		r.OpenUntil = &until
+		r.SleepWindowMs = e.Until.Sub(e.Time).Round(time.Millisecond).Milliseconds()
+	}
+
+	return r
+}
+
+//MessageWriter is implemented by kafka.Writer and Writer
+type MessageWriter interface {
+	WriteMessages(ctx context.Context, msgs ...kafka.Message) error
+}
+
+type ExporterOption func(e *Exporter)
+
+//WithExportTopic sets the topic of messages, leave it empty if the writer has one
+func WithExportTopic(topic string) ExporterOption {
+	return func(e *Exporter) {
+		e.topic = topic
+	}
+}
+
+//WithExportInstance sets instance of records, default hostname-pid
+func WithExportInstance(id string) ExporterOption {
+	return func(e *Exporter) {
+		e.instance = id
+	}
+}
+
+//WithExportBuffer sets how many events are queued for Run, default 4096. events beyond are dropped, see Dropped
+func WithExportBuffer(size int) ExporterOption {
+	return func(e *Exporter) {
+		if size > 0 {
+			e.queue = make(chan breaker.Event, size)
+		}
+	}
+}
+
+//Exporter streams events of circuit breakers to kafka for offline analytics, see EventRecord for the schema.
+//give Listener to circuit breakers by breaker.WithEventListener and keep Run running. rejected requests are
+//only exported when sampled, see breaker.WithRejectSampling
+type Exporter struct {
+	w        MessageWriter
+	topic    string
+	instance string
+
+	queue   chan breaker.Event
+	dropped uint64
+}
+
+//NewExporter return an exporter writing to w. don't pass a Writer guarded by breakers exporting to it
+func NewExporter(w MessageWriter, opts ...ExporterOption) *Exporter {
+	host, _ := os.Hostname()
+	e := &Exporter{
+		w:        w,
+		instance: fmt.Sprintf("%s-%d", host, os.Getpid()),
+		queue:    make(chan breaker.Event, defaultExportBuffer),
+	}
+
+	for _, opt := range opts {
+		opt(e)
+	}
+
+	return e
+}
+
+//Listener returns the listener to give to breaker.WithEventListener, it never blocks
+func (e *Exporter) Listener() func(breaker.Event) {
+	return func(ev breaker.Event) {
+		select {
+		case e.queue <- ev:
+		default:
+			atomic.AddUint64(&e.dropped, 1)
+		}
+	}
+}
+
+//Dropped returns how many events were dropped because the queue was full
+func (e *Exporter) Dropped() uint64 {
+	return atomic.LoadUint64(&e.dropped)
+}
+
+//Run writes queued events in batches until ctx is done. a batch failing to write is dropped and its error
+//returned, call Run again to go on
+func (e *Exporter) Run(ctx context.Context) error {
+	batch := make([]kafka.Message, 0, defaultExportBatch)
+	for {
+		select {
+		case ev := <-e.queue:
+			batch = append(batch[:0], e.message(ev))
+		drain:
+			for len(batch) < defaultExportBatch {
+				select {
+				case ev := <-e.queue:
+					batch = append(batch, e.message(ev))
+				default:
+					break drain
+				}
+			}
+
+			if err := e.w.WriteMessages(ctx, batch...); err != nil {
+				return err
+			}
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func (e *Exporter) message(ev breaker.Event) kafka.Message {
+	//EventRecord always encodes
+	v, _ := json.Marshal(NewEventRecord(e.instance, ev))
+
+	return kafka.Message{
+		Topic: e.topic,
+		Key:   []byte(ev.Name),
+		Value: v,
+		Time:  ev.Time,
+	}
+}
@@ -0,0 +1,92 @@
+package breakerkafka
+
+import (
+	"errors"
+	"sync"
+)
+
+var errBufferFull = errors.New("breakerkafka: buffer is full")
+
+type Option func(o *options)
+
+//WithBuffer buffers up to size messages per topic while its breaker is open instead of failing fast.
+//buffered messages are sent before new ones as soon as the breaker lets requests pass again, or by Flush
+func WithBuffer(size int) Option {
+	return func(o *options) {
+		o.bufferSize = size
+	}
+}
+
+type options struct {
+	bufferSize int
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	return o
+}
+
+//spool buffers messages per topic while their breaker is open
+type spool[M any] struct {
+	mu     sync.Mutex
+	size   int
+	topics map[string][]M
+}
+
+func newSpool[M any](size int) *spool[M] {
+	return &spool[M]{size: size, topics: make(map[string][]M)}
+}
+
+func (s *spool[M]) enabled() bool {
+	return s.size > 0
+}
+
+func (s *spool[M]) push(topic string, msgs ...M) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.topics[topic])+len(msgs) > s.size {
+		return errBufferFull
+	}
+	s.topics[topic] = append(s.topics[topic], msgs...)
+
+	return nil
+}
+
+//take removes and returns buffered messages of topic
+func (s *spool[M]) take(topic string) []M {
+	s.mu.Lock()
+	msgs := s.topics[topic]
+	delete(s.topics, topic)
+	s.mu.Unlock()
+
+	return msgs
+}
+
+//putBack returns messages failed to send to the front of the buffer, dropping what doesn't fit
+func (s *spool[M]) putBack(topic string, msgs []M) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	msgs = append(msgs, s.topics[topic]...)
+	if len(msgs) > s.size {
+		msgs = msgs[:s.size]
+	}
+	s.topics[topic] = msgs
+}
+
+func (s *spool[M]) topicNames() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	names := make([]string, 0, len(s.topics))
+	for name := range s.topics {
+		names = append(names, name)
+	}
+
+	return names
+}
@@ -0,0 +1,134 @@
+package breakerkafka
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/carl-leopard/circuitbreaker/breaker"
+	"github.com/segmentio/kafka-go"
+)
+
+//Writer wraps kafka.Writer with a circuit breaker per topic, see SyncProducer
+type Writer struct {
+	*kafka.Writer
+
+	g     *breaker.Group
+	spool *spool[kafka.Message]
+}
+
+//NewWriter return a writer keyed by topic from g
+func NewWriter(w *kafka.Writer, g *breaker.Group, opts ...Option) *Writer {
+	o := newOptions(opts)
+
+	return &Writer{
+		Writer: w,
+		g:      g,
+		spool:  newSpool[kafka.Message](o.bufferSize),
+	}
+}
+
+func isKafkaGoFailure(err error) bool {
+	return err != nil &&
+		!errors.Is(err, context.Canceled) &&
+		!errors.Is(err, kafka.MessageSizeTooLarge)
+}
+
+//WriteMessages writes msgs topic by topic, messages without a topic go to the topic of the writer
+func (w *Writer) WriteMessages(ctx context.Context, msgs ...kafka.Message) error {
+	var order []string
+	byTopic := make(map[string][]kafka.Message)
+	for _, msg := range msgs {
+		topic := msg.Topic
+		if topic == "" {
+			topic = w.Topic
+		}
+		if _, ok := byTopic[topic]; !ok {
+			order = append(order, topic)
+		}
+		byTopic[topic] = append(byTopic[topic], msg)
+	}
+
+	var errs []error
+	for _, topic := range order {
+		if err := w.writeTopic(ctx, topic, byTopic[topic]); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+//Flush writes buffered messages of all topics whose breaker allows it
+func (w *Writer) Flush(ctx context.Context) error {
+	var errs []error
+	for _, topic := range w.spool.topicNames() {
+		cb := w.g.Get(topic)
+		if err := cb.ReportRequest(); err != nil {
+			continue
+		}
+
+		if err := w.flushTopic(ctx, cb, topic); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (w *Writer) writeTopic(ctx context.Context, topic string, msgs []kafka.Message) error {
+	cb := w.g.Get(topic)
+	if err := cb.ReportRequest(); err != nil {
+		if w.spool.enabled() && errors.Is(err, breaker.ErrTooManyErrors) {
+			return w.spool.push(topic, msgs...)
+		}
+		return err
+	}
+
+	if err := w.flushTopic(ctx, cb, topic); err != nil {
+		return err
+	}
+
+	start := time.Now()
+	err := w.Writer.WriteMessages(ctx, msgs...)
+	cb.ReportLatency(time.Since(start))
+
+	if isKafkaGoFailure(err) {
+		cb.ReportErrorContext(ctx)
+	}
+
+	return err
+}
+
+func (w *Writer) flushTopic(ctx context.Context, cb *breaker.CircuitBreaker, topic string) error {
+	if !w.spool.enabled() {
+		return nil
+	}
+
+	msgs := w.spool.take(topic)
+	if len(msgs) == 0 {
+		return nil
+	}
+
+	if err := w.Writer.WriteMessages(ctx, msgs...); err != nil {
+		var failed []kafka.Message
+		var werrs kafka.WriteErrors
+		if errors.As(err, &werrs) {
+			for i, werr := range werrs {
+				if werr != nil {
+					failed = append(failed, msgs[i])
+				}
+			}
+		} else {
+			failed = msgs
+		}
+		w.spool.putBack(topic, failed)
+
+		if isKafkaGoFailure(err) {
+			cb.ReportErrorContext(ctx)
+		}
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,170 @@
+package breakerkafka
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/carl-leopard/circuitbreaker/breaker"
+)
+
+//SyncProducer wraps sarama.SyncProducer with a circuit breaker per topic. while the breaker of a topic
+//is open, sends fail fast with breaker.ErrTooManyErrors, or are buffered if WithBuffer is given, in which
+//case SendMessage returns partition and offset -1
+type SyncProducer struct {
+	sarama.SyncProducer
+
+	g     *breaker.Group
+	spool *spool[*sarama.ProducerMessage]
+}
+
+//NewSyncProducer return a producer keyed by topic from g
+func NewSyncProducer(p sarama.SyncProducer, g *breaker.Group, opts ...Option) *SyncProducer {
+	o := newOptions(opts)
+
+	return &SyncProducer{
+		SyncProducer: p,
+		g:            g,
+		spool:        newSpool[*sarama.ProducerMessage](o.bufferSize),
+	}
+}
+
+//isSaramaFailure messages rejected by the broker as invalid say nothing about delivery health
+func isSaramaFailure(err error) bool {
+	return err != nil &&
+		!errors.Is(err, sarama.ErrMessageSizeTooLarge) &&
+		!errors.Is(err, sarama.ErrInvalidMessage)
+}
+
+func (p *SyncProducer) SendMessage(msg *sarama.ProducerMessage) (partition int32, offset int64, err error) {
+	cb := p.g.Get(msg.Topic)
+	if err := cb.ReportRequest(); err != nil {
+		if p.spool.enabled() && errors.Is(err, breaker.ErrTooManyErrors) {
+			if err := p.spool.push(msg.Topic, msg); err != nil {
+				return -1, -1, err
+			}
+			return -1, -1, nil
+		}
+		return -1, -1, err
+	}
+
+	if err := p.flushTopic(cb, msg.Topic); err != nil {
+		return -1, -1, err
+	}
+
+	start := time.Now()
+	partition, offset, err = p.SyncProducer.SendMessage(msg)
+	cb.ReportLatency(time.Since(start))
+
+	if isSaramaFailure(err) {
+		cb.ReportErrorContext(context.Background())
+	}
+
+	return partition, offset, err
+}
+
+//SendMessages sends msgs topic by topic, see SendMessage
+func (p *SyncProducer) SendMessages(msgs []*sarama.ProducerMessage) error {
+	var order []string
+	byTopic := make(map[string][]*sarama.ProducerMessage)
+	for _, msg := range msgs {
+		if _, ok := byTopic[msg.Topic]; !ok {
+			order = append(order, msg.Topic)
+		}
+		byTopic[msg.Topic] = append(byTopic[msg.Topic], msg)
+	}
+
+	var errs sarama.ProducerErrors
+	for _, topic := range order {
+		if err := p.sendTopic(topic, byTopic[topic]); err != nil {
+			var perrs sarama.ProducerErrors
+			if errors.As(err, &perrs) {
+				errs = append(errs, perrs...)
+				continue
+			}
+			for _, msg := range byTopic[topic] {
+				errs = append(errs, &sarama.ProducerError{Msg: msg, Err: err})
+			}
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+
+	return nil
+}
+
+//Flush sends buffered messages of all topics whose breaker allows it
+func (p *SyncProducer) Flush() error {
+	var first error
+	for _, topic := range p.spool.topicNames() {
+		cb := p.g.Get(topic)
+		if err := cb.ReportRequest(); err != nil {
+			continue
+		}
+
+		if err := p.flushTopic(cb, topic); err != nil && first == nil {
+			first = err
+		}
+	}
+
+	return first
+}
+
+func (p *SyncProducer) sendTopic(topic string, msgs []*sarama.ProducerMessage) error {
+	cb := p.g.Get(topic)
+	if err := cb.ReportRequest(); err != nil {
+		if p.spool.enabled() && errors.Is(err, breaker.ErrTooManyErrors) {
+			return p.spool.push(topic, msgs...)
+		}
+		return err
+	}
+
+	if err := p.flushTopic(cb, topic); err != nil {
+		return err
+	}
+
+	start := time.Now()
+	err := p.SyncProducer.SendMessages(msgs)
+	cb.ReportLatency(time.Since(start))
+
+	if isSaramaFailure(err) {
+		cb.ReportErrorContext(context.Background())
+	}
+
+	return err
+}
+
+//flushTopic sends buffered messages of topic, cb has already let the request pass
+func (p *SyncProducer) flushTopic(cb *breaker.CircuitBreaker, topic string) error {
+	if !p.spool.enabled() {
+		return nil
+	}
+
+	msgs := p.spool.take(topic)
+	if len(msgs) == 0 {
+		return nil
+	}
+
+	if err := p.SyncProducer.SendMessages(msgs); err != nil {
+		var failed []*sarama.ProducerMessage
+		var perrs sarama.ProducerErrors
+		if errors.As(err, &perrs) {
+			for _, perr := range perrs {
+				failed = append(failed, perr.Msg)
+			}
+		} else {
+			failed = msgs
+		}
+		p.spool.putBack(topic, failed)
+
+		if isSaramaFailure(err) {
+			cb.ReportErrorContext(context.Background())
+		}
+		return err
+	}
+
+	return nil
+}
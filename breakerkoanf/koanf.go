@@ -0,0 +1,90 @@
+package breakerkoanf
+
+import (
+	"log"
+	"reflect"
+	"strings"
+
+	"github.com/carl-leopard/circuitbreaker/breaker"
+	"github.com/go-viper/mapstructure/v2"
+	"github.com/knadh/koanf/v2"
+)
+
+//unmarshalConf decodes by the mapstructure tags of breaker.Settings, turning strings like "30s" into durations
+//and strings like "20%" into percentages, unknown keys fail if st is strict. koanf sets the result of the
+//decoder config, so it's made per call
+func unmarshalConf(st breaker.ConfigStrictness) koanf.UnmarshalConf {
+	return koanf.UnmarshalConf{
+		Tag: "mapstructure",
+		DecoderConfig: &mapstructure.DecoderConfig{
+			DecodeHook:       mapstructure.ComposeDecodeHookFunc(mapstructure.StringToTimeDurationHookFunc(), stringToPercent),
+			WeaklyTypedInput: true,
+			ErrorUnused:      st == breaker.ConfigStrict,
+		},
+	}
+}
+
+//stringToPercent decodes strings like "20%" into uint8 fields, only percentages are uint8 in breaker.Settings
+func stringToPercent(from, to reflect.Type, data interface{}) (interface{}, error) {
+	if from.Kind() != reflect.String || to.Kind() != reflect.Uint8 {
+		return data, nil
+	}
+
+	return breaker.ParsePercent(data.(string))
+}
+
+//Decode decodes breaker.Config at path of k, the whole tree if path is empty. durations may be strings
+//like "30s" and "5m", percentages strings like "20%", e.g.
+//
+//	circuitbreakers:
+//	  breakers:
+//	    - name: payments
+//	      sleep_window: 30s
+func Decode(k *koanf.Koanf, path string) (*breaker.Config, error) {
+	return DecodeStrictness(k, path, breaker.ConfigDefault)
+}
+
+//DecodeStrictness works like Decode with unknown keys and values out of range treated as of st, see
+//breaker.ConfigStrictness
+func DecodeStrictness(k *koanf.Koanf, path string, st breaker.ConfigStrictness) (*breaker.Config, error) {
+	var cfg breaker.Config
+	if err := k.UnmarshalWithConf(path, &cfg, unmarshalConf(st)); err != nil {
+		return nil, err
+	}
+	if st == breaker.ConfigLenient {
+		if err := cfg.Clamp(); err != nil {
+			log.Printf("circuit breaker config out of range, clamped: %v", strings.ReplaceAll(err.Error(), "\n", "; "))
+		}
+	}
+
+	return &cfg, nil
+}
+
+//DecodeSettings decodes breaker.Settings of a single circuit breaker at path of k
+func DecodeSettings(k *koanf.Koanf, path string) (breaker.Settings, error) {
+	var s breaker.Settings
+	err := k.UnmarshalWithConf(path, &s, unmarshalConf(breaker.ConfigDefault))
+
+	return s, err
+}
+
+//Load decodes breaker.Config at path of k and returns a registry of its circuit breakers, like breaker.LoadConfig
+func Load(k *koanf.Koanf, path string, opts ...breaker.CircuitBreakerOption) (*breaker.Registry, error) {
+	return LoadStrictness(k, path, breaker.ConfigDefault, opts...)
+}
+
+//LoadStrictness works like Load with unknown keys and values out of range treated as of st
+func LoadStrictness(k *koanf.Koanf, path string, st breaker.ConfigStrictness,
+	opts ...breaker.CircuitBreakerOption) (*breaker.Registry, error) {
+	cfg, err := DecodeStrictness(k, path, st)
+	if err != nil {
+		return nil, err
+	}
+
+	r := breaker.NewRegistry()
+	if err := cfg.Apply(r, opts...); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
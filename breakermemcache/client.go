@@ -0,0 +1,200 @@
+package breakermemcache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/bradfitz/gomemcache/memcache"
+	"github.com/carl-leopard/circuitbreaker/breaker"
+)
+
+//Client wraps memcache.Client with a circuit breaker per server. while the breaker of a server is open,
+//reads of its keys are cache misses and writes fail fast with breaker.ErrTooManyErrors.
+//methods not overridden here, e.g. FlushAll, are not guarded
+type Client struct {
+	*memcache.Client
+
+	ss memcache.ServerSelector
+	g  *breaker.Group
+}
+
+//New works like memcache.New, breakers are keyed by server address from g
+func New(g *breaker.Group, server ...string) *Client {
+	ss := new(memcache.ServerList)
+	ss.SetServers(server...)
+
+	return NewFromSelector(g, ss)
+}
+
+//NewFromSelector works like memcache.NewFromSelector
+func NewFromSelector(g *breaker.Group, ss memcache.ServerSelector) *Client {
+	return &Client{
+		Client: memcache.NewFromSelector(ss),
+		ss:     ss,
+		g:      g,
+	}
+}
+
+//isFailure protocol answers prove the server is healthy, everything else, e.g. network errors or timeouts is not
+func isFailure(err error) bool {
+	switch {
+	case err == nil,
+		errors.Is(err, memcache.ErrCacheMiss),
+		errors.Is(err, memcache.ErrCASConflict),
+		errors.Is(err, memcache.ErrNotStored),
+		errors.Is(err, memcache.ErrMalformedKey),
+		errors.Is(err, memcache.ErrNoStats):
+		return false
+	default:
+		return true
+	}
+}
+
+func (c *Client) breakerFor(key string) (*breaker.CircuitBreaker, error) {
+	addr, err := c.ss.PickServer(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.g.Get(addr.String()), nil
+}
+
+func (c *Client) do(key string, fn func() error) error {
+	cb, err := c.breakerFor(key)
+	if err != nil {
+		return err
+	}
+
+	if err := cb.ReportRequest(); err != nil {
+		return err
+	}
+
+	start := time.Now()
+	err = fn()
+	cb.ReportLatency(time.Since(start))
+
+	if isFailure(err) {
+		cb.ReportErrorContext(context.Background())
+	}
+
+	return err
+}
+
+func (c *Client) Get(key string) (item *memcache.Item, err error) {
+	err = c.do(key, func() (err error) {
+		item, err = c.Client.Get(key)
+		return err
+	})
+	if errors.Is(err, breaker.ErrTooManyErrors) {
+		return nil, memcache.ErrCacheMiss
+	}
+
+	return item, err
+}
+
+func (c *Client) GetAndTouch(key string, expiration int32) (item *memcache.Item, err error) {
+	err = c.do(key, func() (err error) {
+		item, err = c.Client.GetAndTouch(key, expiration)
+		return err
+	})
+	if errors.Is(err, breaker.ErrTooManyErrors) {
+		return nil, memcache.ErrCacheMiss
+	}
+
+	return item, err
+}
+
+//GetMulti works like memcache.Client.GetMulti, keys of servers whose breaker is open are missing from the result
+func (c *Client) GetMulti(keys []string) (map[string]*memcache.Item, error) {
+	byServer := make(map[string][]string)
+	for _, key := range keys {
+		addr, err := c.ss.PickServer(key)
+		if err != nil {
+			return nil, err
+		}
+		byServer[addr.String()] = append(byServer[addr.String()], key)
+	}
+
+	var (
+		mu       sync.Mutex
+		wg       sync.WaitGroup
+		items    = make(map[string]*memcache.Item, len(keys))
+		firstErr error
+	)
+	for _, serverKeys := range byServer {
+		wg.Add(1)
+		go func(serverKeys []string) {
+			defer wg.Done()
+
+			var got map[string]*memcache.Item
+			err := c.do(serverKeys[0], func() (err error) {
+				got, err = c.Client.GetMulti(serverKeys)
+				return err
+			})
+
+			mu.Lock()
+			defer mu.Unlock()
+			for k, v := range got {
+				items[k] = v
+			}
+			if err != nil && !errors.Is(err, breaker.ErrTooManyErrors) && firstErr == nil {
+				firstErr = err
+			}
+		}(serverKeys)
+	}
+	wg.Wait()
+
+	return items, firstErr
+}
+
+func (c *Client) Set(item *memcache.Item) error {
+	return c.do(item.Key, func() error { return c.Client.Set(item) })
+}
+
+func (c *Client) Add(item *memcache.Item) error {
+	return c.do(item.Key, func() error { return c.Client.Add(item) })
+}
+
+func (c *Client) Replace(item *memcache.Item) error {
+	return c.do(item.Key, func() error { return c.Client.Replace(item) })
+}
+
+func (c *Client) Append(item *memcache.Item) error {
+	return c.do(item.Key, func() error { return c.Client.Append(item) })
+}
+
+func (c *Client) Prepend(item *memcache.Item) error {
+	return c.do(item.Key, func() error { return c.Client.Prepend(item) })
+}
+
+func (c *Client) CompareAndSwap(item *memcache.Item) error {
+	return c.do(item.Key, func() error { return c.Client.CompareAndSwap(item) })
+}
+
+func (c *Client) Delete(key string) error {
+	return c.do(key, func() error { return c.Client.Delete(key) })
+}
+
+func (c *Client) Touch(key string, seconds int32) error {
+	return c.do(key, func() error { return c.Client.Touch(key, seconds) })
+}
+
+func (c *Client) Increment(key string, delta uint64) (newValue uint64, err error) {
+	err = c.do(key, func() (err error) {
+		newValue, err = c.Client.Increment(key, delta)
+		return err
+	})
+
+	return newValue, err
+}
+
+func (c *Client) Decrement(key string, delta uint64) (newValue uint64, err error) {
+	err = c.do(key, func() (err error) {
+		newValue, err = c.Client.Decrement(key, delta)
+		return err
+	})
+
+	return newValue, err
+}
@@ -0,0 +1,97 @@
+package breakermongo
+
+import (
+	"context"
+	"net"
+	"strings"
+
+	"github.com/carl-leopard/circuitbreaker/breaker"
+	"go.mongodb.org/mongo-driver/v2/event"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+//IsFailure treats network errors and timeouts as failures, command errors like duplicate keys
+//are answered by a healthy server
+func IsFailure(err error) bool {
+	return mongo.IsNetworkError(err) || mongo.IsTimeout(err)
+}
+
+//Apply sets a command monitor and a dialer to opts, both keyed by server address from g.
+//it replaces monitor and dialer already set
+func Apply(opts *options.ClientOptions, g *breaker.Group) *options.ClientOptions {
+	return opts.SetMonitor(Monitor(g)).SetDialer(Dialer(g, nil))
+}
+
+//Monitor return a command monitor feeding outcomes and latencies of commands into per-server breakers
+func Monitor(g *breaker.Group) *event.CommandMonitor {
+	return &event.CommandMonitor{
+		Started: func(ctx context.Context, e *event.CommandStartedEvent) {
+			g.Get(serverAddr(e.ConnectionID)).ReportRequest()
+		},
+		Succeeded: func(ctx context.Context, e *event.CommandSucceededEvent) {
+			g.Get(serverAddr(e.ConnectionID)).ReportLatency(e.Duration)
+		},
+		Failed: func(ctx context.Context, e *event.CommandFailedEvent) {
+			cb := g.Get(serverAddr(e.ConnectionID))
+			cb.ReportLatency(e.Duration)
+
+			if IsFailure(e.Failure) {
+				cb.ReportErrorContext(ctx)
+			}
+		},
+	}
+}
+
+//Dialer return a dialer failing fast while the breaker of address is open. writes on its connections fail too,
+//so the driver marks the server unknown and selects other servers until the sleep window ends.
+//next defaults to net.Dialer
+func Dialer(g *breaker.Group, next options.ContextDialer) options.ContextDialer {
+	if next == nil {
+		next = &net.Dialer{}
+	}
+
+	return &dialer{g: g, next: next}
+}
+
+type dialer struct {
+	g    *breaker.Group
+	next options.ContextDialer
+}
+
+func (d *dialer) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	cb := d.g.Get(address)
+	if err := cb.ReportRequest(); err != nil {
+		return nil, err
+	}
+
+	c, err := d.next.DialContext(ctx, network, address)
+	if err != nil {
+		cb.ReportErrorContext(ctx)
+		return nil, err
+	}
+
+	return &conn{Conn: c, cb: cb}, nil
+}
+
+type conn struct {
+	net.Conn
+	cb *breaker.CircuitBreaker
+}
+
+func (c *conn) Write(b []byte) (int, error) {
+	if c.cb.Status() == breaker.CircuitBreakerStatusOpen {
+		return 0, breaker.ErrTooManyErrors
+	}
+
+	return c.Conn.Write(b)
+}
+
+//serverAddr trims the counter of a connection id like "localhost:27017[-5]"
+func serverAddr(connectionID string) string {
+	if i := strings.IndexByte(connectionID, '['); i >= 0 {
+		return connectionID[:i]
+	}
+
+	return connectionID
+}
@@ -0,0 +1,143 @@
+package breakernats
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/carl-leopard/circuitbreaker/breaker"
+	"github.com/nats-io/nats.go"
+)
+
+type Option func(c *Conn)
+
+//Classifier decides whether an error returned by nats counts as a failure
+type Classifier func(err error) breaker.Outcome
+
+//DefaultClassifier treats missing responders, timeouts and lost connections as failures,
+//client side errors like a bad subject or payload count as successes
+func DefaultClassifier(err error) breaker.Outcome {
+	switch {
+	case errors.Is(err, nats.ErrNoResponders),
+		errors.Is(err, nats.ErrTimeout),
+		errors.Is(err, context.DeadlineExceeded),
+		errors.Is(err, nats.ErrConnectionClosed),
+		errors.Is(err, nats.ErrReconnectBufExceeded):
+		return breaker.OutcomeFailure
+	default:
+		return breaker.OutcomeSuccess
+	}
+}
+
+//WithClassifier replaces DefaultClassifier
+func WithClassifier(f Classifier) Option {
+	return func(c *Conn) {
+		if f != nil {
+			c.classify = f
+		}
+	}
+}
+
+//Conn runs publish and request operations of nats.Conn under circuit breakers,
+//failing fast with breaker.ErrTooManyErrors while open. other methods are not guarded
+type Conn struct {
+	*nats.Conn
+
+	breakerFor func(subj string) *breaker.CircuitBreaker
+	classify   Classifier
+}
+
+//New return a conn guarded by cb
+func New(nc *nats.Conn, cb *breaker.CircuitBreaker, opts ...Option) *Conn {
+	return newConn(nc, func(string) *breaker.CircuitBreaker { return cb }, opts)
+}
+
+//NewGroup return a conn with a circuit breaker per subject from g
+func NewGroup(nc *nats.Conn, g *breaker.Group, opts ...Option) *Conn {
+	return newConn(nc, g.Get, opts)
+}
+
+func newConn(nc *nats.Conn, breakerFor func(string) *breaker.CircuitBreaker, opts []Option) *Conn {
+	c := &Conn{
+		Conn:       nc,
+		breakerFor: breakerFor,
+		classify:   DefaultClassifier,
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
+}
+
+func (c *Conn) do(ctx context.Context, subj string, fn func() error) error {
+	cb := c.breakerFor(subj)
+	if err := cb.ReportRequest(); err != nil {
+		return err
+	}
+
+	start := time.Now()
+	err := fn()
+	cb.ReportLatency(time.Since(start))
+
+	if err != nil && c.classify(err) == breaker.OutcomeFailure {
+		cb.ReportErrorContext(ctx)
+	}
+
+	return err
+}
+
+func (c *Conn) Publish(subj string, data []byte) error {
+	return c.do(context.Background(), subj, func() error {
+		return c.Conn.Publish(subj, data)
+	})
+}
+
+func (c *Conn) PublishMsg(m *nats.Msg) error {
+	return c.do(context.Background(), m.Subject, func() error {
+		return c.Conn.PublishMsg(m)
+	})
+}
+
+func (c *Conn) PublishRequest(subj, reply string, data []byte) error {
+	return c.do(context.Background(), subj, func() error {
+		return c.Conn.PublishRequest(subj, reply, data)
+	})
+}
+
+func (c *Conn) Request(subj string, data []byte, timeout time.Duration) (msg *nats.Msg, err error) {
+	err = c.do(context.Background(), subj, func() (err error) {
+		msg, err = c.Conn.Request(subj, data, timeout)
+		return err
+	})
+
+	return msg, err
+}
+
+func (c *Conn) RequestWithContext(ctx context.Context, subj string, data []byte) (msg *nats.Msg, err error) {
+	err = c.do(ctx, subj, func() (err error) {
+		msg, err = c.Conn.RequestWithContext(ctx, subj, data)
+		return err
+	})
+
+	return msg, err
+}
+
+func (c *Conn) RequestMsg(m *nats.Msg, timeout time.Duration) (msg *nats.Msg, err error) {
+	err = c.do(context.Background(), m.Subject, func() (err error) {
+		msg, err = c.Conn.RequestMsg(m, timeout)
+		return err
+	})
+
+	return msg, err
+}
+
+func (c *Conn) RequestMsgWithContext(ctx context.Context, m *nats.Msg) (msg *nats.Msg, err error) {
+	err = c.do(ctx, m.Subject, func() (err error) {
+		msg, err = c.Conn.RequestMsgWithContext(ctx, m)
+		return err
+	})
+
+	return msg, err
+}
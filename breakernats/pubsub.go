@@ -0,0 +1,93 @@
+package breakernats
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/carl-leopard/circuitbreaker/breaker"
+	"github.com/nats-io/nats.go"
+)
+
+const defaultSubject = "circuitbreaker.transitions"
+
+type PubSubOption func(p *PubSub)
+
+//WithSubject sets the subject transitions are published to, default "circuitbreaker.transitions"
+func WithSubject(subject string) PubSubOption {
+	return func(p *PubSub) {
+		p.subject = subject
+	}
+}
+
+//WithInstance sets id of this instance, default hostname-pid
+func WithInstance(id string) PubSubOption {
+	return func(p *PubSub) {
+		p.instance = id
+	}
+}
+
+//PubSub broadcasts transitions over nats without sharing any counts, for fleet-wide fast-fail with
+//minimal infrastructure. instances not subscribed when a trip is published miss it.
+//use it with breaker.WithTransitionPublisher and breaker.FollowPeers
+type PubSub struct {
+	nc       *nats.Conn
+	subject  string
+	instance string
+}
+
+var (
+	_ breaker.TransitionPublisher = (*PubSub)(nil)
+	_ breaker.TransitionWatcher   = (*PubSub)(nil)
+)
+
+//NewPubSub return a broadcast over nc
+func NewPubSub(nc *nats.Conn, opts ...PubSubOption) *PubSub {
+	host, _ := os.Hostname()
+	p := &PubSub{
+		nc:       nc,
+		subject:  defaultSubject,
+		instance: fmt.Sprintf("%s-%d", host, os.Getpid()),
+	}
+
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	return p
+}
+
+//PublishTransition implements breaker.TransitionPublisher
+func (p *PubSub) PublishTransition(_ context.Context, t breaker.Transition) error {
+	t.Instance = p.instance
+	v, err := json.Marshal(t)
+	if err != nil {
+		return err
+	}
+
+	return p.nc.Publish(p.subject, v)
+}
+
+//WatchTransitions implements breaker.TransitionWatcher
+func (p *PubSub) WatchTransitions(ctx context.Context, fn func(breaker.Transition)) error {
+	ch := make(chan *nats.Msg, 64)
+	sub, err := p.nc.ChanSubscribe(p.subject, ch)
+	if err != nil {
+		return err
+	}
+	defer sub.Unsubscribe()
+
+	for {
+		select {
+		case msg := <-ch:
+			var t breaker.Transition
+			if err := json.Unmarshal(msg.Data, &t); err != nil || t.Instance == p.instance {
+				continue
+			}
+			fn(t)
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
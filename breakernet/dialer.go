@@ -0,0 +1,39 @@
+package breakernet
+
+import (
+	"context"
+	"errors"
+	"net"
+	"time"
+
+	"github.com/carl-leopard/circuitbreaker/breaker"
+)
+
+//DialContextFunc has the signature of net.Dialer.DialContext and http.Transport.DialContext
+type DialContextFunc func(ctx context.Context, network, address string) (net.Conn, error)
+
+//DialContext return a dial func with a circuit breaker per address from g. dial failures are reported
+//as errors, and dials to an address whose breaker is open fail fast until its sleep window ends,
+//so connection pools stop queuing dials to a dead address. next defaults to a zero net.Dialer
+func DialContext(g *breaker.Group, next DialContextFunc) DialContextFunc {
+	if next == nil {
+		next = (&net.Dialer{}).DialContext
+	}
+
+	return func(ctx context.Context, network, address string) (net.Conn, error) {
+		cb := g.Get(address)
+		if err := cb.ReportRequestWithMeta(breaker.RequestMeta{Key: address, Method: network}); err != nil {
+			return nil, &net.OpError{Op: "dial", Net: network, Err: err}
+		}
+
+		start := time.Now()
+		conn, err := next(ctx, network, address)
+		cb.ReportLatency(time.Since(start))
+
+		if err != nil && !errors.Is(err, context.Canceled) {
+			cb.ReportErrorContext(ctx)
+		}
+
+		return conn, err
+	}
+}
@@ -0,0 +1,165 @@
+package breakernet
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/carl-leopard/circuitbreaker/breaker"
+)
+
+type ResolverOption func(r *Resolver)
+
+//WithStaleCache answers lookups with the last successful result not older than maxAge
+//while the breaker is open or a lookup fails
+func WithStaleCache(maxAge time.Duration) ResolverOption {
+	return func(r *Resolver) {
+		r.staleMaxAge = maxAge
+	}
+}
+
+//Resolver runs lookups of a net.Resolver under a circuit breaker
+type Resolver struct {
+	r  *net.Resolver
+	cb *breaker.CircuitBreaker
+
+	staleMaxAge time.Duration
+	mu          sync.RWMutex
+	cache       map[string]cacheEntry
+}
+
+type cacheEntry struct {
+	value interface{}
+	at    time.Time
+}
+
+//NewResolver wraps r, net.DefaultResolver if nil
+func NewResolver(r *net.Resolver, cb *breaker.CircuitBreaker, opts ...ResolverOption) *Resolver {
+	if r == nil {
+		r = net.DefaultResolver
+	}
+
+	res := &Resolver{
+		r:     r,
+		cb:    cb,
+		cache: make(map[string]cacheEntry),
+	}
+
+	for _, opt := range opts {
+		opt(res)
+	}
+
+	return res
+}
+
+//isResolverFailure NXDOMAIN is an answer of a healthy resolver, timeouts and server failures are not
+func isResolverFailure(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return !dnsErr.IsNotFound
+	}
+
+	return true
+}
+
+func (r *Resolver) LookupHost(ctx context.Context, host string) ([]string, error) {
+	v, err := r.lookup(ctx, "host:"+host, func() (interface{}, error) {
+		return r.r.LookupHost(ctx, host)
+	})
+	addrs, _ := v.([]string)
+
+	return addrs, err
+}
+
+func (r *Resolver) LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error) {
+	v, err := r.lookup(ctx, "ipaddr:"+host, func() (interface{}, error) {
+		return r.r.LookupIPAddr(ctx, host)
+	})
+	addrs, _ := v.([]net.IPAddr)
+
+	return addrs, err
+}
+
+func (r *Resolver) LookupIP(ctx context.Context, network, host string) ([]net.IP, error) {
+	v, err := r.lookup(ctx, "ip:"+network+":"+host, func() (interface{}, error) {
+		return r.r.LookupIP(ctx, network, host)
+	})
+	ips, _ := v.([]net.IP)
+
+	return ips, err
+}
+
+func (r *Resolver) LookupSRV(ctx context.Context, service, proto, name string) (string, []*net.SRV, error) {
+	type srv struct {
+		cname string
+		addrs []*net.SRV
+	}
+
+	v, err := r.lookup(ctx, "srv:"+service+":"+proto+":"+name, func() (interface{}, error) {
+		cname, addrs, err := r.r.LookupSRV(ctx, service, proto, name)
+		if err != nil {
+			return nil, err
+		}
+		return srv{cname, addrs}, nil
+	})
+	if err != nil {
+		return "", nil, err
+	}
+	s := v.(srv)
+
+	return s.cname, s.addrs, nil
+}
+
+func (r *Resolver) lookup(ctx context.Context, key string, fn func() (interface{}, error)) (interface{}, error) {
+	if err := r.cb.ReportRequestWithMeta(breaker.RequestMeta{Key: key}); err != nil {
+		if v, ok := r.stale(key); ok {
+			return v, nil
+		}
+		return nil, err
+	}
+
+	start := time.Now()
+	v, err := fn()
+	r.cb.ReportLatency(time.Since(start))
+
+	if err != nil {
+		if isResolverFailure(err) {
+			r.cb.ReportErrorContext(ctx)
+
+			if v, ok := r.stale(key); ok {
+				return v, nil
+			}
+		}
+		return nil, err
+	}
+
+	if r.staleMaxAge > 0 {
+		r.mu.Lock()
+		r.cache[key] = cacheEntry{value: v, at: time.Now()}
+		r.mu.Unlock()
+	}
+
+	return v, nil
+}
+
+func (r *Resolver) stale(key string) (interface{}, bool) {
+	if r.staleMaxAge <= 0 {
+		return nil, false
+	}
+
+	r.mu.RLock()
+	e, ok := r.cache[key]
+	r.mu.RUnlock()
+
+	if !ok || time.Since(e.at) > r.staleMaxAge {
+		return nil, false
+	}
+
+	return e.value, true
+}
@@ -0,0 +1,207 @@
+package breakeropenfeature
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/carl-leopard/circuitbreaker/breaker"
+	"github.com/open-feature/go-sdk/openfeature"
+)
+
+const (
+	DefaultModeFlag    = "circuit-breaker-mode"
+	DefaultProfileFlag = "circuit-breaker-profile"
+
+	defaultPollInterval = 30 * time.Second
+)
+
+type Option func(o *Overrides)
+
+//WithModeFlag sets key of the string flag holding breaker.Mode text, e.g. "force-open", default DefaultModeFlag
+func WithModeFlag(key string) Option {
+	return func(o *Overrides) {
+		o.modeFlag = key
+	}
+}
+
+//WithProfiles sets threshold profiles selected by the string flag key, e.g. "strict" or "lenient".
+//a breaker gets back the open config it had before its first profile once the flag is empty or names an unknown
+//profile
+func WithProfiles(key string, profiles map[string]breaker.CircuitBreakerOpenConfig) Option {
+	return func(o *Overrides) {
+		o.profileFlag = key
+		o.profiles = profiles
+	}
+}
+
+//WithPollInterval sets how often flags are evaluated by Run, default 30s
+func WithPollInterval(t time.Duration) Option {
+	return func(o *Overrides) {
+		if t > 0 {
+			o.pollInterval = t
+		}
+	}
+}
+
+//Overrides controls modes and threshold profiles of breakers in a registry by OpenFeature flags.
+//each breaker is evaluated with its name as targeting key and attribute "breaker", so flag rules can target
+//single breakers, while environment is up to the provider and global evaluation context
+type Overrides struct {
+	client *openfeature.Client
+	r      *breaker.Registry
+
+	modeFlag     string
+	profileFlag  string
+	profiles     map[string]breaker.CircuitBreakerOpenConfig
+	pollInterval time.Duration
+
+	modes   map[string]string                           //breaker name => last mode flag value, only used by Apply
+	applied map[string]string                           //breaker name => applied profile, only used by Apply
+	base    map[string]breaker.CircuitBreakerOpenConfig //breaker name => open config before its profile
+}
+
+//New return overrides of breakers in r evaluated by client
+func New(client *openfeature.Client, r *breaker.Registry, opts ...Option) *Overrides {
+	o := &Overrides{
+		client:       client,
+		r:            r,
+		modeFlag:     DefaultModeFlag,
+		profileFlag:  DefaultProfileFlag,
+		pollInterval: defaultPollInterval,
+		modes:        make(map[string]string),
+		applied:      make(map[string]string),
+		base:         make(map[string]breaker.CircuitBreakerOpenConfig),
+	}
+
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	return o
+}
+
+//Apply evaluates flags once for every breaker in registry. a mode is set only when the flag value changes, so
+//modes set by SetModeFor or config in between are kept until the flag changes again. the mode and profile flags
+//are evaluated independently, a flag not found counts as its default. a failed evaluation leaves its part of the
+//breaker as it is and is returned joined with the others. it must not be called concurrently
+func (o *Overrides) Apply(ctx context.Context) error {
+	var errs []error
+	for _, name := range o.r.Names() {
+		cb, ok := o.r.Get(name)
+		if !ok {
+			continue
+		}
+
+		if err := o.apply(ctx, cb); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (o *Overrides) apply(ctx context.Context, cb *breaker.CircuitBreaker) error {
+	evalCtx := openfeature.NewEvaluationContext(cb.Name(), map[string]any{"breaker": cb.Name()})
+
+	return errors.Join(o.applyMode(ctx, cb, evalCtx), o.applyProfile(ctx, cb, evalCtx))
+}
+
+//stringFlag evaluates flag, a flag not found is def like a flag evaluated to it
+func (o *Overrides) stringFlag(ctx context.Context, flag, def string, evalCtx openfeature.EvaluationContext) (string, error) {
+	d, err := o.client.StringValueDetails(ctx, flag, def, evalCtx)
+	if err != nil {
+		if d.ErrorCode == openfeature.FlagNotFoundCode {
+			return def, nil
+		}
+		return def, err
+	}
+
+	return d.Value, nil
+}
+
+func (o *Overrides) applyMode(ctx context.Context, cb *breaker.CircuitBreaker, evalCtx openfeature.EvaluationContext) error {
+	v, err := o.stringFlag(ctx, o.modeFlag, breaker.ModeNormal.String(), evalCtx)
+	if err != nil {
+		return err
+	}
+	last, ok := o.modes[cb.Name()]
+	if !ok {
+		last = breaker.ModeNormal.String()
+	}
+	if v == last {
+		return nil
+	}
+
+	mode, err := breaker.ParseMode(v)
+	if err != nil {
+		return err
+	}
+	cb.SetMode(mode)
+	o.modes[cb.Name()] = v
+
+	return nil
+}
+
+func (o *Overrides) applyProfile(ctx context.Context, cb *breaker.CircuitBreaker, evalCtx openfeature.EvaluationContext) error {
+	if len(o.profiles) == 0 {
+		return nil
+	}
+
+	profile, err := o.stringFlag(ctx, o.profileFlag, "", evalCtx)
+	if err != nil {
+		return err
+	}
+
+	applied, hasProfile := o.applied[cb.Name()]
+	oc, ok := o.profiles[profile]
+	if !ok {
+		if hasProfile {
+			cb.SetOpenConfig(o.base[cb.Name()])
+			delete(o.applied, cb.Name())
+			delete(o.base, cb.Name())
+		}
+		return nil
+	}
+	if applied == profile {
+		return nil
+	}
+	if !hasProfile {
+		o.base[cb.Name()] = cb.Snapshot().OpenConfig
+	}
+	cb.SetOpenConfig(oc)
+	o.applied[cb.Name()] = profile
+
+	return nil
+}
+
+//Run applies flags every poll interval and whenever the provider reports a config change, until ctx is done.
+//errors of each round are passed to onError if not nil
+func (o *Overrides) Run(ctx context.Context, onError func(error)) {
+	changed := make(chan struct{}, 1)
+	callback := func(openfeature.EventDetails) {
+		select {
+		case changed <- struct{}{}:
+		default:
+		}
+	}
+	o.client.AddHandler(openfeature.ProviderConfigChange, &callback)
+	defer o.client.RemoveHandler(openfeature.ProviderConfigChange, &callback)
+
+	t := time.NewTicker(o.pollInterval)
+	defer t.Stop()
+
+	for {
+		if err := o.Apply(ctx); err != nil && onError != nil {
+			onError(err)
+		}
+
+		select {
+		case <-t.C:
+		case <-changed:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
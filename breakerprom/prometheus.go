@@ -0,0 +1,123 @@
+package breakerprom
+
+import (
+	"context"
+	"time"
+
+	"github.com/carl-leopard/circuitbreaker/breaker"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/trace"
+)
+
+//ExemplarFunc returns exemplar labels of the active trace in ctx, nil if there is none
+type ExemplarFunc func(ctx context.Context) prometheus.Labels
+
+type Option func(m *Metrics)
+
+//WithNamespace sets namespace of all metrics, default "circuit_breaker"
+func WithNamespace(ns string) Option {
+	return func(m *Metrics) {
+		m.namespace = ns
+	}
+}
+
+//WithExemplarFunc replaces the default exemplar extraction, which uses OpenTelemetry span context
+func WithExemplarFunc(f ExemplarFunc) Option {
+	return func(m *Metrics) {
+		m.exemplar = f
+	}
+}
+
+//Metrics holds prometheus collectors shared by sinks of all circuit breakers
+type Metrics struct {
+	namespace string
+	exemplar  ExemplarFunc
+
+	counters  *prometheus.CounterVec
+	gauges    *prometheus.GaugeVec
+	durations *prometheus.HistogramVec
+}
+
+//NewMetrics creates collectors and registers them to reg
+func NewMetrics(reg prometheus.Registerer, opts ...Option) (*Metrics, error) {
+	m := &Metrics{
+		namespace: "circuit_breaker",
+		exemplar:  traceExemplar,
+	}
+
+	for _, opt := range opts {
+		opt(m)
+	}
+
+	m.counters = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: m.namespace,
+		Name:      "events_total",
+		Help:      "Events of circuit breakers, see breaker.Metric* for names.",
+	}, []string{"breaker", "event"})
+	m.gauges = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: m.namespace,
+		Name:      "gauge",
+		Help:      "Gauges of circuit breakers, e.g. status.",
+	}, []string{"breaker", "name"})
+	m.durations = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: m.namespace,
+		Name:      "duration_seconds",
+		Help:      "Durations of circuit breakers, e.g. how long it stays open.",
+		Buckets:   prometheus.ExponentialBuckets(0.001, 4, 12),
+	}, []string{"breaker", "name"})
+
+	for _, c := range []prometheus.Collector{m.counters, m.gauges, m.durations} {
+		if err := reg.Register(c); err != nil {
+			return nil, err
+		}
+	}
+
+	return m, nil
+}
+
+//Sink returns a MetricsSink reporting as circuit breaker name
+func (m *Metrics) Sink(name string) breaker.MetricsSink {
+	return &sink{m: m, name: name}
+}
+
+type sink struct {
+	m    *Metrics
+	name string
+}
+
+func (s *sink) IncrCounter(name string, delta uint32) {
+	s.m.counters.WithLabelValues(s.name, name).Add(float64(delta))
+}
+
+//IncrCounterContext implements breaker.ContextMetricsSink, attaching an exemplar when ctx carries a trace
+func (s *sink) IncrCounterContext(ctx context.Context, name string, delta uint32) {
+	c := s.m.counters.WithLabelValues(s.name, name)
+
+	if s.m.exemplar != nil {
+		if labels := s.m.exemplar(ctx); len(labels) > 0 {
+			if ea, ok := c.(prometheus.ExemplarAdder); ok {
+				ea.AddWithExemplar(float64(delta), labels)
+				return
+			}
+		}
+	}
+
+	c.Add(float64(delta))
+}
+
+func (s *sink) SetGauge(name string, value float64) {
+	s.m.gauges.WithLabelValues(s.name, name).Set(value)
+}
+
+func (s *sink) ObserveDuration(name string, d time.Duration) {
+	s.m.durations.WithLabelValues(s.name, name).Observe(d.Seconds())
+}
+
+func traceExemplar(ctx context.Context) prometheus.Labels {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() || !sc.IsSampled() {
+		return nil
+	}
+
+	return prometheus.Labels{"trace_id": sc.TraceID().String()}
+}
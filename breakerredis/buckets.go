@@ -0,0 +1,138 @@
+package breakerredis
+
+import (
+	"context"
+	"time"
+
+	"github.com/carl-leopard/circuitbreaker/breaker"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	defaultBucketSize  = 10 * time.Second
+	defaultBucketCount = 18
+)
+
+//bucketScript adds requests and errors to the current bucket of a rolling window, drops buckets out of it,
+//and opens the breaker for the sleep window when the sums reach thresholds, all in one round trip.
+//fields of the hash are r:<bucket> and e:<bucket>, the trip is a key expiring at the end of the sleep window.
+//it returns requests, errors and milliseconds left of the trip, 0 if there is none
+var bucketScript = redis.NewScript(`
+local bucket = tonumber(ARGV[1])
+local count = tonumber(ARGV[2])
+local oldest = bucket - count + 1
+
+redis.call("HINCRBY", KEYS[1], "r:" .. bucket, ARGV[3])
+redis.call("HINCRBY", KEYS[1], "e:" .. bucket, ARGV[4])
+
+local r, e = 0, 0
+local kvs = redis.call("HGETALL", KEYS[1])
+for i = 1, #kvs, 2 do
+	local kind, b = string.match(kvs[i], "^(%a):(%d+)$")
+	b = tonumber(b)
+	if b == nil or b < oldest then
+		redis.call("HDEL", KEYS[1], kvs[i])
+	elseif kind == "r" then
+		r = r + tonumber(kvs[i + 1])
+	else
+		e = e + tonumber(kvs[i + 1])
+	end
+end
+redis.call("PEXPIRE", KEYS[1], ARGV[5])
+
+local left = redis.call("PTTL", KEYS[2])
+if left < 0 then
+	left = 0
+	local volume = tonumber(ARGV[6])
+	if volume > 0 and tonumber(ARGV[8]) > 0 and r >= volume and e * 100 >= r * tonumber(ARGV[7]) then
+		left = tonumber(ARGV[8])
+		redis.call("SET", KEYS[2], "1", "PX", left, "NX")
+	end
+end
+
+return {r, e, left}
+`)
+
+type BucketOption func(c *BucketCounter)
+
+//WithBuckets sets the rolling window to count buckets of size each, default 18 buckets of 10s.
+//it should match the refresh interval of the breakers sharing it
+func WithBuckets(size time.Duration, count int) BucketOption {
+	return func(c *BucketCounter) {
+		if size > 0 && count > 0 {
+			c.bucketSize = size
+			c.bucketCount = count
+		}
+	}
+}
+
+//WithThresholds makes the script open breakers for sleepWindow when sums of the rolling window reach thresholds
+//of oc, refresh interval of oc is not used. without it only the sums are returned and breakers decide on their own
+func WithThresholds(oc breaker.CircuitBreakerOpenConfig, sleepWindow time.Duration) BucketOption {
+	return func(c *BucketCounter) {
+		c.openConfig = oc
+		c.sleepWindow = sleepWindow
+	}
+}
+
+//WithBucketKeyPrefix sets prefix of redis keys, default "circuitbreaker:"
+func WithBucketKeyPrefix(prefix string) BucketOption {
+	return func(c *BucketCounter) {
+		c.prefix = prefix
+	}
+}
+
+//BucketCounter implements breaker.WindowCounter as a rolling window of buckets in redis. every flush is one script
+//call updating the current bucket, summing the window and, see WithThresholds, evaluating thresholds, so a trip
+//decided by one instance is seen by all others on their next flush, whatever window they are in
+type BucketCounter struct {
+	rdb    redis.Scripter
+	prefix string
+
+	bucketSize  time.Duration
+	bucketCount int
+
+	openConfig  breaker.CircuitBreakerOpenConfig
+	sleepWindow time.Duration
+}
+
+var _ breaker.WindowCounter = (*BucketCounter)(nil)
+
+//NewBucketCounter return a counter in rdb, e.g. *redis.Client or *redis.ClusterClient
+func NewBucketCounter(rdb redis.Scripter, opts ...BucketOption) *BucketCounter {
+	c := &BucketCounter{
+		rdb:         rdb,
+		prefix:      defaultKeyPrefix,
+		bucketSize:  defaultBucketSize,
+		bucketCount: defaultBucketCount,
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
+}
+
+//IncrWindow implements breaker.WindowCounter, the totals are of the rolling window ending now rather than of start
+func (c *BucketCounter) IncrWindow(ctx context.Context, name string, start time.Time, requests, errors uint32) (breaker.Window, error) {
+	keys := []string{
+		c.prefix + "{" + name + "}:buckets",
+		c.prefix + "{" + name + "}:open",
+	}
+	window := c.bucketSize * time.Duration(c.bucketCount)
+
+	vs, err := bucketScript.Run(ctx, c.rdb, keys,
+		time.Now().UnixNano()/int64(c.bucketSize), c.bucketCount, requests, errors, window.Milliseconds(),
+		c.openConfig.RequestVolumeThreshold, c.openConfig.ErrorThresholdPercent, c.sleepWindow.Milliseconds()).Int64Slice()
+	if err != nil {
+		return breaker.Window{}, err
+	}
+
+	return breaker.Window{
+		Start:    start,
+		Requests: breaker.SaturatedCount(uint64(vs[0])),
+		Errors:   breaker.SaturatedCount(uint64(vs[1])),
+		OpenFor:  time.Duration(vs[2]) * time.Millisecond,
+	}, nil
+}
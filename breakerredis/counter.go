@@ -0,0 +1,100 @@
+package breakerredis
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/carl-leopard/circuitbreaker/breaker"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	defaultKeyPrefix = "circuitbreaker:"
+	defaultWindowTTL = 15 * time.Minute
+)
+
+//incrWindowScript adds requests and errors to a window hash and returns its totals
+var incrWindowScript = redis.NewScript(`
+local r = redis.call("HINCRBY", KEYS[1], "r", ARGV[1])
+local e = redis.call("HINCRBY", KEYS[1], "e", ARGV[2])
+redis.call("PEXPIRE", KEYS[1], ARGV[3])
+return {r, e}
+`)
+
+//readWindowScript returns totals of a window hash, 0 for a missing one. a script, so any redis.Scripter will do
+var readWindowScript = redis.NewScript(`
+local v = redis.call("HMGET", KEYS[1], "r", "e")
+return {tonumber(v[1]) or 0, tonumber(v[2]) or 0}
+`)
+
+type CounterOption func(c *WindowCounter)
+
+//WithKeyPrefix sets prefix of redis keys, default "circuitbreaker:"
+func WithKeyPrefix(prefix string) CounterOption {
+	return func(c *WindowCounter) {
+		c.prefix = prefix
+	}
+}
+
+//WithWindowTTL sets how long a window is kept in redis, it must be longer than the refresh interval. default 15m
+func WithWindowTTL(t time.Duration) CounterOption {
+	return func(c *WindowCounter) {
+		if t > 0 {
+			c.ttl = t
+		}
+	}
+}
+
+//WindowCounter implements breaker.WindowCounter in redis, a window is a hash updated atomically by a script
+type WindowCounter struct {
+	rdb    redis.Scripter
+	prefix string
+	ttl    time.Duration
+}
+
+var (
+	_ breaker.WindowCounter = (*WindowCounter)(nil)
+	_ breaker.WindowReader  = (*WindowCounter)(nil)
+)
+
+//NewWindowCounter return a counter in rdb, e.g. *redis.Client or *redis.ClusterClient
+func NewWindowCounter(rdb redis.Scripter, opts ...CounterOption) *WindowCounter {
+	c := &WindowCounter{
+		rdb:    rdb,
+		prefix: defaultKeyPrefix,
+		ttl:    defaultWindowTTL,
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
+}
+
+//windowKey keeps name in a hash tag, so all windows of a breaker live in one cluster slot
+func (c *WindowCounter) windowKey(name string, start time.Time) string {
+	return c.prefix + "{" + name + "}:" + strconv.FormatInt(start.Unix(), 10)
+}
+
+//IncrWindow implements breaker.WindowCounter
+func (c *WindowCounter) IncrWindow(ctx context.Context, name string, start time.Time, requests, errors uint32) (breaker.Window, error) {
+	vs, err := incrWindowScript.Run(ctx, c.rdb, []string{c.windowKey(name, start)},
+		requests, errors, c.ttl.Milliseconds()).Int64Slice()
+	if err != nil {
+		return breaker.Window{}, err
+	}
+
+	return breaker.Window{Start: start, Requests: breaker.SaturatedCount(uint64(vs[0])), Errors: breaker.SaturatedCount(uint64(vs[1]))}, nil
+}
+
+//ReadWindow implements breaker.WindowReader
+func (c *WindowCounter) ReadWindow(ctx context.Context, name string, start time.Time) (breaker.Window, error) {
+	vs, err := readWindowScript.Run(ctx, c.rdb, []string{c.windowKey(name, start)}).Int64Slice()
+	if err != nil {
+		return breaker.Window{}, err
+	}
+
+	return breaker.Window{Start: start, Requests: breaker.SaturatedCount(uint64(vs[0])), Errors: breaker.SaturatedCount(uint64(vs[1]))}, nil
+}
@@ -0,0 +1,143 @@
+package breakerredis
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/carl-leopard/circuitbreaker/breaker"
+	"github.com/redis/go-redis/v9"
+)
+
+type Option func(h *Hook)
+
+//Classifier decides whether an error returned by redis counts as a failure
+type Classifier func(err error) breaker.Outcome
+
+//DefaultClassifier treats network errors, timeouts and replies of an unavailable server
+//(LOADING, CLUSTERDOWN, MASTERDOWN, BUSY) as failures. redis.Nil and other replies count as successes
+func DefaultClassifier(err error) breaker.Outcome {
+	if err == nil || err == redis.Nil || errors.Is(err, context.Canceled) {
+		return breaker.OutcomeSuccess
+	}
+
+	var redisErr redis.Error
+	if errors.As(err, &redisErr) {
+		for _, prefix := range []string{"LOADING", "CLUSTERDOWN", "MASTERDOWN", "BUSY"} {
+			if strings.HasPrefix(redisErr.Error(), prefix) {
+				return breaker.OutcomeFailure
+			}
+		}
+		return breaker.OutcomeSuccess
+	}
+
+	return breaker.OutcomeFailure
+}
+
+//WithClassifier replaces DefaultClassifier
+func WithClassifier(f Classifier) Option {
+	return func(h *Hook) {
+		if f != nil {
+			h.classify = f
+		}
+	}
+}
+
+//Hook is a redis.Hook running dials, commands and pipelines of one node under a circuit breaker
+type Hook struct {
+	cb       *breaker.CircuitBreaker
+	classify Classifier
+}
+
+var _ redis.Hook = (*Hook)(nil)
+
+//NewHook return a hook of cb
+func NewHook(cb *breaker.CircuitBreaker, opts ...Option) *Hook {
+	h := &Hook{
+		cb:       cb,
+		classify: DefaultClassifier,
+	}
+
+	for _, opt := range opts {
+		opt(h)
+	}
+
+	return h
+}
+
+//Instrument adds a hook keyed by address of c from g
+func Instrument(c *redis.Client, g *breaker.Group, opts ...Option) {
+	c.AddHook(NewHook(g.Get(c.Options().Addr), opts...))
+}
+
+//InstrumentCluster adds a hook to every node of c, keyed by node address from g,
+//so a failing shard is isolated while others keep serving
+func InstrumentCluster(c *redis.ClusterClient, g *breaker.Group, opts ...Option) {
+	c.OnNewNode(func(rdb *redis.Client) {
+		Instrument(rdb, g, opts...)
+	})
+}
+
+func (h *Hook) DialHook(next redis.DialHook) redis.DialHook {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		var conn net.Conn
+		err := h.do(ctx, func() (err error) {
+			conn, err = next(ctx, network, addr)
+			return err
+		})
+
+		return conn, err
+	}
+}
+
+func (h *Hook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		//rejected commands never reach redis, so nothing else sets their error
+		if err := h.cb.ReportRequest(); err != nil {
+			cmd.SetErr(err)
+			return err
+		}
+
+		return h.call(ctx, func() error {
+			return next(ctx, cmd)
+		})
+	}
+}
+
+func (h *Hook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		if err := h.cb.ReportRequest(); err != nil {
+			for _, cmd := range cmds {
+				cmd.SetErr(err)
+			}
+			return err
+		}
+
+		return h.call(ctx, func() error {
+			return next(ctx, cmds)
+		})
+	}
+}
+
+func (h *Hook) do(ctx context.Context, fn func() error) error {
+	if err := h.cb.ReportRequest(); err != nil {
+		return err
+	}
+
+	return h.call(ctx, fn)
+}
+
+//call runs fn of a request circuit breaker passed and reports its outcome
+func (h *Hook) call(ctx context.Context, fn func() error) error {
+	start := time.Now()
+	err := fn()
+	h.cb.ReportLatency(time.Since(start))
+
+	if h.classify(err) == breaker.OutcomeFailure {
+		h.cb.ReportErrorContext(ctx)
+	}
+
+	return err
+}
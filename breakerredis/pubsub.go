@@ -0,0 +1,99 @@
+package breakerredis
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/carl-leopard/circuitbreaker/breaker"
+	"github.com/redis/go-redis/v9"
+)
+
+const defaultChannel = "circuitbreaker:transitions"
+
+type PubSubOption func(p *PubSub)
+
+//WithChannel sets the pub/sub channel, default "circuitbreaker:transitions"
+func WithChannel(channel string) PubSubOption {
+	return func(p *PubSub) {
+		p.channel = channel
+	}
+}
+
+//WithInstance sets id of this instance, default hostname-pid
+func WithInstance(id string) PubSubOption {
+	return func(p *PubSub) {
+		p.instance = id
+	}
+}
+
+//PubSub broadcasts transitions over redis pub/sub without sharing any counts, for fleet-wide fast-fail with
+//nothing stored in redis. instances not subscribed when a trip is published miss it.
+//use it with breaker.WithTransitionPublisher and breaker.FollowPeers
+type PubSub struct {
+	rdb      redis.UniversalClient
+	channel  string
+	instance string
+}
+
+var (
+	_ breaker.TransitionPublisher = (*PubSub)(nil)
+	_ breaker.TransitionWatcher   = (*PubSub)(nil)
+)
+
+//NewPubSub return a broadcast over rdb
+func NewPubSub(rdb redis.UniversalClient, opts ...PubSubOption) *PubSub {
+	host, _ := os.Hostname()
+	p := &PubSub{
+		rdb:      rdb,
+		channel:  defaultChannel,
+		instance: fmt.Sprintf("%s-%d", host, os.Getpid()),
+	}
+
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	return p
+}
+
+//PublishTransition implements breaker.TransitionPublisher
+func (p *PubSub) PublishTransition(ctx context.Context, t breaker.Transition) error {
+	t.Instance = p.instance
+	v, err := json.Marshal(t)
+	if err != nil {
+		return err
+	}
+
+	return p.rdb.Publish(ctx, p.channel, v).Err()
+}
+
+//WatchTransitions implements breaker.TransitionWatcher
+func (p *PubSub) WatchTransitions(ctx context.Context, fn func(breaker.Transition)) error {
+	sub := p.rdb.Subscribe(ctx, p.channel)
+	defer sub.Close()
+
+	//fails fast if redis is unreachable, Channel would retry silently
+	if _, err := sub.Receive(ctx); err != nil {
+		return err
+	}
+
+	ch := sub.Channel()
+	for {
+		select {
+		case msg, ok := <-ch:
+			if !ok {
+				return redis.ErrClosed
+			}
+
+			var t breaker.Transition
+			if err := json.Unmarshal([]byte(msg.Payload), &t); err != nil || t.Instance == p.instance {
+				continue
+			}
+			fn(t)
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
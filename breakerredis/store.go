@@ -0,0 +1,16 @@
+package breakerredis
+
+import "github.com/carl-leopard/circuitbreaker/breaker"
+
+//Store implements breaker.Store in redis, counts in windows of WindowCounter and trips broadcast by PubSub
+type Store struct {
+	*WindowCounter
+	*PubSub
+}
+
+var _ breaker.Store = (*Store)(nil)
+
+//NewStore combines c and p into a store
+func NewStore(c *WindowCounter, p *PubSub) *Store {
+	return &Store{WindowCounter: c, PubSub: p}
+}
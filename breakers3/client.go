@@ -0,0 +1,154 @@
+package breakers3
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/carl-leopard/circuitbreaker/breaker"
+	"github.com/minio/minio-go/v7"
+)
+
+//IsFailure treats 5xx, throttling and network errors as failures. other error responses, e.g. NoSuchKey
+//or AccessDenied, are answered by a healthy endpoint
+func IsFailure(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+
+	resp := minio.ToErrorResponse(err)
+	switch {
+	case resp.StatusCode == 0:
+		//no response from endpoint
+		return true
+	case resp.StatusCode >= http.StatusInternalServerError,
+		resp.StatusCode == http.StatusTooManyRequests,
+		resp.Code == "SlowDown":
+		return true
+	default:
+		return false
+	}
+}
+
+type Option func(c *Client)
+
+//WithSecondary reads objects from s, e.g. a replica in another region, while the breaker of the primary bucket is open.
+//bucket maps a primary bucket to its replica, nil keeps the name. writes never go to the secondary
+func WithSecondary(s *minio.Client, bucket func(primary string) string) Option {
+	return func(c *Client) {
+		if bucket == nil {
+			bucket = func(b string) string { return b }
+		}
+
+		c.secondary = s
+		c.secondaryBucket = bucket
+	}
+}
+
+//Client wraps minio.Client with a circuit breaker per endpoint and bucket, keyed "host/bucket" from a group.
+//methods not overridden here are not guarded
+type Client struct {
+	*minio.Client
+
+	g *breaker.Group
+
+	secondary       *minio.Client
+	secondaryBucket func(string) string
+}
+
+//New return a client guarding primary with breakers from g
+func New(primary *minio.Client, g *breaker.Group, opts ...Option) *Client {
+	c := &Client{Client: primary, g: g}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
+}
+
+func (c *Client) breakerFor(mc *minio.Client, bucket string) *breaker.CircuitBreaker {
+	return c.g.Get(mc.EndpointURL().Host + "/" + bucket)
+}
+
+func do(ctx context.Context, cb *breaker.CircuitBreaker, fn func() error) error {
+	if err := cb.ReportRequest(); err != nil {
+		return err
+	}
+
+	start := time.Now()
+	err := fn()
+	cb.ReportLatency(time.Since(start))
+
+	if IsFailure(err) {
+		cb.ReportErrorContext(ctx)
+	}
+
+	return err
+}
+
+//read runs fn against the primary, or the secondary while the primary is open
+func (c *Client) read(ctx context.Context, bucket string, fn func(mc *minio.Client, bucket string) error) error {
+	err := do(ctx, c.breakerFor(c.Client, bucket), func() error {
+		return fn(c.Client, bucket)
+	})
+	if c.secondary == nil || !errors.Is(err, breaker.ErrTooManyErrors) {
+		return err
+	}
+
+	sb := c.secondaryBucket(bucket)
+	return do(ctx, c.breakerFor(c.secondary, sb), func() error {
+		return fn(c.secondary, sb)
+	})
+}
+
+//GetObject works like minio.Client.GetObject. the object is stated before returning, so a failing endpoint
+//is detected here rather than on the first Read
+func (c *Client) GetObject(ctx context.Context, bucket, object string, opts minio.GetObjectOptions) (*minio.Object, error) {
+	var obj *minio.Object
+	err := c.read(ctx, bucket, func(mc *minio.Client, bucket string) error {
+		o, err := mc.GetObject(ctx, bucket, object, opts)
+		if err != nil {
+			return err
+		}
+		if _, err := o.Stat(); err != nil {
+			o.Close()
+			return err
+		}
+
+		obj = o
+		return nil
+	})
+
+	return obj, err
+}
+
+//StatObject works like minio.Client.StatObject
+func (c *Client) StatObject(ctx context.Context, bucket, object string, opts minio.StatObjectOptions) (minio.ObjectInfo, error) {
+	var info minio.ObjectInfo
+	err := c.read(ctx, bucket, func(mc *minio.Client, bucket string) (err error) {
+		info, err = mc.StatObject(ctx, bucket, object, opts)
+		return err
+	})
+
+	return info, err
+}
+
+//PutObject works like minio.Client.PutObject, it fails fast with breaker.ErrTooManyErrors while the bucket is open
+func (c *Client) PutObject(ctx context.Context, bucket, object string, reader io.Reader, size int64, opts minio.PutObjectOptions) (minio.UploadInfo, error) {
+	var info minio.UploadInfo
+	err := do(ctx, c.breakerFor(c.Client, bucket), func() (err error) {
+		info, err = c.Client.PutObject(ctx, bucket, object, reader, size, opts)
+		return err
+	})
+
+	return info, err
+}
+
+//RemoveObject works like minio.Client.RemoveObject, it fails fast with breaker.ErrTooManyErrors while the bucket is open
+func (c *Client) RemoveObject(ctx context.Context, bucket, object string, opts minio.RemoveObjectOptions) error {
+	return do(ctx, c.breakerFor(c.Client, bucket), func() error {
+		return c.Client.RemoveObject(ctx, bucket, object, opts)
+	})
+}
@@ -0,0 +1,220 @@
+package breakersql
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+)
+
+//errors database/sql returns for transaction options drivers without ConnBeginTx can't honor
+var (
+	errIsolationLevel = errors.New("sql: driver does not support non-default isolation level")
+	errReadOnly       = errors.New("sql: driver does not support read-only transactions")
+)
+
+type conn struct {
+	driver.Conn
+	g *Guard
+}
+
+func (c *conn) Ping(ctx context.Context) error {
+	p, ok := c.Conn.(driver.Pinger)
+	if !ok {
+		return nil
+	}
+
+	return c.g.Do(ctx, func() error {
+		return p.Ping(ctx)
+	})
+}
+
+func (c *conn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	e, ok := c.Conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+
+	var res driver.Result
+	err := c.g.Do(ctx, func() (err error) {
+		res, err = e.ExecContext(ctx, query, args)
+		return err
+	})
+
+	return res, err
+}
+
+func (c *conn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	q, ok := c.Conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+
+	var rows driver.Rows
+	err := c.g.Do(ctx, func() (err error) {
+		rows, err = q.QueryContext(ctx, query, args)
+		return err
+	})
+
+	return rows, err
+}
+
+func (c *conn) Prepare(query string) (driver.Stmt, error) {
+	return c.PrepareContext(context.Background(), query)
+}
+
+func (c *conn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	var st driver.Stmt
+	err := c.g.Do(ctx, func() (err error) {
+		if p, ok := c.Conn.(driver.ConnPrepareContext); ok {
+			st, err = p.PrepareContext(ctx, query)
+		} else {
+			st, err = c.Conn.Prepare(query)
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &stmt{Stmt: st, g: c.g}, nil
+}
+
+func (c *conn) Begin() (driver.Tx, error) {
+	return c.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+func (c *conn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	b, ok := c.Conn.(driver.ConnBeginTx)
+	if !ok {
+		//like database/sql for drivers without ConnBeginTx, options Begin can't honor fail before the breaker
+		if opts.Isolation != driver.IsolationLevel(sql.LevelDefault) {
+			return nil, errIsolationLevel
+		}
+		if opts.ReadOnly {
+			return nil, errReadOnly
+		}
+	}
+
+	var tx driver.Tx
+	err := c.g.Do(ctx, func() (err error) {
+		if ok {
+			tx, err = b.BeginTx(ctx, opts)
+		} else {
+			tx, err = c.Conn.Begin()
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return tx, nil
+}
+
+func (c *conn) ResetSession(ctx context.Context) error {
+	if r, ok := c.Conn.(driver.SessionResetter); ok {
+		return r.ResetSession(ctx)
+	}
+
+	return nil
+}
+
+func (c *conn) IsValid() bool {
+	if v, ok := c.Conn.(driver.Validator); ok {
+		return v.IsValid()
+	}
+
+	return true
+}
+
+func (c *conn) CheckNamedValue(nv *driver.NamedValue) error {
+	if nc, ok := c.Conn.(driver.NamedValueChecker); ok {
+		return nc.CheckNamedValue(nv)
+	}
+
+	return driver.ErrSkip
+}
+
+type stmt struct {
+	driver.Stmt
+	g *Guard
+}
+
+func (s *stmt) Exec(args []driver.Value) (driver.Result, error) {
+	var res driver.Result
+	err := s.g.Do(context.Background(), func() (err error) {
+		res, err = s.Stmt.Exec(args)
+		return err
+	})
+
+	return res, err
+}
+
+func (s *stmt) Query(args []driver.Value) (driver.Rows, error) {
+	var rows driver.Rows
+	err := s.g.Do(context.Background(), func() (err error) {
+		rows, err = s.Stmt.Query(args)
+		return err
+	})
+
+	return rows, err
+}
+
+func (s *stmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	e, ok := s.Stmt.(driver.StmtExecContext)
+	if !ok {
+		values, err := namedValuesToValues(args)
+		if err != nil {
+			return nil, err
+		}
+		return s.Exec(values)
+	}
+
+	var res driver.Result
+	err := s.g.Do(ctx, func() (err error) {
+		res, err = e.ExecContext(ctx, args)
+		return err
+	})
+
+	return res, err
+}
+
+func (s *stmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	q, ok := s.Stmt.(driver.StmtQueryContext)
+	if !ok {
+		values, err := namedValuesToValues(args)
+		if err != nil {
+			return nil, err
+		}
+		return s.Query(values)
+	}
+
+	var rows driver.Rows
+	err := s.g.Do(ctx, func() (err error) {
+		rows, err = q.QueryContext(ctx, args)
+		return err
+	})
+
+	return rows, err
+}
+
+func (s *stmt) CheckNamedValue(nv *driver.NamedValue) error {
+	if nc, ok := s.Stmt.(driver.NamedValueChecker); ok {
+		return nc.CheckNamedValue(nv)
+	}
+
+	return driver.ErrSkip
+}
+
+func namedValuesToValues(args []driver.NamedValue) ([]driver.Value, error) {
+	values := make([]driver.Value, len(args))
+	for i, arg := range args {
+		if arg.Name != "" {
+			return nil, errNamedArgs
+		}
+		values[i] = arg.Value
+	}
+
+	return values, nil
+}
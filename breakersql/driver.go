@@ -0,0 +1,191 @@
+package breakersql
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"net"
+	"time"
+
+	"github.com/carl-leopard/circuitbreaker/breaker"
+)
+
+var errNamedArgs = errors.New("breakersql: driver does not support the use of Named Parameters")
+
+type Option func(g *Guard)
+
+//Classifier decides whether an error returned by the driver counts as a failure
+type Classifier func(err error) breaker.Outcome
+
+//DefaultClassifier treats broken connections, network errors and timeouts as failures. other errors,
+//e.g. constraint violations or syntax errors, are answered by a healthy database and count as successes
+func DefaultClassifier(err error) breaker.Outcome {
+	var netErr net.Error
+
+	switch {
+	case err == nil, errors.Is(err, context.Canceled):
+		return breaker.OutcomeSuccess
+	case errors.Is(err, driver.ErrBadConn),
+		errors.Is(err, context.DeadlineExceeded),
+		errors.Is(err, io.EOF),
+		errors.Is(err, io.ErrUnexpectedEOF),
+		errors.As(err, &netErr):
+		return breaker.OutcomeFailure
+	default:
+		return breaker.OutcomeSuccess
+	}
+}
+
+//WithClassifier replaces DefaultClassifier
+func WithClassifier(f Classifier) Option {
+	return func(g *Guard) {
+		if f != nil {
+			g.classify = f
+		}
+	}
+}
+
+//Guard runs database calls under a circuit breaker, shared by the driver wrapper and ORM adapters
+type Guard struct {
+	cb       *breaker.CircuitBreaker
+	classify Classifier
+}
+
+//NewGuard return a guard of cb
+func NewGuard(cb *breaker.CircuitBreaker, opts ...Option) *Guard {
+	g := &Guard{
+		cb:       cb,
+		classify: DefaultClassifier,
+	}
+
+	for _, opt := range opts {
+		opt(g)
+	}
+
+	return g
+}
+
+//Do runs fn if circuit breaker allows it, see Before and After
+func (g *Guard) Do(ctx context.Context, fn func() error) error {
+	if err := g.Before(); err != nil {
+		return err
+	}
+
+	start := time.Now()
+	err := fn()
+	g.After(ctx, start, err)
+
+	return err
+}
+
+//Before reports a call, a non-nil error means the call must not run
+func (g *Guard) Before() error {
+	return g.cb.ReportRequest()
+}
+
+//After reports latency of a call started at start and classifies its err
+func (g *Guard) After(ctx context.Context, start time.Time, err error) {
+	g.cb.ReportLatency(time.Since(start))
+
+	//ErrSkip only asks database/sql to take another path, nothing is sent to database
+	if err != driver.ErrSkip && g.classify(err) == breaker.OutcomeFailure {
+		g.cb.ReportErrorContext(ctx)
+	}
+}
+
+//OpenDB opens a *sql.DB whose connections run under cb
+func OpenDB(c driver.Connector, cb *breaker.CircuitBreaker, opts ...Option) *sql.DB {
+	return sql.OpenDB(NewConnector(c, cb, opts...))
+}
+
+//NewConnector decorates c, so every Connect/Ping/Exec/Query/Prepare/Begin runs under cb
+func NewConnector(c driver.Connector, cb *breaker.CircuitBreaker, opts ...Option) driver.Connector {
+	return &connector{Connector: c, g: NewGuard(cb, opts...)}
+}
+
+//Wrap decorates d for sql.Register, see NewConnector
+func Wrap(d driver.Driver, cb *breaker.CircuitBreaker, opts ...Option) driver.Driver {
+	return &wrappedDriver{Driver: d, g: NewGuard(cb, opts...)}
+}
+
+type wrappedDriver struct {
+	driver.Driver
+	g *Guard
+}
+
+func (d *wrappedDriver) Open(name string) (driver.Conn, error) {
+	var c driver.Conn
+	err := d.g.Do(context.Background(), func() (err error) {
+		c, err = d.Driver.Open(name)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &conn{Conn: c, g: d.g}, nil
+}
+
+//OpenConnector implements driver.DriverContext
+func (d *wrappedDriver) OpenConnector(name string) (driver.Connector, error) {
+	if dc, ok := d.Driver.(driver.DriverContext); ok {
+		c, err := dc.OpenConnector(name)
+		if err != nil {
+			return nil, err
+		}
+		return &connector{Connector: c, g: d.g, driver: d}, nil
+	}
+
+	return &connector{Connector: dsnConnector{name: name, driver: d.Driver}, g: d.g, driver: d}, nil
+}
+
+type dsnConnector struct {
+	name   string
+	driver driver.Driver
+}
+
+func (c dsnConnector) Connect(context.Context) (driver.Conn, error) {
+	return c.driver.Open(c.name)
+}
+
+func (c dsnConnector) Driver() driver.Driver {
+	return c.driver
+}
+
+type connector struct {
+	driver.Connector
+	g      *Guard
+	driver driver.Driver
+}
+
+func (c *connector) Connect(ctx context.Context) (driver.Conn, error) {
+	var dc driver.Conn
+	err := c.g.Do(ctx, func() (err error) {
+		dc, err = c.Connector.Connect(ctx)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &conn{Conn: dc, g: c.g}, nil
+}
+
+func (c *connector) Driver() driver.Driver {
+	if c.driver != nil {
+		return c.driver
+	}
+
+	return &wrappedDriver{Driver: c.Connector.Driver(), g: c.g}
+}
+
+//Close implements io.Closer, which database/sql calls on connectors when DB is closed
+func (c *connector) Close() error {
+	if closer, ok := c.Connector.(io.Closer); ok {
+		return closer.Close()
+	}
+
+	return nil
+}
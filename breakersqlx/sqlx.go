@@ -0,0 +1,33 @@
+package breakersqlx
+
+import (
+	"database/sql"
+	"database/sql/driver"
+
+	"github.com/carl-leopard/circuitbreaker/breaker"
+	"github.com/carl-leopard/circuitbreaker/breakersql"
+	"github.com/jmoiron/sqlx"
+)
+
+//Open works like sqlx.Open, all calls of the returned DB run under cb
+func Open(driverName, dataSourceName string, cb *breaker.CircuitBreaker, opts ...breakersql.Option) (*sqlx.DB, error) {
+	//only used to look up the registered driver, sql.Open doesn't connect
+	db, err := sql.Open(driverName, dataSourceName)
+	if err != nil {
+		return nil, err
+	}
+	d := db.Driver()
+	db.Close()
+
+	c, err := breakersql.Wrap(d, cb, opts...).(driver.DriverContext).OpenConnector(dataSourceName)
+	if err != nil {
+		return nil, err
+	}
+
+	return sqlx.NewDb(sql.OpenDB(c), driverName), nil
+}
+
+//NewDb wraps connector c like breakersql.OpenDB and returns it as *sqlx.DB of driverName
+func NewDb(c driver.Connector, driverName string, cb *breaker.CircuitBreaker, opts ...breakersql.Option) *sqlx.DB {
+	return sqlx.NewDb(breakersql.OpenDB(c, cb, opts...), driverName)
+}
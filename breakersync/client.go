@@ -0,0 +1,267 @@
+package breakersync
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/carl-leopard/circuitbreaker/breaker"
+	"github.com/carl-leopard/circuitbreaker/breakersync/syncpb"
+	"google.golang.org/grpc"
+)
+
+const defaultReconnectDelay = time.Second
+
+var errQueueFull = errors.New("breakersync: send queue is full")
+
+type Option func(c *Client)
+
+//WithReconnectDelay sets how long Run waits before reconnecting a broken stream, default 1s
+func WithReconnectDelay(d time.Duration) Option {
+	return func(c *Client) {
+		if d > 0 {
+			c.reconnectDelay = d
+		}
+	}
+}
+
+//Client exchanges window summaries and transitions with a peer or aggregator running Server.
+//it implements breaker.Store, windows sum counts of all instances it hears from, so breakers trip on
+//fleet-wide counts, and trips of peers are passed to the watcher. only one watcher is supported
+type Client struct {
+	client         syncpb.PeerSyncClient
+	instance       string
+	reconnectDelay time.Duration
+
+	out       chan *syncpb.Message
+	incoming  chan breaker.Transition
+	connected int32         //1 while a stream is open
+	changed   chan struct{} //signaled when connected changes
+
+	mu    sync.Mutex
+	local map[string]breaker.Window            //breaker name => window of this instance
+	peers map[string]map[string]breaker.Window //breaker name => instance => latest window
+}
+
+var _ breaker.Store = (*Client)(nil)
+
+//NewClient return a client on cc identified by instance, call Run to connect
+func NewClient(cc grpc.ClientConnInterface, instance string, opts ...Option) *Client {
+	c := &Client{
+		client:         syncpb.NewPeerSyncClient(cc),
+		instance:       instance,
+		reconnectDelay: defaultReconnectDelay,
+		out:            make(chan *syncpb.Message, streamBuffer),
+		incoming:       make(chan breaker.Transition, streamBuffer),
+		changed:        make(chan struct{}, 1),
+		local:          make(map[string]breaker.Window),
+		peers:          make(map[string]map[string]breaker.Window),
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
+}
+
+//Run keeps a stream to the peer open until ctx is done, reconnecting when it breaks
+func (c *Client) Run(ctx context.Context) error {
+	for {
+		//a broken stream is retried until ctx is done
+		c.exchange(ctx)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		select {
+		case <-time.After(c.reconnectDelay):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func (c *Client) exchange(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	stream, err := c.client.Exchange(ctx)
+	if err != nil {
+		return err
+	}
+	if _, err := stream.Header(); err != nil {
+		return err
+	}
+
+	c.setConnected(true)
+	defer c.setConnected(false)
+
+	go func() {
+		for {
+			select {
+			case m := <-c.out:
+				if err := stream.Send(m); err != nil {
+					cancel()
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	for {
+		m, err := stream.Recv()
+		if err != nil {
+			return err
+		}
+		if m.GetInstance() == c.instance {
+			continue
+		}
+
+		switch {
+		case m.GetWindow() != nil:
+			c.storePeerWindow(m.GetInstance(), m.GetWindow())
+		case m.GetTransition() != nil:
+			select {
+			case c.incoming <- fromProtoTransition(m.GetInstance(), m.GetTransition()):
+			default:
+			}
+		}
+	}
+}
+
+func (c *Client) setConnected(connected bool) {
+	var v int32
+	if connected {
+		v = 1
+	}
+	atomic.StoreInt32(&c.connected, v)
+
+	select {
+	case c.changed <- struct{}{}:
+	default:
+	}
+}
+
+//Connected reports whether a stream to the peer is open
+func (c *Client) Connected() bool {
+	return atomic.LoadInt32(&c.connected) == 1
+}
+
+func (c *Client) storePeerWindow(instance string, pw *syncpb.WindowSummary) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	byInstance, ok := c.peers[pw.GetName()]
+	if !ok {
+		byInstance = make(map[string]breaker.Window)
+		c.peers[pw.GetName()] = byInstance
+	}
+	byInstance[instance] = fromProtoWindow(pw)
+}
+
+func (c *Client) send(m *syncpb.Message) error {
+	m.Instance = c.instance
+
+	select {
+	case c.out <- m:
+		return nil
+	default:
+		return errQueueFull
+	}
+}
+
+//IncrWindow implements breaker.WindowCounter, the totals include the latest windows of peers starting at start
+func (c *Client) IncrWindow(_ context.Context, name string, start time.Time, requests, errors uint32) (breaker.Window, error) {
+	c.mu.Lock()
+	w := c.local[name]
+	if !w.Start.Equal(start) {
+		w = breaker.Window{Start: start}
+	}
+	w.Requests = breaker.SaturatedCount(uint64(w.Requests) + uint64(requests))
+	w.Errors = breaker.SaturatedCount(uint64(w.Errors) + uint64(errors))
+	c.local[name] = w
+
+	ws := []breaker.Window{w}
+	for _, pw := range c.peers[name] {
+		ws = append(ws, pw)
+	}
+	c.mu.Unlock()
+
+	//peers fall back to their own counts if the summary is lost, so a full queue is not an error of counting
+	c.send(&syncpb.Message{Body: &syncpb.Message_Window{Window: toProtoWindow(name, w, 0)}})
+
+	return sumWindows(start, ws...), nil
+}
+
+//ReadWindow implements breaker.WindowReader, the totals include the latest windows of peers starting at start
+func (c *Client) ReadWindow(_ context.Context, name string, start time.Time) (breaker.Window, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	ws := []breaker.Window{c.local[name]}
+	for _, pw := range c.peers[name] {
+		ws = append(ws, pw)
+	}
+
+	return sumWindows(start, ws...), nil
+}
+
+//PublishTransition implements breaker.TransitionPublisher
+func (c *Client) PublishTransition(_ context.Context, t breaker.Transition) error {
+	return c.send(&syncpb.Message{Body: &syncpb.Message_Transition{Transition: toProtoTransition(t)}})
+}
+
+//WatchTransitions implements breaker.TransitionWatcher
+func (c *Client) WatchTransitions(ctx context.Context, fn func(breaker.Transition)) error {
+	for {
+		select {
+		case t := <-c.incoming:
+			fn(t)
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+//Follow makes circuit breakers of r follow a coordinator, see WithDecisions, until ctx is done. while connected
+//they are set following, see breaker.CircuitBreaker.SetFollowing, and trip by transitions pushed by the coordinator
+//or peers. while not they fall back to decide on local counts. those of breaker.DecisionLocal never follow.
+//it consumes transitions, don't use it with WatchTransitions. circuit breakers registered later follow from the
+//next reconnect delay
+func (c *Client) Follow(ctx context.Context, r *breaker.Registry) error {
+	t := time.NewTicker(c.reconnectDelay)
+	defer t.Stop()
+	defer setFollowing(r, false)
+
+	setFollowing(r, c.Connected())
+	for {
+		select {
+		case tr := <-c.incoming:
+			if tr.To != breaker.CircuitBreakerStatusOpen {
+				continue
+			}
+			if cb, ok := r.Get(tr.Name); ok {
+				cb.TripFromPeer(time.Until(tr.Until))
+			}
+		case <-c.changed:
+			setFollowing(r, c.Connected())
+		case <-t.C:
+			setFollowing(r, c.Connected())
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func setFollowing(r *breaker.Registry, following bool) {
+	for _, name := range r.Names() {
+		if cb, ok := r.Get(name); ok && cb.Decision() != breaker.DecisionLocal {
+			cb.SetFollowing(following)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package breakersync
+
+import (
+	"time"
+
+	"github.com/carl-leopard/circuitbreaker/breaker"
+	"github.com/carl-leopard/circuitbreaker/breakersync/syncpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func toProtoTransition(t breaker.Transition) *syncpb.Transition {
+	pt := &syncpb.Transition{
+		Name:  t.Name,
+		From:  syncpb.Status(t.From),
+		To:    syncpb.Status(t.To),
+		Cause: syncpb.Cause(t.Cause),
+		Time:  timestamppb.New(t.Time),
+		Zone:  t.Zone,
+	}
+	if !t.Until.IsZero() {
+		pt.Until = timestamppb.New(t.Until)
+	}
+
+	return pt
+}
+
+func fromProtoTransition(instance string, pt *syncpb.Transition) breaker.Transition {
+	t := breaker.Transition{
+		Name:     pt.GetName(),
+		From:     int32(pt.GetFrom()),
+		To:       int32(pt.GetTo()),
+		Cause:    breaker.TransitionCause(pt.GetCause()),
+		Time:     pt.GetTime().AsTime(),
+		Instance: instance,
+		Zone:     pt.GetZone(),
+	}
+	if pt.Until != nil {
+		t.Until = pt.GetUntil().AsTime()
+	}
+
+	return t
+}
+
+func toProtoWindow(name string, w breaker.Window, status int32) *syncpb.WindowSummary {
+	return &syncpb.WindowSummary{
+		Name:     name,
+		Start:    timestamppb.New(w.Start),
+		Requests: uint64(w.Requests),
+		Errors:   uint64(w.Errors),
+		Status:   syncpb.Status(status),
+	}
+}
+
+func fromProtoWindow(ws *syncpb.WindowSummary) breaker.Window {
+	return breaker.Window{
+		Start:    ws.GetStart().AsTime(),
+		Requests: breaker.SaturatedCount(ws.GetRequests()),
+		Errors:   breaker.SaturatedCount(ws.GetErrors()),
+	}
+}
+
+//sumWindows adds windows starting at start
+func sumWindows(start time.Time, ws ...breaker.Window) breaker.Window {
+	sum := breaker.Window{Start: start}
+	for _, w := range ws {
+		if w.Start.Equal(start) {
+			sum.Requests = breaker.SaturatedCount(uint64(sum.Requests) + uint64(w.Requests))
+			sum.Errors = breaker.SaturatedCount(uint64(sum.Errors) + uint64(w.Errors))
+		}
+	}
+
+	return sum
+}
@@ -0,0 +1,258 @@
+package breakersync
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/carl-leopard/circuitbreaker/breaker"
+	"github.com/carl-leopard/circuitbreaker/breakersync/syncpb"
+)
+
+const streamBuffer = 256
+
+//CoordinatorInstance instance of transitions decided by a Server with WithDecisions
+const CoordinatorInstance = "coordinator"
+
+type ServerOption func(s *Server)
+
+//WithDecisions makes Server a coordinator: when counts of a breaker summed over all instances reach thresholds of oc,
+//it pushes a trip for sleepWindow to every instance, see Client.Follow. only the thresholds of oc are used,
+//windows are those of the instances
+func WithDecisions(oc breaker.CircuitBreakerOpenConfig, sleepWindow time.Duration) ServerOption {
+	return func(s *Server) {
+		s.decide = true
+		s.openConfig = oc
+		s.sleepWindow = sleepWindow
+	}
+}
+
+//WithQuorum makes a coordinator trip a breaker only when at least fraction of the instances reporting its
+//latest window breach the error threshold on their own counts, e.g. 0.5 for half of them,
+//so one instance with a broken network can't open it for the whole fleet. default 0, fleet-wide counts alone decide
+func WithQuorum(fraction float64) ServerOption {
+	return func(s *Server) {
+		if fraction > 1 {
+			fraction = 1
+		}
+		s.quorum = fraction
+	}
+}
+
+//Server implements syncpb.PeerSyncServer as an aggregator. it relays every message received from one instance
+//to all others and keeps the latest window of each breaker per instance for fleet-wide views,
+//with WithDecisions it also trips breakers of all instances on fleet-wide counts
+type Server struct {
+	syncpb.UnimplementedPeerSyncServer
+
+	decide      bool
+	openConfig  breaker.CircuitBreakerOpenConfig
+	sleepWindow time.Duration
+	quorum      float64
+
+	mu        sync.RWMutex
+	streams   map[chan *syncpb.Message]struct{}
+	windows   map[string]map[string]*syncpb.WindowSummary //breaker name => instance => latest window
+	openUntil map[string]time.Time                        //breaker name => end of sleep window decided
+}
+
+//NewServer return an aggregator, register it with syncpb.RegisterPeerSyncServer
+func NewServer(opts ...ServerOption) *Server {
+	s := &Server{
+		streams:   make(map[chan *syncpb.Message]struct{}),
+		windows:   make(map[string]map[string]*syncpb.WindowSummary),
+		openUntil: make(map[string]time.Time),
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+//Exchange implements syncpb.PeerSyncServer. a slow instance misses messages rather than holding up others
+func (s *Server) Exchange(stream syncpb.PeerSync_ExchangeServer) error {
+	//tells the client it is connected before any message is exchanged
+	if err := stream.SendHeader(nil); err != nil {
+		return err
+	}
+
+	out := make(chan *syncpb.Message, streamBuffer)
+	s.mu.Lock()
+	s.streams[out] = struct{}{}
+	s.mu.Unlock()
+
+	defer func() {
+		s.mu.Lock()
+		delete(s.streams, out)
+		s.mu.Unlock()
+	}()
+
+	errc := make(chan error, 1)
+	go func() {
+		for {
+			select {
+			case m := <-out:
+				if err := stream.Send(m); err != nil {
+					errc <- err
+					return
+				}
+			case <-stream.Context().Done():
+				return
+			}
+		}
+	}()
+
+	for {
+		m, err := stream.Recv()
+		if err != nil {
+			return err
+		}
+
+		if w := m.GetWindow(); w != nil {
+			s.storeWindow(m.GetInstance(), w)
+		}
+		s.relay(out, m)
+
+		if w := m.GetWindow(); w != nil && s.decide {
+			s.evaluate(w.GetName())
+		}
+
+		select {
+		case err := <-errc:
+			return err
+		default:
+		}
+	}
+}
+
+func (s *Server) storeWindow(instance string, w *syncpb.WindowSummary) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	byInstance, ok := s.windows[w.GetName()]
+	if !ok {
+		byInstance = make(map[string]*syncpb.WindowSummary)
+		s.windows[w.GetName()] = byInstance
+	}
+	byInstance[instance] = w
+}
+
+func (s *Server) relay(from chan *syncpb.Message, m *syncpb.Message) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for out := range s.streams {
+		if out == from {
+			continue
+		}
+
+		select {
+		case out <- m:
+		default:
+		}
+	}
+}
+
+//evaluate pushes a trip of name to all instances if its fleet window reaches thresholds
+func (s *Server) evaluate(name string) {
+	s.mu.Lock()
+	now := time.Now()
+	if now.Before(s.openUntil[name]) {
+		s.mu.Unlock()
+		return
+	}
+
+	fw := s.fleetWindow(name, s.windows[name])
+	if !reachesThresholds(s.openConfig, fw.Window) || !s.quorumBreaching(fw, s.windows[name]) {
+		s.mu.Unlock()
+		return
+	}
+
+	until := now.Add(s.sleepWindow)
+	s.openUntil[name] = until
+	s.mu.Unlock()
+
+	s.relay(nil, &syncpb.Message{
+		Instance: CoordinatorInstance,
+		Body: &syncpb.Message_Transition{Transition: toProtoTransition(breaker.Transition{
+			Name:  name,
+			From:  breaker.CircuitBreakerStatusClosed,
+			To:    breaker.CircuitBreakerStatusOpen,
+			Cause: breaker.CauseSharedWindow,
+			Time:  now,
+			Until: until,
+		})},
+	})
+}
+
+//quorumBreaching reports whether enough instances of the window of fw breach the error threshold, s.mu must be held
+func (s *Server) quorumBreaching(fw FleetWindow, byInstance map[string]*syncpb.WindowSummary) bool {
+	if s.quorum <= 0 {
+		return true
+	}
+
+	breaching := 0
+	for _, pw := range byInstance {
+		w := fromProtoWindow(pw)
+		if w.Start.Equal(fw.Window.Start) && w.Errors > 0 &&
+			uint64(w.Errors)*100 >= uint64(w.Requests)*uint64(s.openConfig.ErrorThresholdPercent) {
+			breaching++
+		}
+	}
+
+	return float64(breaching) >= s.quorum*float64(fw.Instances)
+}
+
+func reachesThresholds(oc breaker.CircuitBreakerOpenConfig, w breaker.Window) bool {
+	return w.Requests >= oc.RequestVolumeThreshold &&
+		uint64(w.Errors)*100 >= uint64(w.Requests)*uint64(oc.ErrorThresholdPercent)
+}
+
+//FleetWindow counts of a breaker summed over the instances reporting its latest window
+type FleetWindow struct {
+	Name      string
+	Window    breaker.Window
+	Instances int
+}
+
+//Windows returns fleet windows of all breakers sorted by name
+func (s *Server) Windows() []FleetWindow {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	out := make([]FleetWindow, 0, len(s.windows))
+	for name, byInstance := range s.windows {
+		out = append(out, s.fleetWindow(name, byInstance))
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].Name < out[j].Name
+	})
+	return out
+}
+
+//fleetWindow sums the latest windows of instances, s.mu must be held
+func (s *Server) fleetWindow(name string, byInstance map[string]*syncpb.WindowSummary) FleetWindow {
+	var (
+		start time.Time
+		ws    = make([]breaker.Window, 0, len(byInstance))
+	)
+	for _, pw := range byInstance {
+		w := fromProtoWindow(pw)
+		if w.Start.After(start) {
+			start = w.Start
+		}
+		ws = append(ws, w)
+	}
+
+	fw := FleetWindow{Name: name, Window: sumWindows(start, ws...)}
+	for _, w := range ws {
+		if w.Start.Equal(start) {
+			fw.Instances++
+		}
+	}
+
+	return fw
+}
@@ -0,0 +1,517 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.12
+// 	protoc        (unknown)
+// source: sync.proto
+
+// Protocol for circuit breakers to exchange window summaries and transitions with peers or a central aggregator.
+
+package syncpb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// Status values match breaker.CircuitBreakerStatus* constants
+type Status int32
+
+const (
+	Status_STATUS_UNSPECIFIED Status = 0
+	Status_STATUS_CLOSED      Status = 1
+	Status_STATUS_OPEN        Status = 2
+	Status_STATUS_HALF_OPEN   Status = 3
+)
+
+// Enum value maps for Status.
+var (
+	Status_name = map[int32]string{
+		0: "STATUS_UNSPECIFIED",
+		1: "STATUS_CLOSED",
+		2: "STATUS_OPEN",
+		3: "STATUS_HALF_OPEN",
+	}
+	Status_value = map[string]int32{
+		"STATUS_UNSPECIFIED": 0,
+		"STATUS_CLOSED":      1,
+		"STATUS_OPEN":        2,
+		"STATUS_HALF_OPEN":   3,
+	}
+)
+
+func (x Status) Enum() *Status {
+	p := new(Status)
+	*p = x
+	return p
+}
+
+func (x Status) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Status) Descriptor() protoreflect.EnumDescriptor {
+	return file_sync_proto_enumTypes[0].Descriptor()
+}
+
+func (Status) Type() protoreflect.EnumType {
+	return &file_sync_proto_enumTypes[0]
+}
+
+func (x Status) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Status.Descriptor instead.
+func (Status) EnumDescriptor() ([]byte, []int) {
+	return file_sync_proto_rawDescGZIP(), []int{0}
+}
+
+// Cause values match breaker.Cause* constants
+type Cause int32
+
+const (
+	Cause_CAUSE_UNSPECIFIED   Cause = 0
+	Cause_CAUSE_ERRORS        Cause = 1
+	Cause_CAUSE_SLEEP_WINDOW  Cause = 2
+	Cause_CAUSE_SHARED_WINDOW Cause = 3
+	Cause_CAUSE_MANUAL        Cause = 4
+	Cause_CAUSE_PEER          Cause = 5
+	Cause_CAUSE_RESTORED      Cause = 6
+)
+
+// Enum value maps for Cause.
+var (
+	Cause_name = map[int32]string{
+		0: "CAUSE_UNSPECIFIED",
+		1: "CAUSE_ERRORS",
+		2: "CAUSE_SLEEP_WINDOW",
+		3: "CAUSE_SHARED_WINDOW",
+		4: "CAUSE_MANUAL",
+		5: "CAUSE_PEER",
+		6: "CAUSE_RESTORED",
+	}
+	Cause_value = map[string]int32{
+		"CAUSE_UNSPECIFIED":   0,
+		"CAUSE_ERRORS":        1,
+		"CAUSE_SLEEP_WINDOW":  2,
+		"CAUSE_SHARED_WINDOW": 3,
+		"CAUSE_MANUAL":        4,
+		"CAUSE_PEER":          5,
+		"CAUSE_RESTORED":      6,
+	}
+)
+
+func (x Cause) Enum() *Cause {
+	p := new(Cause)
+	*p = x
+	return p
+}
+
+func (x Cause) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Cause) Descriptor() protoreflect.EnumDescriptor {
+	return file_sync_proto_enumTypes[1].Descriptor()
+}
+
+func (Cause) Type() protoreflect.EnumType {
+	return &file_sync_proto_enumTypes[1]
+}
+
+func (x Cause) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Cause.Descriptor instead.
+func (Cause) EnumDescriptor() ([]byte, []int) {
+	return file_sync_proto_rawDescGZIP(), []int{1}
+}
+
+type Message struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// instance the message originates from
+	Instance string `protobuf:"bytes,1,opt,name=instance,proto3" json:"instance,omitempty"`
+	// Types that are valid to be assigned to Body:
+	//
+	//	*Message_Window
+	//	*Message_Transition
+	Body          isMessage_Body `protobuf_oneof:"body"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Message) Reset() {
+	*x = Message{}
+	mi := &file_sync_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Message) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Message) ProtoMessage() {}
+
+func (x *Message) ProtoReflect() protoreflect.Message {
+	mi := &file_sync_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Message.ProtoReflect.Descriptor instead.
+func (*Message) Descriptor() ([]byte, []int) {
+	return file_sync_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Message) GetInstance() string {
+	if x != nil {
+		return x.Instance
+	}
+	return ""
+}
+
+func (x *Message) GetBody() isMessage_Body {
+	if x != nil {
+		return x.Body
+	}
+	return nil
+}
+
+func (x *Message) GetWindow() *WindowSummary {
+	if x != nil {
+		if x, ok := x.Body.(*Message_Window); ok {
+			return x.Window
+		}
+	}
+	return nil
+}
+
+func (x *Message) GetTransition() *Transition {
+	if x != nil {
+		if x, ok := x.Body.(*Message_Transition); ok {
+			return x.Transition
+		}
+	}
+	return nil
+}
+
+type isMessage_Body interface {
+	isMessage_Body()
+}
+
+type Message_Window struct {
+	Window *WindowSummary `protobuf:"bytes,2,opt,name=window,proto3,oneof"`
+}
+
+type Message_Transition struct {
+	Transition *Transition `protobuf:"bytes,3,opt,name=transition,proto3,oneof"`
+}
+
+func (*Message_Window) isMessage_Body() {}
+
+func (*Message_Transition) isMessage_Body() {}
+
+// WindowSummary counts of a breaker on one instance in the refresh interval starting at start
+type WindowSummary struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Start         *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=start,proto3" json:"start,omitempty"`
+	Requests      uint64                 `protobuf:"varint,3,opt,name=requests,proto3" json:"requests,omitempty"`
+	Errors        uint64                 `protobuf:"varint,4,opt,name=errors,proto3" json:"errors,omitempty"`
+	Status        Status                 `protobuf:"varint,5,opt,name=status,proto3,enum=circuitbreaker.sync.v1.Status" json:"status,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WindowSummary) Reset() {
+	*x = WindowSummary{}
+	mi := &file_sync_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WindowSummary) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WindowSummary) ProtoMessage() {}
+
+func (x *WindowSummary) ProtoReflect() protoreflect.Message {
+	mi := &file_sync_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WindowSummary.ProtoReflect.Descriptor instead.
+func (*WindowSummary) Descriptor() ([]byte, []int) {
+	return file_sync_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *WindowSummary) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *WindowSummary) GetStart() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Start
+	}
+	return nil
+}
+
+func (x *WindowSummary) GetRequests() uint64 {
+	if x != nil {
+		return x.Requests
+	}
+	return 0
+}
+
+func (x *WindowSummary) GetErrors() uint64 {
+	if x != nil {
+		return x.Errors
+	}
+	return 0
+}
+
+func (x *WindowSummary) GetStatus() Status {
+	if x != nil {
+		return x.Status
+	}
+	return Status_STATUS_UNSPECIFIED
+}
+
+type Transition struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Name  string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	From  Status                 `protobuf:"varint,2,opt,name=from,proto3,enum=circuitbreaker.sync.v1.Status" json:"from,omitempty"`
+	To    Status                 `protobuf:"varint,3,opt,name=to,proto3,enum=circuitbreaker.sync.v1.Status" json:"to,omitempty"`
+	Cause Cause                  `protobuf:"varint,4,opt,name=cause,proto3,enum=circuitbreaker.sync.v1.Cause" json:"cause,omitempty"`
+	Time  *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=time,proto3" json:"time,omitempty"`
+	// end of sleep window, only when to is STATUS_OPEN
+	Until *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=until,proto3" json:"until,omitempty"`
+	// region or zone of the instance, see breaker.Zone
+	Zone          string `protobuf:"bytes,7,opt,name=zone,proto3" json:"zone,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Transition) Reset() {
+	*x = Transition{}
+	mi := &file_sync_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Transition) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Transition) ProtoMessage() {}
+
+func (x *Transition) ProtoReflect() protoreflect.Message {
+	mi := &file_sync_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Transition.ProtoReflect.Descriptor instead.
+func (*Transition) Descriptor() ([]byte, []int) {
+	return file_sync_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Transition) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Transition) GetFrom() Status {
+	if x != nil {
+		return x.From
+	}
+	return Status_STATUS_UNSPECIFIED
+}
+
+func (x *Transition) GetTo() Status {
+	if x != nil {
+		return x.To
+	}
+	return Status_STATUS_UNSPECIFIED
+}
+
+func (x *Transition) GetCause() Cause {
+	if x != nil {
+		return x.Cause
+	}
+	return Cause_CAUSE_UNSPECIFIED
+}
+
+func (x *Transition) GetTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Time
+	}
+	return nil
+}
+
+func (x *Transition) GetUntil() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Until
+	}
+	return nil
+}
+
+func (x *Transition) GetZone() string {
+	if x != nil {
+		return x.Zone
+	}
+	return ""
+}
+
+var File_sync_proto protoreflect.FileDescriptor
+
+const file_sync_proto_rawDesc = "" +
+	"\n" +
+	"\n" +
+	"sync.proto\x12\x16circuitbreaker.sync.v1\x1a\x1fgoogle/protobuf/timestamp.proto\"\xb4\x01\n" +
+	"\aMessage\x12\x1a\n" +
+	"\binstance\x18\x01 \x01(\tR\binstance\x12?\n" +
+	"\x06window\x18\x02 \x01(\v2%.circuitbreaker.sync.v1.WindowSummaryH\x00R\x06window\x12D\n" +
+	"\n" +
+	"transition\x18\x03 \x01(\v2\".circuitbreaker.sync.v1.TransitionH\x00R\n" +
+	"transitionB\x06\n" +
+	"\x04body\"\xc1\x01\n" +
+	"\rWindowSummary\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x120\n" +
+	"\x05start\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\x05start\x12\x1a\n" +
+	"\brequests\x18\x03 \x01(\x04R\brequests\x12\x16\n" +
+	"\x06errors\x18\x04 \x01(\x04R\x06errors\x126\n" +
+	"\x06status\x18\x05 \x01(\x0e2\x1e.circuitbreaker.sync.v1.StatusR\x06status\"\xaf\x02\n" +
+	"\n" +
+	"Transition\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x122\n" +
+	"\x04from\x18\x02 \x01(\x0e2\x1e.circuitbreaker.sync.v1.StatusR\x04from\x12.\n" +
+	"\x02to\x18\x03 \x01(\x0e2\x1e.circuitbreaker.sync.v1.StatusR\x02to\x123\n" +
+	"\x05cause\x18\x04 \x01(\x0e2\x1d.circuitbreaker.sync.v1.CauseR\x05cause\x12.\n" +
+	"\x04time\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\x04time\x120\n" +
+	"\x05until\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\x05until\x12\x12\n" +
+	"\x04zone\x18\a \x01(\tR\x04zone*Z\n" +
+	"\x06Status\x12\x16\n" +
+	"\x12STATUS_UNSPECIFIED\x10\x00\x12\x11\n" +
+	"\rSTATUS_CLOSED\x10\x01\x12\x0f\n" +
+	"\vSTATUS_OPEN\x10\x02\x12\x14\n" +
+	"\x10STATUS_HALF_OPEN\x10\x03*\x97\x01\n" +
+	"\x05Cause\x12\x15\n" +
+	"\x11CAUSE_UNSPECIFIED\x10\x00\x12\x10\n" +
+	"\fCAUSE_ERRORS\x10\x01\x12\x16\n" +
+	"\x12CAUSE_SLEEP_WINDOW\x10\x02\x12\x17\n" +
+	"\x13CAUSE_SHARED_WINDOW\x10\x03\x12\x10\n" +
+	"\fCAUSE_MANUAL\x10\x04\x12\x0e\n" +
+	"\n" +
+	"CAUSE_PEER\x10\x05\x12\x12\n" +
+	"\x0eCAUSE_RESTORED\x10\x062\\\n" +
+	"\bPeerSync\x12P\n" +
+	"\bExchange\x12\x1f.circuitbreaker.sync.v1.Message\x1a\x1f.circuitbreaker.sync.v1.Message(\x010\x01B;Z9github.com/carl-leopard/circuitbreaker/breakersync/syncpbb\x06proto3"
+
+var (
+	file_sync_proto_rawDescOnce sync.Once
+	file_sync_proto_rawDescData []byte
+)
+
+func file_sync_proto_rawDescGZIP() []byte {
+	file_sync_proto_rawDescOnce.Do(func() {
+		file_sync_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_sync_proto_rawDesc), len(file_sync_proto_rawDesc)))
+	})
+	return file_sync_proto_rawDescData
+}
+
+var file_sync_proto_enumTypes = make([]protoimpl.EnumInfo, 2)
+var file_sync_proto_msgTypes = make([]protoimpl.MessageInfo, 3)
+var file_sync_proto_goTypes = []any{
+	(Status)(0),                   // 0: circuitbreaker.sync.v1.Status
+	(Cause)(0),                    // 1: circuitbreaker.sync.v1.Cause
+	(*Message)(nil),               // 2: circuitbreaker.sync.v1.Message
+	(*WindowSummary)(nil),         // 3: circuitbreaker.sync.v1.WindowSummary
+	(*Transition)(nil),            // 4: circuitbreaker.sync.v1.Transition
+	(*timestamppb.Timestamp)(nil), // 5: google.protobuf.Timestamp
+}
+var file_sync_proto_depIdxs = []int32{
+	3,  // 0: circuitbreaker.sync.v1.Message.window:type_name -> circuitbreaker.sync.v1.WindowSummary
+	4,  // 1: circuitbreaker.sync.v1.Message.transition:type_name -> circuitbreaker.sync.v1.Transition
+	5,  // 2: circuitbreaker.sync.v1.WindowSummary.start:type_name -> google.protobuf.Timestamp
+	0,  // 3: circuitbreaker.sync.v1.WindowSummary.status:type_name -> circuitbreaker.sync.v1.Status
+	0,  // 4: circuitbreaker.sync.v1.Transition.from:type_name -> circuitbreaker.sync.v1.Status
+	0,  // 5: circuitbreaker.sync.v1.Transition.to:type_name -> circuitbreaker.sync.v1.Status
+	1,  // 6: circuitbreaker.sync.v1.Transition.cause:type_name -> circuitbreaker.sync.v1.Cause
+	5,  // 7: circuitbreaker.sync.v1.Transition.time:type_name -> google.protobuf.Timestamp
+	5,  // 8: circuitbreaker.sync.v1.Transition.until:type_name -> google.protobuf.Timestamp
+	2,  // 9: circuitbreaker.sync.v1.PeerSync.Exchange:input_type -> circuitbreaker.sync.v1.Message
+	2,  // 10: circuitbreaker.sync.v1.PeerSync.Exchange:output_type -> circuitbreaker.sync.v1.Message
+	10, // [10:11] is the sub-list for method output_type
+	9,  // [9:10] is the sub-list for method input_type
+	9,  // [9:9] is the sub-list for extension type_name
+	9,  // [9:9] is the sub-list for extension extendee
+	0,  // [0:9] is the sub-list for field type_name
+}
+
+func init() { file_sync_proto_init() }
+func file_sync_proto_init() {
+	if File_sync_proto != nil {
+		return
+	}
+	file_sync_proto_msgTypes[0].OneofWrappers = []any{
+		(*Message_Window)(nil),
+		(*Message_Transition)(nil),
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_sync_proto_rawDesc), len(file_sync_proto_rawDesc)),
+			NumEnums:      2,
+			NumMessages:   3,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_sync_proto_goTypes,
+		DependencyIndexes: file_sync_proto_depIdxs,
+		EnumInfos:         file_sync_proto_enumTypes,
+		MessageInfos:      file_sync_proto_msgTypes,
+	}.Build()
+	File_sync_proto = out.File
+	file_sync_proto_goTypes = nil
+	file_sync_proto_depIdxs = nil
+}
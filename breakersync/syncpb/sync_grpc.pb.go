@@ -0,0 +1,121 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.6.2
+// - protoc             (unknown)
+// source: sync.proto
+
+// Protocol for circuit breakers to exchange window summaries and transitions with peers or a central aggregator.
+
+package syncpb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	PeerSync_Exchange_FullMethodName = "/circuitbreaker.sync.v1.PeerSync/Exchange"
+)
+
+// PeerSyncClient is the client API for PeerSync service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type PeerSyncClient interface {
+	// Exchange streams messages both ways until either side closes. an aggregator relays every message
+	// it receives on one stream to all other streams.
+	Exchange(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[Message, Message], error)
+}
+
+type peerSyncClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewPeerSyncClient(cc grpc.ClientConnInterface) PeerSyncClient {
+	return &peerSyncClient{cc}
+}
+
+func (c *peerSyncClient) Exchange(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[Message, Message], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &PeerSync_ServiceDesc.Streams[0], PeerSync_Exchange_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[Message, Message]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type PeerSync_ExchangeClient = grpc.BidiStreamingClient[Message, Message]
+
+// PeerSyncServer is the server API for PeerSync service.
+// All implementations must embed UnimplementedPeerSyncServer
+// for forward compatibility.
+type PeerSyncServer interface {
+	// Exchange streams messages both ways until either side closes. an aggregator relays every message
+	// it receives on one stream to all other streams.
+	Exchange(grpc.BidiStreamingServer[Message, Message]) error
+	mustEmbedUnimplementedPeerSyncServer()
+}
+
+// UnimplementedPeerSyncServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedPeerSyncServer struct{}
+
+func (UnimplementedPeerSyncServer) Exchange(grpc.BidiStreamingServer[Message, Message]) error {
+	return status.Error(codes.Unimplemented, "method Exchange not implemented")
+}
+func (UnimplementedPeerSyncServer) mustEmbedUnimplementedPeerSyncServer() {}
+func (UnimplementedPeerSyncServer) testEmbeddedByValue()                  {}
+
+// UnsafePeerSyncServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to PeerSyncServer will
+// result in compilation errors.
+type UnsafePeerSyncServer interface {
+	mustEmbedUnimplementedPeerSyncServer()
+}
+
+func RegisterPeerSyncServer(s grpc.ServiceRegistrar, srv PeerSyncServer) {
+	// If the following call panics, it indicates UnimplementedPeerSyncServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&PeerSync_ServiceDesc, srv)
+}
+
+func _PeerSync_Exchange_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(PeerSyncServer).Exchange(&grpc.GenericServerStream[Message, Message]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type PeerSync_ExchangeServer = grpc.BidiStreamingServer[Message, Message]
+
+// PeerSync_ServiceDesc is the grpc.ServiceDesc for PeerSync service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var PeerSync_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "circuitbreaker.sync.v1.PeerSync",
+	HandlerType: (*PeerSyncServer)(nil),
+	Methods:     []grpc.MethodDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Exchange",
+			Handler:       _PeerSync_Exchange_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+	},
+	Metadata: "sync.proto",
+}
@@ -0,0 +1,136 @@
+package breakertemporal
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/carl-leopard/circuitbreaker/breaker"
+	"go.temporal.io/sdk/activity"
+	"go.temporal.io/sdk/interceptor"
+	"go.temporal.io/sdk/temporal"
+)
+
+//ErrorType is the application error type of activities rejected by an open breaker, e.g. to match in workflows
+const ErrorType = "CircuitBreakerOpen"
+
+//Classifier decides whether an error returned by an activity counts as a failure
+type Classifier func(err error) breaker.Outcome
+
+//DefaultClassifier treats errors as failures except cancellation and non-retryable application errors,
+//which are business outcomes rather than an unhealthy dependency
+func DefaultClassifier(err error) breaker.Outcome {
+	var appErr *temporal.ApplicationError
+
+	switch {
+	case err == nil, errors.Is(err, context.Canceled), temporal.IsCanceledError(err):
+		return breaker.OutcomeSuccess
+	case errors.As(err, &appErr) && appErr.NonRetryable():
+		return breaker.OutcomeSuccess
+	default:
+		return breaker.OutcomeFailure
+	}
+}
+
+type Option func(o *options)
+
+//WithClassifier replaces DefaultClassifier
+func WithClassifier(f Classifier) Option {
+	return func(o *options) {
+		if f != nil {
+			o.classify = f
+		}
+	}
+}
+
+type options struct {
+	classify Classifier
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{classify: DefaultClassifier}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	return o
+}
+
+//RejectedError converts err returned by a rejected ReportRequest of cb into a retryable application error
+//of ErrorType, whose next retry waits the remaining sleep window instead of the retry policy backoff
+func RejectedError(cb *breaker.CircuitBreaker, err error) error {
+	delay := cb.RemainingSleepWindow()
+	if delay < time.Second {
+		delay = time.Second
+	}
+
+	return temporal.NewApplicationErrorWithOptions("circuit breaker "+cb.Name()+": "+err.Error(), ErrorType,
+		temporal.ApplicationErrorOptions{Cause: err, NextRetryDelay: delay})
+}
+
+//Wrap runs activity fn under cb, see RejectedError for attempts made while cb is open
+func Wrap[In, Out any](cb *breaker.CircuitBreaker, fn func(ctx context.Context, in In) (Out, error), opts ...Option) func(ctx context.Context, in In) (Out, error) {
+	o := newOptions(opts)
+
+	return func(ctx context.Context, in In) (Out, error) {
+		var out Out
+		err := run(ctx, cb, o, func() (err error) {
+			out, err = fn(ctx, in)
+			return err
+		})
+
+		return out, err
+	}
+}
+
+func run(ctx context.Context, cb *breaker.CircuitBreaker, o *options, fn func() error) error {
+	if err := cb.ReportRequestWithMeta(breaker.RequestMeta{Key: cb.Name()}); err != nil {
+		return RejectedError(cb, err)
+	}
+
+	start := time.Now()
+	err := fn()
+	cb.ReportLatency(time.Since(start))
+
+	if o.classify(err) == breaker.OutcomeFailure {
+		cb.ReportErrorContext(ctx)
+	}
+
+	return err
+}
+
+//Interceptor is a worker interceptor running every activity under the breaker of its activity type from a group,
+//register it with worker.Options.Interceptors
+type Interceptor struct {
+	interceptor.WorkerInterceptorBase
+
+	g *breaker.Group
+	o *options
+}
+
+//NewInterceptor return an interceptor with breakers from g
+func NewInterceptor(g *breaker.Group, opts ...Option) *Interceptor {
+	return &Interceptor{g: g, o: newOptions(opts)}
+}
+
+//InterceptActivity implements interceptor.WorkerInterceptor
+func (i *Interceptor) InterceptActivity(ctx context.Context, next interceptor.ActivityInboundInterceptor) interceptor.ActivityInboundInterceptor {
+	return &activityInbound{ActivityInboundInterceptorBase: interceptor.ActivityInboundInterceptorBase{Next: next}, i: i}
+}
+
+type activityInbound struct {
+	interceptor.ActivityInboundInterceptorBase
+	i *Interceptor
+}
+
+func (a *activityInbound) ExecuteActivity(ctx context.Context, in *interceptor.ExecuteActivityInput) (interface{}, error) {
+	cb := a.i.g.Get(activity.GetInfo(ctx).ActivityType.Name)
+
+	var res interface{}
+	err := run(ctx, cb, a.i.o, func() (err error) {
+		res, err = a.Next.ExecuteActivity(ctx, in)
+		return err
+	})
+
+	return res, err
+}
@@ -0,0 +1,131 @@
+package breakertwirp
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/carl-leopard/circuitbreaker/breaker"
+	"github.com/twitchtv/twirp"
+)
+
+type Option func(o *options)
+
+//WithFailureCodes sets twirp error codes reported as errors, default Unavailable, DeadlineExceeded and Internal
+func WithFailureCodes(codes ...twirp.ErrorCode) Option {
+	return func(o *options) {
+		o.failureCodes = make(map[twirp.ErrorCode]struct{}, len(codes))
+		for _, c := range codes {
+			o.failureCodes[c] = struct{}{}
+		}
+	}
+}
+
+type options struct {
+	failureCodes map[twirp.ErrorCode]struct{}
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{
+		failureCodes: map[twirp.ErrorCode]struct{}{
+			twirp.Unavailable:      {},
+			twirp.DeadlineExceeded: {},
+			twirp.Internal:         {},
+		},
+	}
+
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	return o
+}
+
+type callKey struct{}
+
+//call state of a call passed between hooks
+type call struct {
+	cb       *breaker.CircuitBreaker
+	start    time.Time
+	rejected bool
+}
+
+//key is "Service/Method", with package if present, e.g. "example.Haberdasher/MakeHat"
+func key(ctx context.Context) string {
+	service, _ := twirp.ServiceName(ctx)
+	method, _ := twirp.MethodName(ctx)
+	if pkg, ok := twirp.PackageName(ctx); ok && pkg != "" {
+		service = pkg + "." + service
+	}
+
+	return service + "/" + method
+}
+
+//ServerHooks reject calls with twirp.Unavailable while the breaker of service/method from g is open,
+//errors of failure codes are reported to it
+func ServerHooks(g *breaker.Group, opts ...Option) *twirp.ServerHooks {
+	o := newOptions(opts)
+
+	return &twirp.ServerHooks{
+		RequestRouted: func(ctx context.Context) (context.Context, error) {
+			k := key(ctx)
+			c := &call{cb: g.Get(k), start: time.Now()}
+			ctx = context.WithValue(ctx, callKey{}, c)
+
+			if err := c.cb.ReportRequestWithMeta(breaker.RequestMeta{Key: k}); err != nil {
+				c.rejected = true
+				return ctx, twirp.NewError(twirp.Unavailable, "circuit breaker: "+err.Error())
+			}
+
+			return ctx, nil
+		},
+		ResponseSent: func(ctx context.Context) {
+			if c, ok := ctx.Value(callKey{}).(*call); ok && !c.rejected {
+				c.cb.ReportLatency(time.Since(c.start))
+			}
+		},
+		Error: func(ctx context.Context, err twirp.Error) context.Context {
+			if c, ok := ctx.Value(callKey{}).(*call); ok && !c.rejected {
+				if _, failure := o.failureCodes[err.Code()]; failure {
+					c.cb.ReportErrorContext(ctx)
+				}
+			}
+
+			return ctx
+		},
+	}
+}
+
+//ClientHooks fail calls fast while the breaker of service/method from g is open,
+//errors of failure codes are reported to it
+func ClientHooks(g *breaker.Group, opts ...Option) *twirp.ClientHooks {
+	o := newOptions(opts)
+
+	return &twirp.ClientHooks{
+		RequestPrepared: func(ctx context.Context, req *http.Request) (context.Context, error) {
+			k := key(ctx)
+			c := &call{cb: g.Get(k), start: time.Now()}
+
+			if err := c.cb.ReportRequestWithMeta(breaker.RequestMeta{Key: k}); err != nil {
+				c.rejected = true
+				return context.WithValue(ctx, callKey{}, c), err
+			}
+
+			return context.WithValue(ctx, callKey{}, c), nil
+		},
+		ResponseReceived: func(ctx context.Context) {
+			if c, ok := ctx.Value(callKey{}).(*call); ok && !c.rejected {
+				c.cb.ReportLatency(time.Since(c.start))
+			}
+		},
+		Error: func(ctx context.Context, err twirp.Error) {
+			if c, ok := ctx.Value(callKey{}).(*call); ok && !c.rejected {
+				c.cb.ReportLatency(time.Since(c.start))
+
+				if _, failure := o.failureCodes[err.Code()]; failure {
+					c.cb.ReportErrorContext(ctx)
+				}
+			}
+		},
+	}
+}
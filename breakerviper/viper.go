@@ -0,0 +1,95 @@
+package breakerviper
+
+import (
+	"log"
+	"reflect"
+	"strings"
+
+	"github.com/carl-leopard/circuitbreaker/breaker"
+	"github.com/go-viper/mapstructure/v2"
+	"github.com/spf13/viper"
+)
+
+//Decode decodes breaker.Config at key of v, the whole tree if key is empty. durations may be strings
+//like "30s" and "5m", percentages strings like "20%", e.g.
+//
+//	circuitbreakers:
+//	  breakers:
+//	    - name: payments
+//	      sleep_window: 30s
+func Decode(v *viper.Viper, key string) (*breaker.Config, error) {
+	return DecodeStrictness(v, key, breaker.ConfigDefault)
+}
+
+//DecodeStrictness works like Decode with unknown keys and values out of range treated as of st, see
+//breaker.ConfigStrictness
+func DecodeStrictness(v *viper.Viper, key string, st breaker.ConfigStrictness) (*breaker.Config, error) {
+	var cfg breaker.Config
+	if err := unmarshal(v, key, &cfg, st); err != nil {
+		return nil, err
+	}
+	if st == breaker.ConfigLenient {
+		if err := cfg.Clamp(); err != nil {
+			log.Printf("circuit breaker config out of range, clamped: %v", strings.ReplaceAll(err.Error(), "\n", "; "))
+		}
+	}
+
+	return &cfg, nil
+}
+
+//DecodeSettings decodes breaker.Settings of a single circuit breaker at key of v
+func DecodeSettings(v *viper.Viper, key string) (breaker.Settings, error) {
+	var s breaker.Settings
+	err := unmarshal(v, key, &s, breaker.ConfigDefault)
+
+	return s, err
+}
+
+//Load decodes breaker.Config at key of v and returns a registry of its circuit breakers, like breaker.LoadConfig
+func Load(v *viper.Viper, key string, opts ...breaker.CircuitBreakerOption) (*breaker.Registry, error) {
+	return LoadStrictness(v, key, breaker.ConfigDefault, opts...)
+}
+
+//LoadStrictness works like Load with unknown keys and values out of range treated as of st
+func LoadStrictness(v *viper.Viper, key string, st breaker.ConfigStrictness,
+	opts ...breaker.CircuitBreakerOption) (*breaker.Registry, error) {
+	cfg, err := DecodeStrictness(v, key, st)
+	if err != nil {
+		return nil, err
+	}
+
+	r := breaker.NewRegistry()
+	if err := cfg.Apply(r, opts...); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+//decodeHook adds percentages to the default hooks of viper
+var decodeHook = viper.DecodeHook(mapstructure.ComposeDecodeHookFunc(
+	mapstructure.StringToTimeDurationHookFunc(),
+	mapstructure.StringToSliceHookFunc(","),
+	stringToPercent,
+))
+
+//stringToPercent decodes strings like "20%" into uint8 fields, only percentages are uint8 in breaker.Settings
+func stringToPercent(from, to reflect.Type, data interface{}) (interface{}, error) {
+	if from.Kind() != reflect.String || to.Kind() != reflect.Uint8 {
+		return data, nil
+	}
+
+	return breaker.ParsePercent(data.(string))
+}
+
+//unmarshal decodes key of v into out, unknown keys fail if st is strict
+func unmarshal(v *viper.Viper, key string, out interface{}, st breaker.ConfigStrictness) error {
+	unused := func(c *mapstructure.DecoderConfig) {
+		c.ErrorUnused = st == breaker.ConfigStrict
+	}
+	if key == "" {
+		return v.Unmarshal(out, decodeHook, unused)
+	}
+
+	return v.UnmarshalKey(key, out, decodeHook, unused)
+}
@@ -0,0 +1,195 @@
+package breakerwebhook
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/carl-leopard/circuitbreaker/breaker"
+	"github.com/carl-leopard/circuitbreaker/breakerhttp"
+)
+
+//ErrDeferred is returned by Send when the delivery is spooled because its destination is open
+var ErrDeferred = errors.New("breakerwebhook: delivery deferred")
+
+//Delivery is a webhook to POST to URL
+type Delivery struct {
+	URL    string
+	Header http.Header
+	Body   []byte
+}
+
+type Option func(d *Dispatcher)
+
+//WithClient sets the client sending webhooks, default http.DefaultClient
+func WithClient(c *http.Client) Option {
+	return func(d *Dispatcher) {
+		if c != nil {
+			d.client = c
+		}
+	}
+}
+
+//WithClassifier replaces breakerhttp.DefaultClassifier
+func WithClassifier(f breakerhttp.Classifier) Option {
+	return func(d *Dispatcher) {
+		if f != nil {
+			d.classify = f
+		}
+	}
+}
+
+//WithSpool defers deliveries to s while their destination is open instead of failing them, see Dispatcher.Flush
+func WithSpool(s Spool) Option {
+	return func(d *Dispatcher) {
+		d.spool = s
+	}
+}
+
+//Dispatcher delivers webhooks with a circuit breaker per destination host from a group,
+//so one dead receiver does not hold up deliveries to the others
+type Dispatcher struct {
+	g        *breaker.Group
+	client   *http.Client
+	classify breakerhttp.Classifier
+	spool    Spool
+}
+
+//NewDispatcher return a dispatcher with breakers from g
+func NewDispatcher(g *breaker.Group, opts ...Option) *Dispatcher {
+	d := &Dispatcher{
+		g:        g,
+		client:   http.DefaultClient,
+		classify: breakerhttp.DefaultClassifier,
+	}
+
+	for _, opt := range opts {
+		opt(d)
+	}
+
+	return d
+}
+
+//Send delivers dv. while its destination is open dv is spooled and ErrDeferred returned,
+//or breaker.ErrTooManyErrors without a spool
+func (d *Dispatcher) Send(ctx context.Context, dv Delivery) error {
+	u, err := url.Parse(dv.URL)
+	if err != nil {
+		return err
+	}
+
+	host := u.Host
+	cb := d.g.Get(host)
+	if err := cb.ReportRequestWithMeta(breaker.RequestMeta{Key: host, Method: http.MethodPost}); err != nil {
+		if d.spool == nil {
+			return err
+		}
+		if err := d.spool.Push(ctx, host, dv); err != nil {
+			return err
+		}
+
+		return ErrDeferred
+	}
+
+	_, err = d.deliver(ctx, cb, dv)
+	return err
+}
+
+//deliver sends dv, the outcome tells whether the error is caused by the destination being unhealthy
+func (d *Dispatcher) deliver(ctx context.Context, cb *breaker.CircuitBreaker, dv Delivery) (breaker.Outcome, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, dv.URL, bytes.NewReader(dv.Body))
+	if err != nil {
+		return breaker.OutcomeSuccess, err
+	}
+	for k, vs := range dv.Header {
+		req.Header[k] = vs
+	}
+
+	start := time.Now()
+	resp, err := d.client.Do(req)
+	cb.ReportLatency(time.Since(start))
+
+	if err != nil {
+		if errors.Is(err, context.Canceled) {
+			return breaker.OutcomeSuccess, err
+		}
+
+		cb.ReportErrorContext(ctx)
+		return breaker.OutcomeFailure, err
+	}
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+
+	outcome := d.classify(resp)
+	if outcome == breaker.OutcomeFailure {
+		cb.ReportErrorContext(ctx)
+	}
+	if resp.StatusCode >= http.StatusBadRequest {
+		return outcome, fmt.Errorf("breakerwebhook: %s: %s", dv.URL, resp.Status)
+	}
+
+	return outcome, nil
+}
+
+//Flush redelivers spooled deliveries of destinations no longer open. a half-open destination gets a single
+//delivery as probe first, the rest only follow if the probe succeeds. deliveries failed because the destination
+//is unhealthy go back to the spool, those rejected by a healthy destination, e.g. with 4xx, are dropped and returned as errors
+func (d *Dispatcher) Flush(ctx context.Context) error {
+	if d.spool == nil {
+		return nil
+	}
+
+	hosts, err := d.spool.Hosts(ctx)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, host := range hosts {
+		if err := d.flushHost(ctx, host); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (d *Dispatcher) flushHost(ctx context.Context, host string) error {
+	cb := d.g.Get(host)
+
+	//deliveries rejected by the host are dropped with their errors collected, the rest of the spool still drains
+	var errs []error
+	for {
+		n := 16
+		switch cb.Status() {
+		case breaker.CircuitBreakerStatusOpen:
+			return errors.Join(errs...)
+		case breaker.CircuitBreakerStatusHalfOpen:
+			n = 1
+		}
+
+		ds, err := d.spool.Pop(ctx, host, n)
+		if err != nil || len(ds) == 0 {
+			return errors.Join(append(errs, err)...)
+		}
+
+		for i, dv := range ds {
+			if err := cb.ReportRequestWithMeta(breaker.RequestMeta{Key: host, Method: http.MethodPost}); err != nil {
+				return errors.Join(append(errs, d.spool.Requeue(ctx, host, ds[i:]))...)
+			}
+
+			outcome, err := d.deliver(ctx, cb, dv)
+			if outcome == breaker.OutcomeFailure || errors.Is(err, context.Canceled) {
+				return errors.Join(append(errs, err, d.spool.Requeue(ctx, host, ds[i:]))...)
+			}
+			if err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+}
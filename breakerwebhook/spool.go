@@ -0,0 +1,92 @@
+package breakerwebhook
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+//ErrSpoolFull is returned by a spool that cannot take more deliveries of a destination
+var ErrSpoolFull = errors.New("breakerwebhook: spool is full")
+
+//Spool keeps deliveries deferred while the breaker of their destination is open, e.g. in memory, a database or a queue.
+//it must be safe for concurrent use
+type Spool interface {
+	//Push appends d to deliveries of destination host
+	Push(ctx context.Context, host string, d Delivery) error
+	//Pop removes and returns up to n oldest deliveries of host
+	Pop(ctx context.Context, host string, n int) ([]Delivery, error)
+	//Requeue puts ds popped but not delivered back in front of deliveries of host in one step, in order and
+	//regardless of the size limit, so deliveries pushed meanwhile neither overtake nor crowd them out
+	Requeue(ctx context.Context, host string, ds []Delivery) error
+	//Hosts returns destinations having deliveries
+	Hosts(ctx context.Context) ([]string, error)
+}
+
+//MemorySpool is a Spool in memory holding up to a fixed number of deliveries per destination
+type MemorySpool struct {
+	mu    sync.Mutex
+	size  int
+	hosts map[string][]Delivery
+}
+
+//NewMemorySpool return a spool keeping up to size deliveries per destination
+func NewMemorySpool(size int) *MemorySpool {
+	return &MemorySpool{size: size, hosts: make(map[string][]Delivery)}
+}
+
+func (s *MemorySpool) Push(_ context.Context, host string, d Delivery) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.hosts[host]) >= s.size {
+		return ErrSpoolFull
+	}
+	s.hosts[host] = append(s.hosts[host], d)
+
+	return nil
+}
+
+func (s *MemorySpool) Pop(_ context.Context, host string, n int) ([]Delivery, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ds := s.hosts[host]
+	if n > len(ds) {
+		n = len(ds)
+	}
+
+	out := append([]Delivery(nil), ds[:n]...)
+	if n == len(ds) {
+		delete(s.hosts, host)
+	} else {
+		s.hosts[host] = ds[n:]
+	}
+
+	return out, nil
+}
+
+func (s *MemorySpool) Requeue(_ context.Context, host string, ds []Delivery) error {
+	if len(ds) == 0 {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.hosts[host] = append(append(make([]Delivery, 0, len(ds)+len(s.hosts[host])), ds...), s.hosts[host]...)
+
+	return nil
+}
+
+func (s *MemorySpool) Hosts(context.Context) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	hosts := make([]string, 0, len(s.hosts))
+	for host := range s.hosts {
+		hosts = append(hosts, host)
+	}
+
+	return hosts, nil
+}
@@ -0,0 +1,86 @@
+//breakerbundle inspects a support bundle written by breaker.Registry.WriteBundle.
+//
+//	breakerbundle support.json.gz
+//
+//it prints the breakers as they were when the bundle was written, followed by the events of all of them in time
+//order. -name limits the output to one breaker. load bundles into a registry with breaker.ReadBundle and
+//Bundle.Load for deeper analysis
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/carl-leopard/circuitbreaker/breaker"
+)
+
+func main() {
+	name := flag.String("name", "", "only show breaker of name")
+	flag.Parse()
+
+	if flag.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "usage: breakerbundle [-name breaker] bundle")
+		os.Exit(2)
+	}
+
+	f, err := os.Open(flag.Arg(0))
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer f.Close()
+
+	b, err := breaker.ReadBundle(f)
+	if err != nil {
+		log.Fatalf("breakerbundle: %v", err)
+	}
+
+	fmt.Printf("bundle of %s written %s, %d breakers\n\n", b.Host, b.Created.Format(time.RFC3339), len(b.Breakers))
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "NAME\tSTATUS\tMODE\tDECISION\tREQUESTS\tERRORS\tERROR THRESHOLD\tREQUEST VOLUME\tSLEEP WINDOW\tOPEN UNTIL")
+
+	var events []breaker.Event
+	for _, bb := range b.Breakers {
+		s := bb.Snapshot
+		if *name != "" && s.Name != *name {
+			continue
+		}
+
+		until := "-"
+		if !bb.State.OpenUntil.IsZero() {
+			until = bb.State.OpenUntil.Format(time.RFC3339)
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%d\t%d\t%d%%\t%d\t%s\t%s\n",
+			s.Name, breaker.StatusText(s.Status), bb.State.Mode, bb.Decision, s.RequestVolume, s.ErrorVolume,
+			s.OpenConfig.ErrorThresholdPercent, s.OpenConfig.RequestVolumeThreshold, s.SleepWindow, until)
+
+		events = append(events, bb.Events...)
+	}
+	tw.Flush()
+
+	if len(events) == 0 {
+		return
+	}
+
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].Time.Before(events[j].Time)
+	})
+
+	fmt.Println()
+	tw = tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "TIME\tNAME\tEVENT\tSTATUS\tCAUSE\tKEY\tMETHOD")
+	for _, e := range events {
+		cause := "-"
+		if e.Cause != 0 {
+			cause = e.Cause.String()
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			e.Time.Format(time.RFC3339Nano), e.Name, e.Type, breaker.StatusText(e.Status), cause, e.Meta.Key, e.Meta.Method)
+	}
+	tw.Flush()
+}
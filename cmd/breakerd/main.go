@@ -0,0 +1,80 @@
+//breakerd is a coordinator for circuit breakers of many instances. instances stream their window counts to it
+//by breakersync.Client, it sums them per breaker and pushes a trip to every instance when the fleet-wide counts
+//reach thresholds. with -quorum a fraction of instances must also breach the error threshold on their own counts.
+//
+//	breakerd -listen :7070 -admin :9090 -error-threshold 20 -request-volume 1000 -sleep-window 1m -quorum 0.5
+//
+//instances run Client.Follow to follow its decisions and fall back to their own while it is unreachable.
+//the admin listener serves /windows, fleet-wide counts of the latest window of every breaker as JSON
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/carl-leopard/circuitbreaker/breaker"
+	"github.com/carl-leopard/circuitbreaker/breakersync"
+	"github.com/carl-leopard/circuitbreaker/breakersync/syncpb"
+	"google.golang.org/grpc"
+)
+
+func main() {
+	var (
+		listen = flag.String("listen", ":7070", "address of gRPC service")
+		admin  = flag.String("admin", ":9090", "address of admin endpoints, empty to disable")
+
+		errorThreshold = flag.Uint("error-threshold", 20, "fleet-wide error percent in a window to open a breaker")
+		requestVolume  = flag.Uint("request-volume", 1000, "minimum fleet-wide requests in a window to open a breaker")
+		sleepWindow    = flag.Duration("sleep-window", 3*time.Minute, "how long breakers stay open before half-open")
+		quorum         = flag.Float64("quorum", 0, "fraction of instances whose own counts must breach the error threshold, 0 to decide on fleet-wide counts alone")
+	)
+	flag.Parse()
+
+	srv := breakersync.NewServer(breakersync.WithDecisions(breaker.CircuitBreakerOpenConfig{
+		ErrorThresholdPercent:  uint8(*errorThreshold),
+		RequestVolumeThreshold: uint32(*requestVolume),
+	}, *sleepWindow), breakersync.WithQuorum(*quorum))
+
+	lis, err := net.Listen("tcp", *listen)
+	if err != nil {
+		log.Fatal(err)
+	}
+	gs := grpc.NewServer()
+	syncpb.RegisterPeerSyncServer(gs, srv)
+
+	go func() {
+		if err := gs.Serve(lis); err != nil {
+			log.Fatal(err)
+		}
+	}()
+
+	if *admin != "" {
+		mux := http.NewServeMux()
+		mux.HandleFunc("/windows", func(w http.ResponseWriter, _ *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(srv.Windows())
+		})
+
+		go func() {
+			if err := http.ListenAndServe(*admin, mux); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				log.Fatal(err)
+			}
+		}()
+	}
+	log.Printf("breakerd: listening on %s", *listen)
+
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, syscall.SIGINT, syscall.SIGTERM)
+	<-stop
+
+	//streams of instances never end by themselves
+	gs.Stop()
+}
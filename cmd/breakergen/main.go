@@ -0,0 +1,314 @@
+//breakergen generates a decorator of an interface running every method returning an error under a circuit breaker,
+//so hand-written clients can be guarded without boilerplate per method.
+//
+//	//go:generate breakergen -type Client
+//
+//run in the directory of the package declaring the interface, it writes <type>_breaker.go declaring
+//<Type>Breaker and New<Type>Breaker. a method with context.Context as first parameter reports errors with it.
+//methods without error as last result are passed through unguarded. embedded interfaces are not supported
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+func main() {
+	var (
+		typeName = flag.String("type", "", "name of the interface to decorate, required")
+		dir      = flag.String("dir", ".", "directory of the package declaring the interface")
+		output   = flag.String("output", "", "output file, default <type>_breaker.go in dir")
+	)
+	flag.Parse()
+
+	if *typeName == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if *output == "" {
+		*output = filepath.Join(*dir, strings.ToLower(*typeName)+"_breaker.go")
+	}
+
+	src, err := generate(*dir, *typeName)
+	if err != nil {
+		log.Fatalf("breakergen: %v", err)
+	}
+
+	if err := os.WriteFile(*output, src, 0o644); err != nil {
+		log.Fatalf("breakergen: %v", err)
+	}
+}
+
+//method of the interface to generate
+type method struct {
+	name    string
+	params  []field
+	results []field
+
+	variadic   bool
+	ctxParam   string //name of the context.Context parameter, empty if there is none
+	returnsErr bool
+}
+
+type field struct {
+	name string
+	typ  string
+}
+
+func generate(dir, typeName string) ([]byte, error) {
+	fset := token.NewFileSet()
+	pkgs, err := parser.ParseDir(fset, dir, func(fi os.FileInfo) bool {
+		return !strings.HasSuffix(fi.Name(), "_test.go")
+	}, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, pkg := range pkgs {
+		for _, file := range pkg.Files {
+			for _, decl := range file.Decls {
+				gd, ok := decl.(*ast.GenDecl)
+				if !ok || gd.Tok != token.TYPE {
+					continue
+				}
+
+				for _, spec := range gd.Specs {
+					ts := spec.(*ast.TypeSpec)
+					if ts.Name.Name != typeName {
+						continue
+					}
+
+					it, ok := ts.Type.(*ast.InterfaceType)
+					if !ok {
+						return nil, fmt.Errorf("%s is not an interface", typeName)
+					}
+					if ts.TypeParams != nil {
+						return nil, fmt.Errorf("generic interface %s is not supported", typeName)
+					}
+
+					return render(fset, file, pkg.Name, typeName, it)
+				}
+			}
+		}
+	}
+
+	return nil, fmt.Errorf("interface %s not found in %s", typeName, dir)
+}
+
+func render(fset *token.FileSet, file *ast.File, pkgName, typeName string, it *ast.InterfaceType) ([]byte, error) {
+	ctxName := importName(file, "context")
+
+	var methods []method
+	used := make(map[string]bool)
+	for _, f := range it.Methods.List {
+		ft, ok := f.Type.(*ast.FuncType)
+		if !ok || len(f.Names) == 0 {
+			return nil, errors.New("embedded interfaces are not supported, declare the methods explicitly")
+		}
+		collectPackages(ft, used)
+
+		m := method{name: f.Names[0].Name}
+		m.params, m.variadic = fields(fset, ft.Params, "p")
+		m.results, _ = fields(fset, ft.Results, "r")
+
+		if len(m.params) > 0 && ctxName != "" && m.params[0].typ == ctxName+".Context" {
+			m.ctxParam = m.params[0].name
+		}
+		if n := len(m.results); n > 0 && m.results[n-1].typ == "error" {
+			m.returnsErr = true
+			m.results[n-1].name = "err"
+		}
+
+		methods = append(methods, m)
+	}
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "// Code generated by breakergen -type %s; DO NOT EDIT.\n\n", typeName)
+	fmt.Fprintf(&b, "package %s\n\n", pkgName)
+
+	needContext := ctxName == "context" && used["context"]
+	needTime := importName(file, "time") == "time" && used["time"]
+	for _, m := range methods {
+		needTime = needTime || m.returnsErr
+		needContext = needContext || (m.returnsErr && m.ctxParam == "")
+	}
+
+	var std, other []string
+	if needContext {
+		std = append(std, `"context"`)
+	}
+	if needTime {
+		std = append(std, `"time"`)
+	}
+	other = append(other, `"github.com/carl-leopard/circuitbreaker/breaker"`)
+	for _, spec := range file.Imports {
+		p, _ := strconv.Unquote(spec.Path.Value)
+		name := importName(file, p)
+		//context and time are imported above unless they are renamed
+		if !used[name] || ((p == "context" || p == "time") && name == p) {
+			continue
+		}
+
+		line := strconv.Quote(p)
+		if spec.Name != nil {
+			line = spec.Name.Name + " " + line
+		}
+		if strings.Contains(strings.SplitN(p, "/", 2)[0], ".") {
+			other = append(other, line)
+		} else {
+			std = append(std, line)
+		}
+	}
+	fmt.Fprintf(&b, "import (\n\t%s\n\n\t%s\n)\n\n", strings.Join(std, "\n\t"), strings.Join(other, "\n\t"))
+
+	d := typeName + "Breaker"
+	fmt.Fprintf(&b, "//%s runs methods of %s returning an error under a circuit breaker\n", d, typeName)
+	fmt.Fprintf(&b, "type %s struct {\n\tnext     %s\n\tcb       *breaker.CircuitBreaker\n\tclassify func(err error) breaker.Outcome\n}\n\n", d, typeName)
+
+	fmt.Fprintf(&b, "//New%s decorates next with cb. classify decides whether an error counts as a failure, nil treats every error as failure\n", d)
+	fmt.Fprintf(&b, "func New%s(next %s, cb *breaker.CircuitBreaker, classify func(err error) breaker.Outcome) *%s {\n", d, typeName, d)
+	b.WriteString("\tif classify == nil {\n\t\tclassify = func(err error) breaker.Outcome {\n\t\t\tif err != nil {\n\t\t\t\treturn breaker.OutcomeFailure\n\t\t\t}\n\n\t\t\treturn breaker.OutcomeSuccess\n\t\t}\n\t}\n\n")
+	fmt.Fprintf(&b, "\treturn &%s{next: next, cb: cb, classify: classify}\n}\n\n", d)
+
+	fmt.Fprintf(&b, "var _ %s = (*%s)(nil)\n", typeName, d)
+
+	for _, m := range methods {
+		writeMethod(&b, d, m)
+	}
+
+	src, err := format.Source(b.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("format generated code: %w", err)
+	}
+
+	return src, nil
+}
+
+func writeMethod(b *bytes.Buffer, d string, m method) {
+	params := make([]string, len(m.params))
+	args := make([]string, len(m.params))
+	for i, p := range m.params {
+		params[i] = p.name + " " + p.typ
+		args[i] = p.name
+	}
+	if m.variadic {
+		args[len(args)-1] += "..."
+	}
+
+	results := make([]string, len(m.results))
+	names := make([]string, len(m.results))
+	for i, r := range m.results {
+		results[i] = r.name + " " + r.typ
+		names[i] = r.name
+	}
+
+	call := fmt.Sprintf("d.next.%s(%s)", m.name, strings.Join(args, ", "))
+
+	fmt.Fprintf(b, "\nfunc (d *%s) %s(%s) (%s) {\n", d, m.name, strings.Join(params, ", "), strings.Join(results, ", "))
+	if !m.returnsErr {
+		if len(m.results) == 0 {
+			fmt.Fprintf(b, "\t%s\n\treturn\n}\n", call)
+		} else {
+			fmt.Fprintf(b, "\treturn %s\n}\n", call)
+		}
+		return
+	}
+
+	ctx := "context.Background()"
+	if m.ctxParam != "" {
+		ctx = m.ctxParam
+	}
+
+	b.WriteString("\tif err = d.cb.ReportRequest(); err != nil {\n\t\treturn\n\t}\n\n")
+	b.WriteString("\tstart := time.Now()\n")
+	fmt.Fprintf(b, "\t%s = %s\n", strings.Join(names, ", "), call)
+	b.WriteString("\td.cb.ReportLatency(time.Since(start))\n\n")
+	fmt.Fprintf(b, "\tif d.classify(err) == breaker.OutcomeFailure {\n\t\td.cb.ReportErrorContext(%s)\n\t}\n\n", ctx)
+	b.WriteString("\treturn\n}\n")
+}
+
+//fields flattens fl and names them by prefix and index
+func fields(fset *token.FileSet, fl *ast.FieldList, prefix string) ([]field, bool) {
+	if fl == nil {
+		return nil, false
+	}
+
+	var (
+		out      []field
+		variadic bool
+	)
+	for _, f := range fl.List {
+		t := f.Type
+		if e, ok := t.(*ast.Ellipsis); ok {
+			variadic = true
+			t = &ast.ArrayType{Elt: e.Elt}
+		}
+
+		var buf bytes.Buffer
+		printer.Fprint(&buf, fset, t)
+		typ := buf.String()
+		if variadic {
+			typ = "..." + strings.TrimPrefix(typ, "[]")
+		}
+
+		//named by index, so none collide with each other or names used by the generated code
+		n := len(f.Names)
+		if n == 0 {
+			n = 1
+		}
+		for i := 0; i < n; i++ {
+			out = append(out, field{name: prefix + strconv.Itoa(len(out)), typ: typ})
+		}
+	}
+
+	return out, variadic
+}
+
+//collectPackages records package names referenced by selectors in ft, e.g. "io" of io.Reader
+func collectPackages(ft *ast.FuncType, used map[string]bool) {
+	ast.Inspect(ft, func(n ast.Node) bool {
+		if sel, ok := n.(*ast.SelectorExpr); ok {
+			if id, ok := sel.X.(*ast.Ident); ok {
+				used[id.Name] = true
+			}
+		}
+		return true
+	})
+}
+
+//importName returns the name importPath is referred to in file, empty if it is not imported.
+//without an explicit name it is assumed to be the last path element, ignoring a major version suffix
+func importName(file *ast.File, importPath string) string {
+	for _, spec := range file.Imports {
+		p, _ := strconv.Unquote(spec.Path.Value)
+		if p != importPath {
+			continue
+		}
+		if spec.Name != nil {
+			return spec.Name.Name
+		}
+
+		name := path.Base(p)
+		if strings.HasPrefix(name, "v") {
+			if _, err := strconv.Atoi(name[1:]); err == nil {
+				name = path.Base(path.Dir(p))
+			}
+		}
+		return strings.TrimPrefix(name, "go-")
+	}
+
+	return ""
+}
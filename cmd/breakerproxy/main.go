@@ -0,0 +1,305 @@
+//breakerproxy is a reverse proxy guarding each upstream with a circuit breaker, to put the protection of this
+//library in front of services not written in Go.
+//
+//	breakerproxy -listen :8080 -admin :9090 -route /api/=http://api:8000 -route /=http://web:3000
+//
+//requests are routed by longest path prefix. while the breaker of an upstream is open its requests are answered
+//with 503 and Retry-After. the breaker of a route is named by its upstream host, e.g. api:8000.
+//
+//breakers share the settings of flags, or come from the declarative config file of -config, see breaker.Config.
+//the file is reloaded when it changes, a route whose breaker the config doesn't name passes unguarded.
+//
+//the admin listener serves
+//
+//	GET  /breakers               a dump of all breakers
+//	GET  /metrics                metrics for prometheus
+//	POST /breakers/{name}/mode   mode=force-open|force-closed|shadow|normal, ttl=30s optional, see SetModeFor
+//	POST /reload                 reload of the config file, with -config only
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"os"
+	"os/signal"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/carl-leopard/circuitbreaker/breaker"
+	"github.com/carl-leopard/circuitbreaker/breakerhttp"
+	"github.com/carl-leopard/circuitbreaker/breakerprom"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+//routeFlags collects repeated -route prefix=url flags
+type routeFlags []string
+
+func (r *routeFlags) String() string {
+	return strings.Join(*r, ",")
+}
+
+func (r *routeFlags) Set(v string) error {
+	*r = append(*r, v)
+	return nil
+}
+
+type route struct {
+	prefix string
+	proxy  http.Handler
+}
+
+func main() {
+	var (
+		routes routeFlags
+
+		listen = flag.String("listen", ":8080", "address of proxy")
+		admin  = flag.String("admin", ":9090", "address of admin endpoints, empty to disable")
+		config = flag.String("config", "", "config file of breakers named by upstream host, replaces the breaker flags below")
+
+		refreshInterval = flag.Duration("refresh-interval", 3*time.Minute, "statistical period of breakers")
+		errorThreshold  = flag.String("error-threshold", "20%", "error percent in refresh interval to open a breaker, like 20 or 20%")
+		requestVolume   = flag.Uint("request-volume", 1000, "minimum requests in refresh interval to open a breaker")
+		sleepWindow     = flag.Duration("sleep-window", 3*time.Minute, "how long a breaker stays open before half-open")
+		maxRetryAfter   = flag.Duration("max-retry-after", 0, "honor Retry-After of upstream up to it as sleep window, 0 to ignore")
+	)
+	flag.Var(&routes, "route", "prefix=upstream url, repeatable")
+	flag.Parse()
+
+	if len(routes) == 0 {
+		log.Fatal("breakerproxy: at least one -route is required")
+	}
+	threshold, err := breaker.ParsePercent(*errorThreshold)
+	if err != nil {
+		log.Fatalf("breakerproxy: -error-threshold: %v", err)
+	}
+	if threshold > 100 {
+		log.Fatalf("breakerproxy: -error-threshold %s is over 100%%", *errorThreshold)
+	}
+	if *requestVolume > math.MaxUint32 {
+		log.Fatalf("breakerproxy: -request-volume %d is over %d", *requestVolume, uint32(math.MaxUint32))
+	}
+	settings := breaker.Settings{
+		RefreshInterval:        *refreshInterval,
+		ErrorThresholdPercent:  threshold,
+		RequestVolumeThreshold: uint32(*requestVolume),
+		SleepWindow:            *sleepWindow,
+	}
+	if err := settings.Validate(); err != nil {
+		log.Fatalf("breakerproxy: %v", err)
+	}
+
+	reg := prometheus.NewRegistry()
+	metrics, err := breakerprom.NewMetrics(reg)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	breakers := breaker.NewRegistry()
+	var watcher *breaker.ConfigWatcher
+	if *config != "" {
+		sink := breaker.WithMetricsSinkOf(metrics.Sink)
+		if breakers, err = breaker.LoadConfig(*config, sink); err != nil {
+			log.Fatal(err)
+		}
+		watcher = breaker.WatchConfig(breakers, *config, breaker.WithReloadOptions(sink))
+		go func() {
+			if err := watcher.Run(ctx); err != nil && !errors.Is(err, context.Canceled) {
+				log.Printf("breakerproxy: watch %s: %v", *config, err)
+			}
+		}()
+	}
+	opts := []breaker.CircuitBreakerOption{
+		breaker.WithOpenConfig(breaker.CircuitBreakerOpenConfig{
+			RefreshInterval:        settings.RefreshInterval,
+			ErrorThresholdPercent:  settings.ErrorThresholdPercent,
+			RequestVolumeThreshold: settings.RequestVolumeThreshold,
+		}),
+		breaker.WithSleepWindow(settings.SleepWindow),
+	}
+
+	var rs []route
+	for _, v := range routes {
+		r, err := newRoute(v, breakers, watcher != nil, metrics, opts, *maxRetryAfter)
+		if err != nil {
+			log.Fatal(err)
+		}
+		rs = append(rs, r)
+	}
+	sort.Slice(rs, func(i, j int) bool {
+		return len(rs[i].prefix) > len(rs[j].prefix)
+	})
+
+	proxy := &http.Server{Addr: *listen, Handler: router(rs)}
+	servers := []*http.Server{proxy}
+
+	if *admin != "" {
+		mux := http.NewServeMux()
+		mux.HandleFunc("GET /breakers", func(w http.ResponseWriter, _ *http.Request) {
+			w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+			breakers.Dump(w)
+		})
+		mux.HandleFunc("POST /breakers/{name}/mode", setMode(breakers))
+		if watcher != nil {
+			mux.HandleFunc("POST /reload", reload(watcher))
+		}
+		mux.Handle("GET /metrics", promhttp.HandlerFor(reg, promhttp.HandlerOpts{}))
+		servers = append(servers, &http.Server{Addr: *admin, Handler: mux})
+	}
+
+	for _, s := range servers {
+		go func(s *http.Server) {
+			if err := s.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				log.Fatal(err)
+			}
+		}(s)
+	}
+	log.Printf("breakerproxy: listening on %s, %d routes", *listen, len(rs))
+
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, syscall.SIGINT, syscall.SIGTERM)
+	<-stop
+	cancel()
+
+	shutdown, cancelShutdown := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancelShutdown()
+	for _, s := range servers {
+		s.Shutdown(shutdown)
+	}
+}
+
+//setMode changes mode of the breaker of path value name, for ttl if the form has one
+func setMode(breakers *breaker.Registry) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		cb, ok := breakers.Get(r.PathValue("name"))
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		m, err := breaker.ParseMode(r.FormValue("mode"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		var ttl time.Duration
+		if v := r.FormValue("ttl"); v != "" {
+			if ttl, err = time.ParseDuration(v); err != nil || ttl < 0 {
+				http.Error(w, fmt.Sprintf("invalid ttl %q", v), http.StatusBadRequest)
+				return
+			}
+		}
+
+		cb.SetModeFor(m, ttl)
+		log.Printf("breakerproxy: breaker %s mode %s ttl %s", cb.Name(), m, ttl)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+//reload reloads the config file and writes what changed, one per line
+func reload(watcher *breaker.ConfigWatcher) http.HandlerFunc {
+	return func(w http.ResponseWriter, _ *http.Request) {
+		changes, err := watcher.Reload()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		for _, ch := range changes {
+			fmt.Fprintln(w, ch)
+		}
+	}
+}
+
+//newRoute parses prefix=url, the breaker of the route is named by upstream host and shared by routes to it. of
+//a configured registry it is looked up per request, otherwise it's created with opts
+func newRoute(v string, breakers *breaker.Registry, configured bool, metrics *breakerprom.Metrics, opts []breaker.CircuitBreakerOption, maxRetryAfter time.Duration) (route, error) {
+	prefix, raw, ok := strings.Cut(v, "=")
+	if !ok || prefix == "" {
+		return route{}, fmt.Errorf("breakerproxy: invalid route %q, want prefix=url", v)
+	}
+
+	target, err := url.Parse(raw)
+	if err != nil || target.Host == "" {
+		return route{}, fmt.Errorf("breakerproxy: invalid upstream of route %q", v)
+	}
+
+	name := target.Host
+	var tOpts []breakerhttp.TransportOption
+	if maxRetryAfter > 0 {
+		tOpts = append(tOpts, breakerhttp.WithRetryAfter(maxRetryAfter))
+	}
+
+	var transport http.RoundTripper
+	if configured {
+		if _, ok := breakers.Get(name); !ok {
+			log.Printf("breakerproxy: config has no breaker %s, route %s is not guarded until one is added", name, prefix)
+		}
+		transport = breakerhttp.NewNamedTransport(breakers, name, http.DefaultTransport, tOpts...)
+	} else if cb, ok := breakers.Get(name); ok {
+		transport = breakerhttp.NewTransport(cb, http.DefaultTransport, tOpts...)
+	} else {
+		cbOpts := append([]breaker.CircuitBreakerOption{}, opts...)
+		cbOpts = append(cbOpts, breaker.WithName(name), breaker.WithMetricsSink(metrics.Sink(name)))
+		cb = breaker.New(cbOpts...)
+		if err := breakers.Register(cb); err != nil {
+			return route{}, err
+		}
+		transport = breakerhttp.NewTransport(cb, http.DefaultTransport, tOpts...)
+	}
+
+	return route{
+		prefix: prefix,
+		proxy: &httputil.ReverseProxy{
+			Rewrite: func(pr *httputil.ProxyRequest) {
+				pr.SetURL(target)
+				pr.SetXForwarded()
+			},
+			Transport: transport,
+			ErrorHandler: func(w http.ResponseWriter, r *http.Request, err error) {
+				if errors.Is(err, breaker.ErrTooManyErrors) {
+					secs := 1
+					if cb, ok := breakers.Get(name); ok {
+						//rounded up, so clients don't come back while the breaker is still open
+						if d := cb.RemainingSleepWindow(); d > time.Second {
+							secs = int((d + time.Second - 1) / time.Second)
+						}
+					}
+
+					w.Header().Set("Retry-After", strconv.Itoa(secs))
+					http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+					return
+				}
+
+				log.Printf("breakerproxy: %s %s: %v", r.Method, r.URL.Path, err)
+				w.WriteHeader(http.StatusBadGateway)
+			},
+		},
+	}, nil
+}
+
+func router(rs []route) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for _, rt := range rs {
+			if strings.HasPrefix(r.URL.Path, rt.prefix) {
+				rt.proxy.ServeHTTP(w, r)
+				return
+			}
+		}
+
+		http.NotFound(w, r)
+	})
+}
@@ -0,0 +1,32 @@
+//breakerschema writes the JSON Schema of circuit breaker config files, see breaker.ConfigSchema.
+//
+//	breakerschema > circuitbreaker.schema.json
+//
+//point editors at it, e.g. with a "# yaml-language-server: $schema=circuitbreaker.schema.json" comment, or
+//validate config files with it in CI before deploy
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+
+	"github.com/carl-leopard/circuitbreaker/breaker"
+)
+
+func main() {
+	out := flag.String("o", "", "write schema to file instead of stdout")
+	flag.Parse()
+
+	b := append(breaker.ConfigSchema(), '\n')
+	if *out == "" {
+		if _, err := os.Stdout.Write(b); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	if err := os.WriteFile(*out, b, 0644); err != nil {
+		log.Fatalf("breakerschema: %v", err)
+	}
+}